@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinkDepth is the maximum number of symbolic links which are followed
+// when resolving a path within the destination
+const maxSymlinkDepth = 255
+
+// extractor writes the entries of an archive to a destination directory and
+// guarantees that nothing is written outside of it, neither via relative paths
+// nor via symbolic links contained within the archive.
+type extractor struct {
+	root            string
+	stripComponents int
+}
+
+// newExtractor prepares the extraction of an archive to dst
+func newExtractor(dst string, uc *UnarchiveOptions) (*extractor, error) {
+	root, err := filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create directory: %v", err)
+	}
+
+	// Resolve the destination itself such that symbolic links leading up to it
+	// are not considered an escape
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extractor{
+		root:            root,
+		stripComponents: uc.StripComponents,
+	}, nil
+}
+
+// strip removes the leading path components of an entry name as set by the
+// StripComponents option.  Entries which are entirely stripped are skipped.
+func (ex *extractor) strip(name string) (string, bool) {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) <= ex.stripComponents {
+		return "", false
+	}
+
+	return strings.Join(parts[ex.stripComponents:], "/"), true
+}
+
+// within returns whether the path resides within the destination
+func (ex *extractor) within(path string) bool {
+	rel, err := filepath.Rel(ex.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve walks the slash-separated relative path starting at dir, following
+// any symbolic links which have already been extracted, and returns the
+// resulting location.  An error is returned if the path leaves the destination
+// at any point.
+func (ex *extractor) resolve(dir, rel string, depth int) (string, error) {
+	if depth > maxSymlinkDepth {
+		return "", fmt.Errorf("too many levels of symbolic links: %s", rel)
+	}
+
+	cur := dir
+	for _, part := range strings.Split(rel, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+		default:
+			cur = filepath.Join(cur, part)
+		}
+
+		if !ex.within(cur) {
+			return "", fmt.Errorf("path escapes destination: %s", rel)
+		}
+
+		fi, err := os.Lstat(cur)
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		link, err := os.Readlink(cur)
+		if err != nil {
+			return "", err
+		}
+
+		if filepath.IsAbs(link) {
+			return "", fmt.Errorf("path escapes destination via symbolic link: %s", rel)
+		}
+
+		cur, err = ex.resolve(filepath.Dir(cur), filepath.ToSlash(link), depth+1)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return cur, nil
+}
+
+// path returns the location of the entry with the provided name.  Symbolic
+// links within its parent directories are followed, whereas an existing
+// symbolic link at the location itself is removed such that it is replaced
+// rather than written through.
+func (ex *extractor) path(name string) (string, error) {
+	dir, base := filepath.Split(name)
+
+	parent, err := ex.resolve(ex.root, filepath.ToSlash(dir), 0)
+	if err != nil {
+		return "", err
+	}
+
+	if base == ".." {
+		return "", fmt.Errorf("path escapes destination: %s", name)
+	}
+
+	path := filepath.Join(parent, base)
+
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return "", err
+		}
+	}
+
+	return path, nil
+}
+
+// dir creates the directory entry
+func (ex *extractor) dir(name string, mode os.FileMode) error {
+	path, err := ex.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, mode.Perm()|0o700); err != nil {
+		return fmt.Errorf("could not create directory: %v", err)
+	}
+
+	return nil
+}
+
+// file creates the regular file entry with the contents of the reader
+func (ex *extractor) file(name string, mode os.FileMode, r io.Reader) error {
+	path, err := ex.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create directory: %v", err)
+	}
+
+	newFile, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+
+	if _, err := io.Copy(newFile, r); err != nil {
+		newFile.Close()
+		return fmt.Errorf("could not copy file: %v", err)
+	}
+
+	return newFile.Close()
+}
+
+// symlink creates the symbolic link entry after verifying that its target
+// resides within the destination
+func (ex *extractor) symlink(name, target string) error {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return fmt.Errorf("symbolic link %s points to an absolute path: %s", name, target)
+	}
+
+	path, err := ex.path(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ex.resolve(filepath.Dir(path), filepath.ToSlash(target), 0); err != nil {
+		return fmt.Errorf("symbolic link %s points outside of destination: %s", name, target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create directory: %v", err)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(target, path); err != nil {
+		return fmt.Errorf("could not create symbolic link: %v", err)
+	}
+
+	return nil
+}
+
+// hardlink creates the hard link entry to a previously extracted file
+func (ex *extractor) hardlink(name, target string) error {
+	source, err := ex.resolve(ex.root, target, 0)
+	if err != nil {
+		return fmt.Errorf("hard link %s points outside of destination: %s", name, target)
+	}
+
+	path, err := ex.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create directory: %v", err)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+
+	if err := os.Link(source, path); err != nil {
+		return fmt.Errorf("could not create hard link: %v", err)
+	}
+
+	return nil
+}
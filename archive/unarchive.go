@@ -33,33 +33,153 @@ package archive
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Format represents a supported archive format
+type Format string
+
+const (
+	FormatUnknown = Format("")
+	FormatTar     = Format("tar")
+	FormatTarGz   = Format("tar.gz")
+	FormatTarXz   = Format("tar.xz")
+	FormatTarBz2  = Format("tar.bz2")
+	FormatZip     = Format("zip")
+)
+
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicXz    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	magicBzip2 = []byte("BZh")
+	magicZip   = []byte("PK\x03\x04")
+	magicEmpty = []byte("PK\x05\x06")
+	magicTar   = []byte("ustar")
 )
 
-// Unarchive takes an input src file and determines (based on its extension)
+// extensions maps known file extensions to their archive format
+var extensions = []struct {
+	ext    string
+	format Format
+}{
+	{".tar.gz", FormatTarGz},
+	{".tgz", FormatTarGz},
+	{".tar.xz", FormatTarXz},
+	{".txz", FormatTarXz},
+	{".tar.bz2", FormatTarBz2},
+	{".tbz2", FormatTarBz2},
+	{".tbz", FormatTarBz2},
+	{".tar", FormatTar},
+	{".zip", FormatZip},
+}
+
+// DetectFormat determines the format of the archive at the provided path by
+// first inspecting its magic bytes and then, as a fallback, its extension.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, fmt.Errorf("could not open file: %v", err)
+	}
+
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, fmt.Errorf("could not read file: %v", err)
+	}
+
+	if format := detectMagic(header[:n]); format != FormatUnknown {
+		return format, nil
+	}
+
+	return FormatFromExtension(path), nil
+}
+
+// detectMagic determines the format of an archive by its leading bytes
+func detectMagic(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, magicGzip):
+		return FormatTarGz
+	case bytes.HasPrefix(header, magicXz):
+		return FormatTarXz
+	case bytes.HasPrefix(header, magicBzip2):
+		return FormatTarBz2
+	case bytes.HasPrefix(header, magicZip), bytes.HasPrefix(header, magicEmpty):
+		return FormatZip
+	case len(header) >= 262 && bytes.Equal(header[257:262], magicTar):
+		return FormatTar
+	}
+
+	return FormatUnknown
+}
+
+// FormatFromExtension determines the format of an archive by its extension
+func FormatFromExtension(path string) Format {
+	name := strings.ToLower(filepath.Base(path))
+	for _, e := range extensions {
+		if strings.HasSuffix(name, e.ext) {
+			return e.format
+		}
+	}
+
+	return FormatUnknown
+}
+
+// Unarchive takes an input src file, determines its format and extracts it to
+// the dst directory
 func Unarchive(src, dst string, opts ...UnarchiveOption) error {
-	switch true {
-	case strings.HasSuffix(src, ".tar.gz"):
+	format, err := DetectFormat(src)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatTarGz:
 		return UntarGz(src, dst, opts...)
+	case FormatTarXz:
+		return UntarXz(src, dst, opts...)
+	case FormatTarBz2:
+		return UntarBz2(src, dst, opts...)
+	case FormatTar:
+		return Untar(src, dst, opts...)
+	case FormatZip:
+		return Unzip(src, dst, opts...)
 	}
 
-	return fmt.Errorf("unrecognized extension: %s", filepath.Base(src))
+	return fmt.Errorf("unrecognized archive format: %s", filepath.Base(src))
 }
 
-// UntarGz unarchives a tarball which has been gzip compressed
-func UntarGz(src, dst string, opts ...UnarchiveOption) error {
+// newUnarchiveOptions applies the provided options
+func newUnarchiveOptions(opts ...UnarchiveOption) (*UnarchiveOptions, error) {
 	uc := &UnarchiveOptions{}
 	for _, opt := range opts {
 		if err := opt(uc); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	return uc, nil
+}
+
+// untarCompressed opens the tarball at src, decompresses it with the provided
+// decompressor and extracts it to dst
+func untarCompressed(src, dst string, decompress func(io.Reader) (io.Reader, error), opts ...UnarchiveOption) error {
+	uc, err := newUnarchiveOptions(opts...)
+	if err != nil {
+		return err
+	}
+
 	f, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("could not open file: %v", err)
@@ -67,12 +187,58 @@ func UntarGz(src, dst string, opts ...UnarchiveOption) error {
 
 	defer f.Close()
 
-	gzipReader, err := gzip.NewReader(f)
+	r, err := decompress(f)
 	if err != nil {
-		return fmt.Errorf("could not open gzip reader: %v", err)
+		return err
 	}
 
-	tarReader := tar.NewReader(gzipReader)
+	return untar(tar.NewReader(r), dst, uc)
+}
+
+// UntarGz unarchives a tarball which has been gzip compressed
+func UntarGz(src, dst string, opts ...UnarchiveOption) error {
+	return untarCompressed(src, dst, func(r io.Reader) (io.Reader, error) {
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not open gzip reader: %v", err)
+		}
+
+		return gzipReader, nil
+	}, opts...)
+}
+
+// UntarXz unarchives a tarball which has been xz compressed
+func UntarXz(src, dst string, opts ...UnarchiveOption) error {
+	return untarCompressed(src, dst, func(r io.Reader) (io.Reader, error) {
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not open xz reader: %v", err)
+		}
+
+		return xzReader, nil
+	}, opts...)
+}
+
+// UntarBz2 unarchives a tarball which has been bzip2 compressed
+func UntarBz2(src, dst string, opts ...UnarchiveOption) error {
+	return untarCompressed(src, dst, func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	}, opts...)
+}
+
+// Untar unarchives an uncompressed tarball
+func Untar(src, dst string, opts ...UnarchiveOption) error {
+	return untarCompressed(src, dst, func(r io.Reader) (io.Reader, error) {
+		return r, nil
+	}, opts...)
+}
+
+// untar extracts all entries of the tar reader to dst
+func untar(tarReader *tar.Reader, dst string, uc *UnarchiveOptions) error {
+	ex, err := newExtractor(dst, uc)
+	if err != nil {
+		return err
+	}
 
 	for {
 		header, err := tarReader.Next()
@@ -85,37 +251,38 @@ func UntarGz(src, dst string, opts ...UnarchiveOption) error {
 			return err
 		}
 
-		var path string
-		if uc.StripComponents > 0 {
-			// We don't use the context-(host-)specific filepath.SplitList because
-			// this is a UNIX tarball
-			parts := strings.Split(header.Name, "/")
-			path = strings.Join(parts[uc.StripComponents:], "/")
-			path = filepath.Join(dst, path)
-		} else {
-			path = filepath.Join(dst, header.Name)
+		name, ok := ex.strip(header.Name)
+		if !ok {
+			continue
 		}
 
 		info := header.FileInfo()
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(path, info.Mode()); err != nil {
-				return fmt.Errorf("could not create directory: %v", err)
+			if err := ex.dir(name, info.Mode()); err != nil {
+				return err
 			}
 
 		case tar.TypeReg:
-			newFile, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
-			if err != nil {
-				return fmt.Errorf("could not create file: %v", err)
+			if err := ex.file(name, info.Mode(), tarReader); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if err := ex.symlink(name, header.Linkname); err != nil {
+				return err
 			}
 
-			if _, err := io.Copy(newFile, tarReader); err != nil {
-				newFile.Close()
-				return fmt.Errorf("could not copy file: %v", err)
+		case tar.TypeLink:
+			target, ok := ex.strip(header.Linkname)
+			if !ok {
+				return fmt.Errorf("hard link %s points outside of archive: %s", header.Name, header.Linkname)
 			}
 
-			newFile.Close()
+			if err := ex.hardlink(name, target); err != nil {
+				return err
+			}
 
 			// TODO: Are there any other files we should consider?
 			// default:
@@ -125,3 +292,72 @@ func UntarGz(src, dst string, opts ...UnarchiveOption) error {
 
 	return nil
 }
+
+// Unzip unarchives a zip archive
+func Unzip(src, dst string, opts ...UnarchiveOption) error {
+	uc, err := newUnarchiveOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	zipReader, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("could not open zip reader: %v", err)
+	}
+
+	defer zipReader.Close()
+
+	ex, err := newExtractor(dst, uc)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zipReader.File {
+		name, ok := ex.strip(zf.Name)
+		if !ok {
+			continue
+		}
+
+		mode := zf.Mode()
+
+		switch {
+		case mode.IsDir():
+			err = ex.dir(name, mode)
+
+		case mode&os.ModeSymlink != 0:
+			err = func() error {
+				rc, err := zf.Open()
+				if err != nil {
+					return err
+				}
+
+				defer rc.Close()
+
+				target, err := io.ReadAll(rc)
+				if err != nil {
+					return err
+				}
+
+				return ex.symlink(name, string(target))
+			}()
+
+		case mode.IsRegular():
+			err = func() error {
+				rc, err := zf.Open()
+				if err != nil {
+					return err
+				}
+
+				defer rc.Close()
+
+				return ex.file(name, mode, rc)
+			}()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
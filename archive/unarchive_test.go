@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+type testEntry struct {
+	name     string
+	body     string
+	linkname string
+	typeflag byte
+}
+
+var testEntries = []testEntry{
+	{name: "libfoo-1.0/", typeflag: tar.TypeDir},
+	{name: "libfoo-1.0/Makefile.uk", body: "$(eval $(call addlib,libfoo))\n", typeflag: tar.TypeReg},
+	{name: "libfoo-1.0/include/foo.h", body: "#define FOO 1\n", typeflag: tar.TypeReg},
+	{name: "libfoo-1.0/include/bar.h", linkname: "foo.h", typeflag: tar.TypeSymlink},
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []testEntry) {
+	tw := tar.NewWriter(w)
+
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Linkname: e.linkname,
+			Typeflag: e.typeflag,
+			Mode:     0o644,
+			Size:     int64(len(e.body)),
+		}
+
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0o755
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeArchive(t *testing.T, name string, entries []testEntry) string {
+	var buf bytes.Buffer
+
+	switch FormatFromExtension(name) {
+	case FormatTar:
+		writeTar(t, &buf, entries)
+
+	case FormatTarGz:
+		gw := gzip.NewWriter(&buf)
+		writeTar(t, gw, entries)
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+	case FormatTarXz:
+		xw, err := xz.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTar(t, xw, entries)
+		if err := xw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+	case FormatZip:
+		zw := zip.NewWriter(&buf)
+		for _, e := range entries {
+			hdr := &zip.FileHeader{Name: e.name}
+			body := e.body
+
+			switch e.typeflag {
+			case tar.TypeDir:
+				hdr.SetMode(os.ModeDir | 0o755)
+			case tar.TypeSymlink:
+				hdr.SetMode(os.ModeSymlink | 0o777)
+				body = e.linkname
+			default:
+				hdr.SetMode(0o644)
+			}
+
+			w, err := zw.CreateHeader(hdr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestUnarchiveFormats(t *testing.T) {
+	for _, name := range []string{"libfoo.tar", "libfoo.tar.gz", "libfoo.tar.xz", "libfoo.zip"} {
+		t.Run(name, func(t *testing.T) {
+			src := writeArchive(t, name, testEntries)
+
+			// Detection must not rely on the extension
+			renamed := filepath.Join(filepath.Dir(src), "download")
+			if err := os.Rename(src, renamed); err != nil {
+				t.Fatal(err)
+			}
+
+			format, err := DetectFormat(renamed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if format != FormatFromExtension(name) {
+				t.Fatalf("expected format %s, got %s", FormatFromExtension(name), format)
+			}
+
+			dst := t.TempDir()
+			if err := Unarchive(renamed, dst, StripComponents(1)); err != nil {
+				t.Fatal(err)
+			}
+
+			contents, err := ioutil.ReadFile(filepath.Join(dst, "include", "bar.h"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(contents) != "#define FOO 1\n" {
+				t.Errorf("unexpected contents: %s", contents)
+			}
+
+			if _, err := os.Stat(filepath.Join(dst, "Makefile.uk")); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUnarchiveEscapes(t *testing.T) {
+	for name, entries := range map[string][]testEntry{
+		"traversal": {
+			{name: "../evil", body: "evil", typeflag: tar.TypeReg},
+		},
+		"absolute symlink": {
+			{name: "link", linkname: "/etc", typeflag: tar.TypeSymlink},
+		},
+		"relative symlink": {
+			{name: "link", linkname: "../../etc", typeflag: tar.TypeSymlink},
+		},
+		"write through symlink": {
+			{name: "link", linkname: ".", typeflag: tar.TypeSymlink},
+			{name: "link/../evil", body: "evil", typeflag: tar.TypeReg},
+		},
+		"chained symlink": {
+			{name: "a", linkname: ".", typeflag: tar.TypeSymlink},
+			{name: "b", linkname: "a/..", typeflag: tar.TypeSymlink},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := writeArchive(t, "evil.tar", entries)
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "dst")
+
+			if err := Unarchive(src, dst); err == nil {
+				t.Fatal("expected extraction to fail")
+			}
+
+			if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+				t.Fatal("file was written outside of destination")
+			}
+		})
+	}
+}
@@ -35,6 +35,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -57,6 +59,7 @@ import (
 
 	// Subcommands
 	"kraftkit.sh/cmd/kraft/build/clean"
+	configcmd "kraftkit.sh/cmd/kraft/build/config"
 	"kraftkit.sh/cmd/kraft/build/configure"
 	"kraftkit.sh/cmd/kraft/build/fetch"
 	"kraftkit.sh/cmd/kraft/build/menuconfig"
@@ -91,6 +94,7 @@ type buildOptions struct {
 func BuildCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd, err := cmdutil.NewCmd(f, "build",
 		cmdutil.WithSubcmds(
+			configcmd.ConfigCmd(f),
 			configure.ConfigureCmd(f),
 			fetch.FetchCmd(f),
 			menuconfig.MenuConfigCmd(f),
@@ -120,6 +124,11 @@ func BuildCmd(f *cmdfactory.Factory) *cobra.Command {
 
 		The default behaviour of %[1]skraft build%[1]s is to build a project.  Given no
 		arguments, you will be guided through interactive mode.
+
+		When a project has multiple targets, the sources of their components are
+		first fetched and prepared for each target in turn.  Unikraft's build system
+		places them within the build directory of each target, so they are fetched
+		once per target.  The targets are then built in parallel.
 	`, "`")
 	cmd.Example = heredoc.Doc(`
 		# Build the current project (cwd)
@@ -200,7 +209,7 @@ func BuildCmd(f *cmdfactory.Factory) *cobra.Command {
 		&opts.Jobs,
 		"jobs", "j",
 		0,
-		"Allow N jobs at once",
+		"Allow N jobs at once, divided amongst the targets built in parallel once the sources of each target are fetched",
 	)
 
 	cmd.Flags().StringVar(
@@ -253,7 +262,7 @@ func buildRun(opts *buildOptions, workdir string) error {
 	}
 
 	if !schema.IsWorkdirInitialized(workdir) {
-		return fmt.Errorf("cannot build uninitialized project! start with: kraft build init")
+		return fmt.Errorf("cannot build uninitialized project! start with: kraft init")
 	}
 
 	// Interpret the application
@@ -310,18 +319,75 @@ func buildRun(opts *buildOptions, workdir string) error {
 		plog.SetOutput(ioutil.Discard)
 	}
 
+	// Fetch and prepare the sources of every target up front and one after the
+	// other, such that the targets can subsequently be built concurrently without
+	// contending over the network or the project's sources.  The sources are
+	// fetched into the output directory of each target and thus once per target.
+	var errs []error
 	var processes []*paraprogress.Process
-	var mopts []make.MakeOption
 
-	if opts.Jobs > 0 {
-		mopts = append(mopts, make.WithJobs(opts.Jobs))
-	} else {
-		mopts = append(mopts, make.WithMaxJobs(opts.Fast))
+	for i, targ := range targets {
+		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
+		i, targ := i, targ
+		errs = append(errs, nil)
+
+		processes = append(processes, paraprogress.NewProcess(
+			fmt.Sprintf("preparing %s (%s)", targ.Name(), targ.ArchPlatString()),
+			func(l log.Logger, w func(progress float64)) error {
+				targ.ApplyOptions(
+					component.WithLogger(l),
+				)
+
+				errs[i] = project.PrepareTargets(
+					app.WithBuildLogger(l),
+					app.WithBuildTarget(targ),
+					app.WithBuildProgressFunc(w),
+					app.WithBuildNoSyncConfig(opts.NoSyncConfig),
+					app.WithBuildLogFile(opts.SaveBuildLog),
+				)
+
+				return errs[i]
+			},
+		))
+	}
+
+	if err := runProcesses(processes, false, norender, plog); err != nil {
+		return err
 	}
 
-	for _, targ := range targets {
+	if err := targetErrors("prepare", targets, errs); err != nil {
+		return err
+	}
+
+	// Each target is built within its own output directory using its own
+	// configuration and can therefore be built in parallel, in which case the
+	// jobs are divided amongst the targets
+	parallel := !cfgm.Config.NoParallel
+
+	builds := 1
+	if parallel {
+		builds = len(targets)
+	}
+
+	jobs := opts.Jobs
+	if jobs == 0 && opts.Fast && builds > 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	split := splitJobs(jobs, builds)
+
+	processes = nil
+
+	for i, targ := range targets {
 		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
-		targ := targ
+		i, targ := i, targ
+
+		var mopts []make.MakeOption
+		if jobs > 0 {
+			mopts = append(mopts, make.WithJobs(split[i%builds]))
+		} else {
+			mopts = append(mopts, make.WithMaxJobs(opts.Fast))
+		}
 
 		processes = append(processes, paraprogress.NewProcess(
 			fmt.Sprintf("building %s (%s)", targ.Name(), targ.ArchPlatString()),
@@ -332,26 +398,33 @@ func buildRun(opts *buildOptions, workdir string) error {
 					component.WithLogger(l),
 				)
 
-				return project.Build(
+				errs[i] = project.Build(
 					app.WithBuildLogger(l),
 					app.WithBuildTarget(targ),
 					app.WithBuildProgressFunc(w),
 					app.WithBuildMakeOptions(mopts...),
-					app.WithBuildNoSyncConfig(opts.NoSyncConfig),
+					app.WithBuildNoPrepare(true),
 					app.WithBuildLogFile(opts.SaveBuildLog),
 				)
+
+				return errs[i]
 			},
 		))
 	}
 
+	if err := runProcesses(processes, parallel, norender, plog); err != nil {
+		return err
+	}
+
+	return targetErrors("build", targets, errs)
+}
+
+// runProcesses renders the progress of the provided processes until they have
+// all exited
+func runProcesses(processes []*paraprogress.Process, parallel, norender bool, plog log.Logger) error {
 	model, err := paraprogress.NewParaProgress(
 		processes,
-		// Disable parallelization as:
-		//  - The first process may be pulling the container image, which is
-		//    necessary for the subsequent build steps;
-		//  - The Unikraft build system can re-use compiled files from previous
-		//    compilations (if the architecture does not change).
-		paraprogress.IsParallel(false),
+		paraprogress.IsParallel(parallel),
 		paraprogress.WithRenderer(norender),
 		paraprogress.WithLogger(plog),
 	)
@@ -361,3 +434,45 @@ func buildRun(opts *buildOptions, workdir string) error {
 
 	return model.Start()
 }
+
+// targetErrors returns an error describing the targets whose action failed,
+// if any
+func targetErrors(action string, targets []target.TargetConfig, errs []error) error {
+	var failed []string
+
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", targets[i].Name(), err))
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("could not %s %s", action, failed[0])
+	}
+
+	return fmt.Errorf("could not %s %d targets: %s", action, len(failed), strings.Join(failed, "; "))
+}
+
+// splitJobs divides the number of jobs amongst the number of builds, such
+// that each build is allowed at least one job
+func splitJobs(jobs, builds int) []int {
+	var split []int
+
+	for i := 0; i < builds; i++ {
+		n := jobs / builds
+		if i < jobs%builds {
+			n++
+		}
+
+		if n == 0 {
+			n = 1
+		}
+
+		split = append(split, n)
+	}
+
+	return split
+}
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/kconfig"
+	"kraftkit.sh/log"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/schema"
+	"kraftkit.sh/utils"
+)
+
+type DiffOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	Logger         func() (log.Logger, error)
+	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	Target   string
+	Output   string
+	ExitCode bool
+	Strict   bool
+}
+
+// TargetDiff lists the differences between the configuration requested by the
+// Kraftfile for a target and its `.config` file
+type TargetDiff struct {
+	Target      string       `json:"target" yaml:"target"`
+	KConfig     string       `json:"kconfig" yaml:"kconfig"`
+	Differences []Difference `json:"differences" yaml:"differences"`
+}
+
+// Difference is a symbol whose value differs from the requested value
+type Difference struct {
+	Status    kconfig.DiffKind `json:"status" yaml:"status"`
+	Symbol    string           `json:"symbol" yaml:"symbol"`
+	Requested string           `json:"requested,omitempty" yaml:"requested,omitempty"`
+	Actual    string           `json:"actual,omitempty" yaml:"actual,omitempty"`
+	Origin    string           `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Reason    string           `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func DiffCmd(f *cmdfactory.Factory) *cobra.Command {
+	opts := &DiffOptions{
+		PackageManager: f.PackageManager,
+		Logger:         f.Logger,
+		IO:             f.IOStreams,
+	}
+
+	cmd, err := cmdutil.NewCmd(f, "diff")
+	if err != nil {
+		panic("could not initialize 'kraft build config diff' commmand")
+	}
+
+	cmd.Short = "Compare the configuration of targets with the Kraftfile"
+	cmd.Use = "diff [FLAGS] [DIR]"
+	cmd.Args = cmdutil.MaxDirArgs(1)
+	cmd.Long = heredoc.Docf(`
+		Compare the KConfig options requested by the Kraftfile with the %[1]s.config%[1]s
+		of each target.
+
+		The options of the Unikraft core, libraries, application, architecture,
+		platform and target are merged as they are when building the target.  An
+		option is reported as:
+
+		  missing     if it was requested but is not present;
+		  overridden  if it was requested but has a different value, e.g. because
+		              its dependencies are not satisfied;
+		  extra       if it was not requested but differs from its default value.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Compare the configuration of all targets of the cwd project
+		$ kraft build config diff
+
+		# Compare the configuration of a particular target of a project at a path
+		$ kraft build config diff -t qemu-x86_64 path/to/app
+
+		# Fail if the configuration of any target has drifted, e.g. in CI
+		$ kraft build config diff --exit-code
+
+		# Also fail on options which were not requested but changed
+		$ kraft build config diff --exit-code --strict
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var workdir string
+
+		if len(args) == 0 {
+			workdir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		} else {
+			workdir = args[0]
+		}
+
+		return diffRun(opts, workdir)
+	}
+
+	cmd.Flags().StringVarP(
+		&opts.Target,
+		"target", "t",
+		"",
+		"Compare a particular known target",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Output,
+		"output", "o",
+		"",
+		"Set the output format (json, yaml)",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.ExitCode,
+		"exit-code",
+		false,
+		"Exit with a non-zero status if a requested option of any target is missing or overridden",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.Strict,
+		"strict",
+		false,
+		"With --exit-code, also consider extra options which were not requested",
+	)
+
+	return cmd
+}
+
+func diffRun(opts *DiffOptions, workdir string) error {
+	switch opts.Output {
+	case "", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	plog, err := opts.Logger()
+	if err != nil {
+		return err
+	}
+
+	// Initialize at least the configuration options for a project
+	projectOpts, err := schema.NewProjectOptions(
+		nil,
+		schema.WithLogger(plog),
+		schema.WithWorkingDirectory(workdir),
+		schema.WithDefaultConfigPath(),
+		schema.WithPackageManager(&pm),
+		schema.WithResolvedPaths(true),
+		schema.WithDotConfig(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Interpret the application
+	project, err := schema.NewApplicationFromOptions(projectOpts)
+	if err != nil {
+		return err
+	}
+
+	var diffs []TargetDiff
+	drifted := 0
+
+	for _, targ := range project.Targets {
+		targ := targ
+		if len(opts.Target) > 0 && targ.Name() != opts.Target {
+			continue
+		}
+
+		tree, err := project.KConfigTree(targ.Name())
+		if err != nil {
+			return err
+		}
+
+		dc, err := kconfig.NewDotConfigFromFile(targ.KConfigFile())
+		if os.IsNotExist(err) {
+			return fmt.Errorf("target %s has not been configured yet: %s does not exist", targ.Name(), targ.KConfigFile())
+		} else if err != nil {
+			return err
+		}
+
+		requested := map[string]string{}
+		for k, v := range project.TargetKConfig(&targ) {
+			requested[k] = *v
+		}
+
+		origins := project.TargetKConfigOrigins(&targ)
+
+		diff := TargetDiff{
+			Target:      targ.Name(),
+			KConfig:     targ.KConfigFile(),
+			Differences: []Difference{},
+		}
+
+		differs := false
+
+		for _, d := range kconfig.Compare(tree, requested, dc) {
+			// Options which were not requested only count as drift when asked to
+			if d.Kind != kconfig.DiffExtra || opts.Strict {
+				differs = true
+			}
+
+			diff.Differences = append(diff.Differences, Difference{
+				Status:    d.Kind,
+				Symbol:    d.Name,
+				Requested: d.Requested,
+				Actual:    d.Actual,
+				Origin:    origins[d.Name],
+				Reason:    d.Reason,
+			})
+		}
+
+		if differs {
+			drifted++
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	if len(diffs) == 0 {
+		if len(opts.Target) > 0 {
+			return fmt.Errorf("unknown target: %s", opts.Target)
+		}
+
+		return fmt.Errorf("project has no targets")
+	}
+
+	var out []byte
+
+	switch opts.Output {
+	case "json":
+		out, err = json.MarshalIndent(diffs, "", "  ")
+		out = append(out, '\n')
+	case "yaml":
+		out, err = yaml.Marshal(diffs)
+	default:
+		err = printDiffs(opts.IO, diffs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := opts.IO.Out.Write(out); err != nil {
+		return err
+	}
+
+	if opts.ExitCode && drifted > 0 {
+		return fmt.Errorf("the configuration of %d target(s) differs from the Kraftfile", drifted)
+	}
+
+	return nil
+}
+
+// printDiffs prints a table of the differences of each target
+func printDiffs(io *iostreams.IOStreams, diffs []TargetDiff) error {
+	cs := io.ColorScheme()
+
+	for i, diff := range diffs {
+		if i > 0 {
+			fmt.Fprintln(io.Out)
+		}
+
+		fmt.Fprintf(io.Out, "%s (%s)\n", cs.Bold(diff.Target), diff.KConfig)
+
+		if len(diff.Differences) == 0 {
+			fmt.Fprintln(io.Out, "no differences")
+			continue
+		}
+
+		table := utils.NewTablePrinter(io)
+		table.AddField("STATUS", nil, cs.Bold)
+		table.AddField("SYMBOL", nil, cs.Bold)
+		table.AddField("REQUESTED", nil, cs.Bold)
+		table.AddField("ACTUAL", nil, cs.Bold)
+		table.AddField("ORIGIN", nil, cs.Bold)
+		table.AddField("REASON", nil, cs.Bold)
+		table.EndRow()
+
+		for _, d := range diff.Differences {
+			color := cs.Yellow
+			if d.Status == kconfig.DiffMissing {
+				color = cs.Red
+			} else if d.Status == kconfig.DiffExtra {
+				color = cs.Cyan
+			}
+
+			table.AddField(string(d.Status), nil, color)
+			table.AddField(d.Symbol, nil, nil)
+			table.AddField(d.Requested, nil, nil)
+			table.AddField(d.Actual, nil, nil)
+			table.AddField(d.Origin, nil, nil)
+			table.AddField(d.Reason, nil, nil)
+			table.EndRow()
+		}
+
+		if err := table.Render(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
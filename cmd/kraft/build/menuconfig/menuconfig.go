@@ -32,7 +32,9 @@
 package menuconfig
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -41,16 +43,25 @@ import (
 	"kraftkit.sh/internal/cmdfactory"
 	"kraftkit.sh/internal/cmdutil"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/kconfig"
 	"kraftkit.sh/log"
 	"kraftkit.sh/make"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/schema"
+	"kraftkit.sh/tui/menuconfig"
+	"kraftkit.sh/unikraft/component"
 )
 
 type MenuConfigOptions struct {
 	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
 	Logger         func() (log.Logger, error)
 	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	Target string
+	Set    []string
+	Dump   bool
+	Make   bool
 }
 
 func MenuConfigCmd(f *cmdfactory.Factory) *cobra.Command {
@@ -66,17 +77,35 @@ func MenuConfigCmd(f *cmdfactory.Factory) *cobra.Command {
 	}
 
 	cmd.Short = "menuconfig open's Unikraft configuration editor TUI"
-	cmd.Use = "menuconfig [DIR]"
+	cmd.Use = "menuconfig [FLAGS] [DIR]"
 	cmd.Aliases = []string{"m", "menu"}
 	cmd.Args = cmdutil.MaxDirArgs(1)
 	cmd.Long = heredoc.Doc(`
-		Open Unikraft's configuration editor TUI`)
+		Open Unikraft's configuration editor TUI
+
+		The configuration options of the project are browsed and changed against
+		the KConfig menu tree of Unikraft, its libraries and the application.
+		Saved changes are written to the kconfig section of the Unikraft core or,
+		if a target is provided, of that target in the Kraftfile, as well as to
+		the .config file if it exists.
+
+		With --set, the options are changed without opening the TUI.  With --dump,
+		the resulting .config file is printed instead of saving any changes.`)
 	cmd.Example = heredoc.Doc(`
 		# Open the menuconfig in the cwd project
 		$ kraft build menuconfig
 		
 		# Open the menuconfig for a project at a path
 		$ kraft build menu path/to/app
+
+		# Open the menuconfig of a particular target of the cwd project
+		$ kraft build menuconfig -t qemu-x86_64
+
+		# Change options of the cwd project without opening the TUI
+		$ kraft build menuconfig --set LIBUKDEBUG_PRINTK_INFO=y
+
+		# Print the resulting .config file without saving any changes
+		$ kraft build menuconfig --set LIBUKDEBUG_PRINTK_INFO=y --dump
 	`)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		workdir := ""
@@ -93,6 +122,34 @@ func MenuConfigCmd(f *cmdfactory.Factory) *cobra.Command {
 		return menuConfigRun(opts, workdir)
 	}
 
+	cmd.Flags().StringVarP(
+		&opts.Target,
+		"target", "t",
+		"",
+		"Configure a particular target instead of the Unikraft core",
+	)
+
+	cmd.Flags().StringArrayVar(
+		&opts.Set,
+		"set",
+		[]string{},
+		"Set a configuration option (NAME=VALUE) without opening the TUI",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.Dump,
+		"dump",
+		false,
+		"Print the resulting .config file instead of saving any changes",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.Make,
+		"make",
+		false,
+		"Open Unikraft's own editor, which only changes the .config file",
+	)
+
 	return cmd
 }
 
@@ -127,11 +184,125 @@ func menuConfigRun(mcopts *MenuConfigOptions, workdir string) error {
 		return err
 	}
 
-	return project.Make(
-		make.WithExecOptions(
-			exec.WithStdin(mcopts.IO.In),
-			exec.WithStdout(mcopts.IO.Out),
-		),
-		make.WithTarget("menuconfig"),
+	if mcopts.Make {
+		return project.Make(
+			make.WithExecOptions(
+				exec.WithStdin(mcopts.IO.In),
+				exec.WithStdout(mcopts.IO.Out),
+			),
+			make.WithTarget("menuconfig"),
+		)
+	}
+
+	dotconfig, err := project.TargetKConfigFile(mcopts.Target)
+	if err != nil {
+		return err
+	}
+
+	tree, err := project.KConfigTree(mcopts.Target)
+	if err != nil {
+		return err
+	}
+
+	// The options requested by the section of the Kraftfile which is edited
+	// and those which result from merging all sections
+	title := project.Name()
+	section := project.Unikraft.Configuration
+	merged := project.Unikraft.Configuration
+
+	for _, targ := range project.Targets {
+		if targ.Name() == mcopts.Target {
+			title = fmt.Sprintf("%s (%s)", project.Name(), targ.Name())
+			section = targ.Configuration
+			merged = project.TargetKConfig(&targ)
+			break
+		}
+	}
+
+	// Start from the current configuration or otherwise from the options
+	// requested by the Kraftfile
+	configured := true
+	dc, err := kconfig.NewDotConfigFromFile(dotconfig)
+	if os.IsNotExist(err) {
+		configured = false
+		dc = kconfig.NewDotConfig()
+
+		for name, value := range values(merged) {
+			dc.Set(name, value)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	var requested []string
+	for name := range values(section) {
+		requested = append(requested, name)
+	}
+
+	mc, err := menuconfig.NewMenuConfig(tree, dc,
+		menuconfig.WithTitle(title),
+		menuconfig.WithRequested(requested...),
 	)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range mcopts.Set {
+		k, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("invalid or malformed argument: %s", value)
+		}
+
+		if err := mc.Set(k, v); err != nil {
+			return err
+		}
+	}
+
+	if mcopts.Dump {
+		return mc.Dump(mcopts.IO.Out)
+	}
+
+	if len(mcopts.Set) == 0 {
+		saved, err := mc.Start()
+		if err != nil {
+			return err
+		}
+
+		if !saved {
+			return nil
+		}
+	}
+
+	changes := mc.Changes()
+	if len(changes) == 0 {
+		plog.Info("no changes to save")
+		return nil
+	}
+
+	if err := project.SaveKConfig(mcopts.Target, changes); err != nil {
+		return fmt.Errorf("could not save configuration: %v", err)
+	}
+
+	if configured {
+		if err := dc.WriteFile(dotconfig); err != nil {
+			return err
+		}
+	}
+
+	plog.Infof("saved %d option(s) to %s", len(changes), project.Filename)
+
+	return nil
+}
+
+// values returns the configuration options which are assigned a value
+func values(kc component.KConfig) map[string]string {
+	m := map[string]string{}
+
+	for name, value := range kc {
+		if value != nil {
+			m[name] = *value
+		}
+	}
+
+	return m
 }
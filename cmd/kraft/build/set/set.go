@@ -39,12 +39,10 @@ import (
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 
-	"kraftkit.sh/exec"
 	"kraftkit.sh/internal/cmdfactory"
 	"kraftkit.sh/internal/cmdutil"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
-	"kraftkit.sh/make"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/schema"
 )
@@ -56,6 +54,7 @@ type SetOptions struct {
 
 	// Command-line arguments
 	Workdir string
+	Target  string
 }
 
 func SetCmd(f *cmdfactory.Factory) *cobra.Command {
@@ -74,13 +73,21 @@ func SetCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd.Use = "set [OPTIONS] [param=value ...]"
 	cmd.Aliases = []string{"s"}
 	cmd.Long = heredoc.Doc(`
-		set a variable for a Unikraft project`)
+		Set a variable for a Unikraft project.
+
+		The variables are validated against the project's KConfig options and
+		saved in the kconfig section of the unikraft component of the Kraftfile,
+		or of the target if one is provided, such that subsequent builds use them.
+		The project's or the target's .config is updated as well if it exists.`)
 	cmd.Example = heredoc.Doc(`
 		# Set variables in the cwd project
 		$ kraft build set LIBDEVFS_DEV_STDOUT=/dev/null LWIP_TCP_SND_BUF=4096
 
 		# Set variables in a project at a path
 		$ kraft build set -w path/to/app LIBDEVFS_DEV_STDOUT=/dev/null LWIP_TCP_SND_BUF=4096
+
+		# Set variables of a particular target of the cwd project
+		$ kraft build set -t qemu-x86_64 LIBUKDEBUG_PRINTK_INFO=y
 	`)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		workdir := ""
@@ -120,6 +127,13 @@ func SetCmd(f *cmdfactory.Factory) *cobra.Command {
 		"Work on a unikernel at a path",
 	)
 
+	cmd.Flags().StringVarP(
+		&opts.Target,
+		"target", "t",
+		"",
+		"Set the variables of a particular target instead of the unikraft component",
+	)
+
 	return cmd
 }
 
@@ -134,15 +148,6 @@ func setRun(copts *SetOptions, workdir string, confOpts []string) error {
 		return err
 	}
 
-	// Check if dotconfig exists in workdir
-	dotconfig := fmt.Sprintf("%s/.config", workdir)
-
-	// Check if the file exists
-	// TODO: offer option to start in interactive mode
-	if _, err := os.Stat(dotconfig); os.IsNotExist(err) {
-		return fmt.Errorf("dotconfig file does not exist: %s", dotconfig)
-	}
-
 	// Initialize at least the configuration options for a project
 	projectOpts, err := schema.NewProjectOptions(
 		nil,
@@ -151,8 +156,6 @@ func setRun(copts *SetOptions, workdir string, confOpts []string) error {
 		schema.WithDefaultConfigPath(),
 		schema.WithPackageManager(&pm),
 		schema.WithResolvedPaths(true),
-		schema.WithDotConfig(true),
-		schema.WithConfig(confOpts),
 	)
 	if err != nil {
 		return err
@@ -164,9 +167,5 @@ func setRun(copts *SetOptions, workdir string, confOpts []string) error {
 		return err
 	}
 
-	return project.Set(
-		make.WithExecOptions(
-			exec.WithStdin(copts.IO.In),
-		),
-	)
+	return project.Set(copts.Target, confOpts...)
 }
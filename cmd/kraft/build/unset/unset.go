@@ -38,12 +38,10 @@ import (
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 
-	"kraftkit.sh/exec"
 	"kraftkit.sh/internal/cmdfactory"
 	"kraftkit.sh/internal/cmdutil"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
-	"kraftkit.sh/make"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/schema"
 )
@@ -55,6 +53,7 @@ type UnsetOptions struct {
 
 	// Command-line arguments
 	Workdir string
+	Target  string
 }
 
 func UnsetCmd(f *cmdfactory.Factory) *cobra.Command {
@@ -73,13 +72,21 @@ func UnsetCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd.Use = "unset [OPTIONS] [param ...]"
 	cmd.Aliases = []string{"u"}
 	cmd.Long = heredoc.Doc(`
-		unset a variable for a Unikraft project`)
+		Unset a variable for a Unikraft project.
+
+		The variables are removed from the kconfig section of the unikraft
+		component of the Kraftfile, or of the target if one is provided, and from
+		the project's or the target's .config, if it exists, such that they take
+		their default values again.`)
 	cmd.Example = heredoc.Doc(`
 		# Unset variables in the cwd project
 		$ kraft build unset LIBDEVFS_DEV_STDOUT LWIP_TCP_SND_BUF
 
 		# Unset variables in a project at a path
 		$ kraft build unset -w path/to/app LIBDEVFS_DEV_STDOUT LWIP_TCP_SND_BUF
+
+		# Unset variables of a particular target of the cwd project
+		$ kraft build unset -t qemu-x86_64 LIBUKDEBUG_PRINTK_INFO
 	`)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		workdir := ""
@@ -101,7 +108,7 @@ func UnsetCmd(f *cmdfactory.Factory) *cobra.Command {
 		}
 
 		for _, arg := range args {
-			confOpts = append(confOpts, arg+"=n")
+			confOpts = append(confOpts, arg)
 		}
 
 		return unsetRun(opts, workdir, confOpts)
@@ -114,6 +121,13 @@ func UnsetCmd(f *cmdfactory.Factory) *cobra.Command {
 		"Work on a unikernel at a path",
 	)
 
+	cmd.Flags().StringVarP(
+		&opts.Target,
+		"target", "t",
+		"",
+		"Unset the variables of a particular target instead of the unikraft component",
+	)
+
 	return cmd
 }
 
@@ -128,15 +142,6 @@ func unsetRun(copts *UnsetOptions, workdir string, confOpts []string) error {
 		return err
 	}
 
-	// Check if dotconfig exists in workdir
-	dotconfig := fmt.Sprintf("%s/.config", workdir)
-
-	// Check if the file exists
-	// TODO: offer option to start in interactive mode
-	if _, err := os.Stat(dotconfig); os.IsNotExist(err) {
-		return fmt.Errorf("dotconfig file does not exist: %s", dotconfig)
-	}
-
 	// Initialize at least the configuration options for a project
 	projectOpts, err := schema.NewProjectOptions(
 		nil,
@@ -145,8 +150,6 @@ func unsetRun(copts *UnsetOptions, workdir string, confOpts []string) error {
 		schema.WithDefaultConfigPath(),
 		schema.WithPackageManager(&pm),
 		schema.WithResolvedPaths(true),
-		schema.WithDotConfig(true),
-		schema.WithConfig(confOpts),
 	)
 	if err != nil {
 		return err
@@ -158,9 +161,5 @@ func unsetRun(copts *UnsetOptions, workdir string, confOpts []string) error {
 		return err
 	}
 
-	return project.Unset(
-		make.WithExecOptions(
-			exec.WithStdin(copts.IO.In),
-		),
-	)
+	return project.Unset(copts.Target, confOpts...)
 }
@@ -0,0 +1,726 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package init
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/internal/yamlnode"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/schema"
+	"kraftkit.sh/tui/paraprogress"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/arch"
+
+	// Additional initializers
+	_ "kraftkit.sh/manifest"
+)
+
+const (
+	// DefaultFileName is the name of the Kraftfile written for new projects
+	DefaultFileName = "Kraftfile"
+
+	// DefaultSpecification is the version of the Kraftfile specification
+	// written for new projects
+	DefaultSpecification = "0.5"
+
+	// DefaultVersion is the version of the Unikraft core and libraries used when
+	// none is requested
+	DefaultVersion = "stable"
+
+	// DefaultPlatform is the platform targeted when none is requested
+	DefaultPlatform = "kvm"
+)
+
+type initOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	ConfigManager  func() (*config.ConfigManager, error)
+	Logger         func() (log.Logger, error)
+	HttpClient     func() (*http.Client, error)
+	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	Name          string
+	Template      string
+	Unikraft      string
+	Libraries     []string
+	Architectures []string
+	Platforms     []string
+	Pull          bool
+	Force         bool
+	NoChecksum    bool
+}
+
+// target is a single entry of the target matrix written to the Kraftfile
+type target struct {
+	Name         string `yaml:"name"`
+	Architecture string `yaml:"architecture"`
+	Platform     string `yaml:"platform"`
+}
+
+func InitCmd(f *cmdfactory.Factory) *cobra.Command {
+	cmd, err := cmdutil.NewCmd(f, "init")
+	if err != nil {
+		panic("could not initialize 'init' commmand")
+	}
+
+	opts := &initOptions{
+		PackageManager: f.PackageManager,
+		ConfigManager:  f.ConfigManager,
+		Logger:         f.Logger,
+		HttpClient:     f.HttpClient,
+		IO:             f.IOStreams,
+	}
+
+	cmd.Short = "Initialize a new Unikraft project"
+	cmd.Use = "init [FLAGS] [DIR]"
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.Long = heredoc.Docf(`
+		Initialize a new Unikraft project.
+
+		A Kraftfile is written to the project directory which declares the version
+		of the Unikraft core, its libraries and a target for every combination of
+		the requested architectures and platforms.  A project can alternatively be
+		bootstrapped from an application template, which is any %[1]sapp%[1]s
+		package found in the package index.
+
+		The Kraftfile is validated against the specification before it is written.
+		With %[1]s--pull%[1]s, the Unikraft core and libraries are retrieved via
+		the package manager into the %[1]s.unikraft/%[1]s directory of the project.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Initialize a project in the current working directory
+		$ kraft init
+
+		# Initialize a project at a path targeting multiple architectures
+		$ kraft init --arch x86_64,arm64 --plat kvm path/to/app
+
+		# Initialize a project with libraries and pull its dependencies
+		$ kraft init --lib musl --lib lwip:stable --pull
+
+		# Bootstrap a project from an application template
+		$ kraft init --template nginx:stable path/to/app
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var err error
+		var workdir string
+		if len(args) == 0 {
+			workdir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		} else {
+			workdir = args[0]
+		}
+
+		return initRun(opts, workdir, cmd.Flags().Changed)
+	}
+
+	cmd.Flags().StringVarP(
+		&opts.Name,
+		"name", "n",
+		"",
+		"Name of the project (default is the name of the directory)",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Template,
+		"template", "t",
+		"",
+		"Bootstrap the project from an application template (NAME[:VERSION])",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Unikraft,
+		"unikraft", "u",
+		DefaultVersion,
+		"Version of the Unikraft core",
+	)
+
+	cmd.Flags().StringSliceVarP(
+		&opts.Libraries,
+		"lib", "l",
+		[]string{},
+		"Add a library to the project (NAME[:VERSION])",
+	)
+
+	cmd.Flags().StringSliceVarP(
+		&opts.Architectures,
+		"arch", "m",
+		[]string{arch.HostArchitecture()},
+		"Architectures of the target matrix",
+	)
+
+	cmd.Flags().StringSliceVarP(
+		&opts.Platforms,
+		"plat", "p",
+		[]string{DefaultPlatform},
+		"Platforms of the target matrix",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.Pull,
+		"pull",
+		false,
+		"Pull the Unikraft core and libraries into the project",
+	)
+
+	cmd.Flags().BoolVarP(
+		&opts.Force,
+		"force", "F",
+		false,
+		"Overwrite an existing Kraftfile and existing files of the template",
+	)
+
+	cmd.Flags().BoolVarP(
+		&opts.NoChecksum,
+		"no-checksum", "C",
+		false,
+		"Do not verify package checksum (if available)",
+	)
+
+	return cmd
+}
+
+func initRun(opts *initOptions, workdir string, changed func(string) bool) error {
+	var err error
+
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	plog, err := opts.Logger()
+	if err != nil {
+		return err
+	}
+
+	cfgm, err := opts.ConfigManager()
+	if err != nil {
+		return err
+	}
+
+	workdir, err = filepath.Abs(workdir)
+	if err != nil {
+		return err
+	}
+
+	if schema.IsWorkdirInitialized(workdir) && !opts.Force {
+		return fmt.Errorf("project is already initialized: %s (use --force to overwrite)", workdir)
+	}
+
+	if err := os.MkdirAll(workdir, 0o755); err != nil {
+		return fmt.Errorf("could not create project directory: %v", err)
+	}
+
+	norender := logger.LoggerTypeFromString(cfgm.Config.Log.Type) != logger.FANCY
+
+	if len(opts.Template) > 0 {
+		if err := pullTemplate(pm, plog, norender, opts, workdir); err != nil {
+			return err
+		}
+	}
+
+	kraftfile, found := schema.FindConfigFile(workdir)
+	if !found {
+		kraftfile = filepath.Join(workdir, DefaultFileName)
+	}
+
+	// Templates provide their own Kraftfile which is only amended by the
+	// explicitly requested options, otherwise a new one is generated
+	doc := &yaml.Node{
+		Kind: yaml.DocumentNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.MappingNode, Tag: "!!map"},
+		},
+	}
+
+	if len(opts.Template) > 0 {
+		if !found {
+			return fmt.Errorf("template %s does not contain a Kraftfile", opts.Template)
+		}
+
+		data, err := os.ReadFile(kraftfile)
+		if err != nil {
+			return fmt.Errorf("could not read Kraftfile of template: %v", err)
+		}
+
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return fmt.Errorf("could not parse Kraftfile of template: %v", err)
+		}
+
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			return fmt.Errorf("Kraftfile of template is not a map")
+		}
+	}
+
+	root := doc.Content[0]
+
+	if yamlnode.Lookup(root, "specification") == nil {
+		if err := setValue(root, "specification", DefaultSpecification); err != nil {
+			return err
+		}
+
+		// Keep the specification at the top of the file
+		root.Content = append(root.Content[len(root.Content)-2:], root.Content[:len(root.Content)-2]...)
+	}
+
+	name := opts.Name
+	if len(name) == 0 {
+		name = filepath.Base(workdir)
+	}
+
+	if err := setValue(root, "name", name); err != nil {
+		return err
+	}
+
+	if len(opts.Template) == 0 || changed("unikraft") {
+		if err := setValue(root, "unikraft", opts.Unikraft); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Libraries) > 0 {
+		libraries := yamlnode.Lookup(root, "libraries")
+		if libraries == nil || libraries.Kind != yaml.MappingNode {
+			libraries = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := setValue(root, "libraries", libraries); err != nil {
+				return err
+			}
+		}
+
+		for _, value := range opts.Libraries {
+			libName, version, err := parseNameVersion(value, unikraft.ComponentTypeLib)
+			if err != nil {
+				return fmt.Errorf("could not parse --lib flag with value %s: %v", value, err)
+			}
+
+			if err := setValue(libraries, libName, version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(opts.Template) == 0 || changed("arch") || changed("plat") {
+		targets, err := targetMatrix(name, opts.Architectures, opts.Platforms)
+		if err != nil {
+			return err
+		}
+
+		if err := setValue(root, "targets", targets); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("could not serialize Kraftfile: %v", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("could not serialize Kraftfile: %v", err)
+	}
+
+	data := buf.Bytes()
+
+	// Validate the result against the specification before writing it such that
+	// subsequent commands are able to interpret the project
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("could not parse generated Kraftfile: %v", err)
+	}
+
+	if err := schema.Validate(raw); err != nil {
+		return fmt.Errorf("invalid Kraftfile: %v", err)
+	}
+
+	if err := os.WriteFile(kraftfile, data, 0o644); err != nil {
+		return fmt.Errorf("could not write Kraftfile: %v", err)
+	}
+
+	plog.Infof("initialized %s in %s", name, kraftfile)
+
+	if opts.Pull {
+		return pullComponents(pm, plog, norender, opts, workdir)
+	}
+
+	return nil
+}
+
+// pullTemplate retrieves the application template via the package manager and
+// copies its contents into the project directory
+func pullTemplate(pm packmanager.PackageManager, plog log.Logger, norender bool, opts *initOptions, workdir string) error {
+	name, version, err := parseNameVersion(opts.Template, unikraft.ComponentTypeApp)
+	if err != nil {
+		return fmt.Errorf("could not parse --template flag with value %s: %v", opts.Template, err)
+	}
+
+	packages, err := pm.Catalog(packmanager.CatalogQuery{
+		Types:   []unikraft.ComponentType{unikraft.ComponentTypeApp},
+		Name:    name,
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+
+	var template pack.Package
+	for _, p := range packages {
+		if p.Options().Type == unikraft.ComponentTypeApp && p.Options().Name == name {
+			template = p
+			break
+		}
+	}
+
+	if template == nil {
+		available, err := pm.Catalog(packmanager.CatalogQuery{
+			Types: []unikraft.ComponentType{unikraft.ComponentTypeApp},
+		})
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		for _, p := range available {
+			names = append(names, p.Options().Name)
+		}
+
+		sort.Strings(names)
+
+		if len(names) == 0 {
+			return fmt.Errorf("could not find template %s: the package index contains no application templates", opts.Template)
+		}
+
+		return fmt.Errorf("could not find template %s, available templates: %s", opts.Template, strings.Join(names, ", "))
+	}
+
+	// Pull the template into a temporary project such that its contents can be
+	// copied to the root of the new project
+	tmp, err := os.MkdirTemp("", "kraft-init-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if _, err := pullPackages([]pack.Package{template}, plog, norender, opts, tmp); err != nil {
+		return err
+	}
+
+	src, err := unikraft.PlaceComponent(tmp, unikraft.ComponentTypeApp, template.Options().Name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("could not pull template %s: %v", opts.Template, err)
+	}
+
+	return copyTree(src, workdir, opts.Force, plog)
+}
+
+// pullComponents retrieves the Unikraft core and libraries of the newly
+// initialized project into its `.unikraft/` directory and records them in the
+// project's lockfile
+func pullComponents(pm packmanager.PackageManager, plog log.Logger, norender bool, opts *initOptions, workdir string) error {
+	projectOpts, err := schema.NewProjectOptions(
+		nil,
+		schema.WithLogger(plog),
+		schema.WithWorkingDirectory(workdir),
+		schema.WithDefaultConfigPath(),
+		schema.WithPackageManager(&pm),
+		schema.WithResolvedPaths(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	project, err := schema.NewApplicationFromOptions(projectOpts)
+	if err != nil {
+		return err
+	}
+
+	var packages []pack.Package
+	var versions []string
+
+	for _, c := range project.Components() {
+		query := packmanager.CatalogQuery{
+			Name:    c.Name(),
+			Version: c.Version(),
+			Types:   []unikraft.ComponentType{c.Type()},
+		}
+
+		next, err := pm.Catalog(query)
+		if err != nil {
+			return err
+		}
+
+		if len(next) == 0 {
+			plog.Warnf("could not find %s", query.String())
+			continue
+		}
+
+		for _, p := range next {
+			packages = append(packages, p)
+			versions = append(versions, c.Version())
+		}
+	}
+
+	pulled, err := pullPackages(packages, plog, norender, opts, workdir)
+	if err != nil {
+		return err
+	}
+
+	lockfile := &schema.Lockfile{}
+	for i, p := range packages {
+		if pulled[i] {
+			lockfile.SetPackage(versions[i], p)
+		}
+	}
+
+	return lockfile.Save(schema.LockfilePath(workdir))
+}
+
+// pullPackages pulls the provided packages into the working directory and
+// returns which of them were pulled successfully
+func pullPackages(packages []pack.Package, plog log.Logger, norender bool, opts *initOptions, workdir string) ([]bool, error) {
+	var processes []*paraprogress.Process
+
+	pulled := make([]bool, len(packages))
+
+	client, err := opts.HttpClient()
+	if err != nil {
+		return pulled, err
+	}
+
+	for i, p := range packages {
+		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
+		i, p := i, p
+
+		processes = append(processes, paraprogress.NewProcess(
+			fmt.Sprintf("pulling %s", p.Options().TypeNameVersion()),
+			func(l log.Logger, w func(progress float64)) error {
+				// Apply the incoming logger which is tailored to display as a
+				// sub-terminal within the fancy processtree.
+				p.ApplyOptions(
+					pack.WithLogger(l),
+				)
+
+				if err := p.Pull(
+					pack.WithPullProgressFunc(w),
+					pack.WithPullWorkdir(workdir),
+					pack.WithPullLogger(l),
+					pack.WithPullChecksum(!opts.NoChecksum),
+					pack.WithPullCache(true),
+					pack.WithPullHTTPClient(client),
+				); err != nil {
+					return err
+				}
+
+				pulled[i] = true
+
+				return nil
+			},
+		))
+	}
+
+	if len(processes) == 0 {
+		return pulled, nil
+	}
+
+	// Silence the logger whilst the processes are rendered
+	if !norender {
+		output := plog.Output()
+		plog.SetOutput(ioutil.Discard)
+		defer plog.SetOutput(output)
+	}
+
+	model, err := paraprogress.NewParaProgress(
+		processes,
+		paraprogress.IsParallel(true),
+		paraprogress.WithRenderer(norender),
+		paraprogress.WithLogger(plog),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pulled, model.Start()
+}
+
+// parseNameVersion parses the NAME[:VERSION] syntax of a component of the
+// given type.  The default version is used when the version is omitted.
+func parseNameVersion(value string, ctype unikraft.ComponentType) (string, string, error) {
+	t, name, version, err := unikraft.GuessTypeNameVersion(value)
+	if err != nil {
+		return "", "", err
+	}
+
+	if t != unikraft.ComponentTypeUnknown && t != ctype {
+		return "", "", fmt.Errorf("expected %s but received %s", ctype, t)
+	}
+
+	if len(name) == 0 {
+		return "", "", fmt.Errorf("cannot ommit name")
+	}
+
+	if len(version) == 0 && ctype != unikraft.ComponentTypeApp {
+		version = DefaultVersion
+	}
+
+	return name, version, nil
+}
+
+// targetMatrix returns a target for every combination of the provided
+// architectures and platforms
+func targetMatrix(name string, archs, plats []string) ([]target, error) {
+	var targets []target
+
+	for _, plat := range plats {
+		for _, a := range archs {
+			if len(a) == 0 {
+				return nil, fmt.Errorf("cannot determine architecture of host, use --arch")
+			}
+
+			targets = append(targets, target{
+				Name:         fmt.Sprintf("%s-%s-%s", name, plat, a),
+				Architecture: a,
+				Platform:     plat,
+			})
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("at least one architecture and platform is required")
+	}
+
+	return targets, nil
+}
+
+// setValue sets the value of the key within a YAML mapping node, retaining the
+// position of the key if it already exists
+func setValue(mapping *yaml.Node, key string, value interface{}) error {
+	node, ok := value.(*yaml.Node)
+	if !ok {
+		node = &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return fmt.Errorf("could not encode %s: %v", key, err)
+		}
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = node
+			return nil
+		}
+	}
+
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		node,
+	)
+
+	return nil
+}
+
+// copyTree copies the contents of the source directory into the destination
+// directory.  Existing files are only overwritten when forced.
+func copyTree(src, dst string, force bool, plog log.Logger) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return os.MkdirAll(target, 0o755)
+		}
+
+		if _, err := os.Lstat(target); err == nil {
+			if !force {
+				plog.Warnf("skipping existing file: %s", target)
+				return nil
+			}
+
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
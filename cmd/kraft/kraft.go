@@ -40,7 +40,9 @@ import (
 	"kraftkit.sh/internal/cmdutil"
 
 	"kraftkit.sh/cmd/kraft/build"
+	initcmd "kraftkit.sh/cmd/kraft/init"
 	"kraftkit.sh/cmd/kraft/pkg"
+	"kraftkit.sh/cmd/kraft/run"
 )
 
 func main() {
@@ -49,8 +51,10 @@ func main() {
 	)
 	cmd, err := cmdutil.NewCmd(f, "kraft",
 		cmdutil.WithSubcmds(
+			initcmd.InitCmd(f),
 			pkg.PkgCmd(f),
 			build.BuildCmd(f),
+			run.RunCmd(f),
 		),
 	)
 	if err != nil {
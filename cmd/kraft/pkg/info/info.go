@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package info
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/store"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/utils"
+)
+
+type InfoOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	ConfigManager  func() (*config.ConfigManager, error)
+	Logger         func() (log.Logger, error)
+	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	Output string
+}
+
+// PackageInfo is the detailed description of a package as it is printed
+type PackageInfo struct {
+	Type        unikraft.ComponentType `json:"type" yaml:"type"`
+	Name        string                 `json:"name" yaml:"name"`
+	Version     string                 `json:"version" yaml:"version"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Git         string                 `json:"git,omitempty" yaml:"git,omitempty"`
+	Origin      string                 `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Channels    []ChannelInfo          `json:"channels,omitempty" yaml:"channels,omitempty"`
+	Versions    []VersionInfo          `json:"versions,omitempty" yaml:"versions,omitempty"`
+}
+
+// ChannelInfo describes a channel of a package and its local cache state
+type ChannelInfo struct {
+	Name     string `json:"name" yaml:"name"`
+	Default  bool   `json:"default" yaml:"default"`
+	Latest   string `json:"latest,omitempty" yaml:"latest,omitempty"`
+	Sha256   string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Resource string `json:"resource,omitempty" yaml:"resource,omitempty"`
+	Cached   bool   `json:"cached" yaml:"cached"`
+}
+
+// VersionInfo describes a version of a package and its local cache state
+type VersionInfo struct {
+	Version  string `json:"version" yaml:"version"`
+	Type     string `json:"type,omitempty" yaml:"type,omitempty"`
+	Sha256   string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Unikraft string `json:"unikraft,omitempty" yaml:"unikraft,omitempty"`
+	Resource string `json:"resource,omitempty" yaml:"resource,omitempty"`
+	Cached   bool   `json:"cached" yaml:"cached"`
+}
+
+func InfoCmd(f *cmdfactory.Factory) *cobra.Command {
+	opts := &InfoOptions{
+		PackageManager: f.PackageManager,
+		ConfigManager:  f.ConfigManager,
+		Logger:         f.Logger,
+		IO:             f.IOStreams,
+	}
+
+	cmd, err := cmdutil.NewCmd(f, "info")
+	if err != nil {
+		panic("could not initialize 'kraft pkg info' commmand")
+	}
+
+	cmd.Short = "Show detailed information about a Unikraft component package"
+	cmd.Use = "info [FLAGS] [TYPE/]NAME[:VERSION]"
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Long = heredoc.Docf(`
+		Show detailed information about a Unikraft component package.
+
+		The package is looked up in the local catalog of packages, which can be
+		refreshed with %[1]skraft pkg update%[1]s.  All channels and versions of the
+		package are shown along with where they are retrieved from, whether they
+		are present in the local cache and where the package was found.  When a
+		version or channel is provided, it is shown as the selected version.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Show information about the Unikraft core
+		$ kraft pkg info unikraft
+
+		# Show information about a specific version of a library
+		$ kraft pkg info lib/musl:1.0.0
+
+		# Print the information as JSON
+		$ kraft pkg info lib/lwip --output json
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return infoRun(opts, args[0])
+	}
+
+	cmd.Flags().StringVarP(
+		&opts.Output,
+		"output", "o",
+		"",
+		"Set the output format (json, yaml)",
+	)
+
+	return cmd
+}
+
+func infoRun(opts *InfoOptions, query string) error {
+	switch opts.Output {
+	case "", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	cfgm, err := opts.ConfigManager()
+	if err != nil {
+		return err
+	}
+
+	t, n, v, err := unikraft.GuessTypeNameVersion(query)
+	if err != nil {
+		return err
+	}
+
+	cquery := packmanager.CatalogQuery{
+		Name:    n,
+		Version: v,
+	}
+	if t != unikraft.ComponentTypeUnknown {
+		cquery.Types = []unikraft.ComponentType{t}
+	}
+
+	packages, err := pm.Catalog(cquery)
+	if err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		return fmt.Errorf("could not find %s", query)
+	}
+
+	// The packages in the catalog only represent the selected version, so
+	// retrieve the complete manifests to describe all channels and versions
+	mpm := pm
+	if pm.String() != "manifest" {
+		mpm, _ = pm.From("manifest")
+	}
+
+	var manifests []*manifest.Manifest
+	if mm, ok := mpm.(manifest.ManifestManager); ok {
+		manifests, err = mm.Manifests()
+		if err != nil {
+			return err
+		}
+	}
+
+	st := store.NewStore(cfgm.Config.Paths.Sources)
+
+	var infos []PackageInfo
+	for _, p := range packages {
+		infos = append(infos, packageInfo(p, manifests, st))
+	}
+
+	var out []byte
+
+	switch opts.Output {
+	case "json":
+		if len(infos) == 1 {
+			out, err = json.MarshalIndent(infos[0], "", "  ")
+		} else {
+			out, err = json.MarshalIndent(infos, "", "  ")
+		}
+		out = append(out, '\n')
+	case "yaml":
+		if len(infos) == 1 {
+			out, err = yaml.Marshal(infos[0])
+		} else {
+			out, err = yaml.Marshal(infos)
+		}
+	default:
+		for i, info := range infos {
+			if i > 0 {
+				fmt.Fprintln(opts.IO.Out)
+			}
+
+			if err := printInfo(opts.IO, info); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not marshal package information: %v", err)
+	}
+
+	_, err = opts.IO.Out.Write(out)
+	return err
+}
+
+// packageInfo describes the package using the complete manifest of the same
+// type and name, if there is one
+func packageInfo(p pack.Package, manifests []*manifest.Manifest, st *store.Store) PackageInfo {
+	info := PackageInfo{
+		Type:    p.Options().Type,
+		Name:    p.Name(),
+		Version: p.Options().Version,
+	}
+
+	var m *manifest.Manifest
+	for _, candidate := range manifests {
+		if candidate.Type == info.Type && candidate.Name == info.Name {
+			m = candidate
+			break
+		}
+	}
+
+	if m == nil {
+		return info
+	}
+
+	info.Description = strings.TrimSpace(m.Description)
+	info.Git = m.GitRepo
+	info.Origin = m.SourceOrigin
+
+	for _, channel := range m.Channels {
+		info.Channels = append(info.Channels, ChannelInfo{
+			Name:     channel.Name,
+			Default:  channel.Default,
+			Latest:   channel.Latest,
+			Sha256:   channel.Sha256,
+			Resource: channel.Resource,
+			Cached:   cached(channel.Local, channel.Sha256, st),
+		})
+	}
+
+	for _, version := range m.Versions {
+		info.Versions = append(info.Versions, VersionInfo{
+			Version:  version.Version,
+			Type:     string(version.Type),
+			Sha256:   version.Sha256,
+			Unikraft: version.Unikraft,
+			Resource: version.Resource,
+			Cached:   cached(version.Local, version.Sha256, st),
+		})
+	}
+
+	return info
+}
+
+// cached returns whether the resource is present in the local cache, either as
+// a downloaded archive or unpacked within the source store
+func cached(local, sha256 string, st *store.Store) bool {
+	if len(sha256) > 0 && st.Has(sha256) {
+		return true
+	}
+
+	if len(local) == 0 {
+		return false
+	}
+
+	_, err := os.Stat(local)
+	return err == nil
+}
+
+// printInfo prints the human-readable description of the package
+func printInfo(io *iostreams.IOStreams, info PackageInfo) error {
+	cs := io.ColorScheme()
+
+	fmt.Fprintf(io.Out, "%s\n", cs.Bold(fmt.Sprintf("%s/%s:%s", info.Type, info.Name, info.Version)))
+
+	if len(info.Description) > 0 {
+		fmt.Fprintf(io.Out, "\n%s\n", info.Description)
+	}
+
+	fmt.Fprintln(io.Out)
+
+	for _, field := range [][2]string{
+		{"Origin", info.Origin},
+		{"Git", info.Git},
+	} {
+		if len(field[1]) > 0 {
+			fmt.Fprintf(io.Out, "%s: %s\n", cs.Bold(field[0]), field[1])
+		}
+	}
+
+	if len(info.Channels) > 0 {
+		fmt.Fprintf(io.Out, "\n%s\n", cs.Bold("Channels"))
+
+		table := utils.NewTablePrinter(io)
+		table.AddField("NAME", nil, cs.Bold)
+		table.AddField("DEFAULT", nil, cs.Bold)
+		table.AddField("LATEST", nil, cs.Bold)
+		table.AddField("CACHED", nil, cs.Bold)
+		table.AddField("SHA256", nil, cs.Bold)
+		table.AddField("RESOURCE", nil, cs.Bold)
+		table.EndRow()
+
+		for _, channel := range info.Channels {
+			table.AddField(channel.Name, nil, nil)
+			table.AddField(yesNo(channel.Default), nil, nil)
+			table.AddField(channel.Latest, nil, nil)
+			table.AddField(yesNo(channel.Cached), nil, nil)
+			table.AddField(channel.Sha256, nil, nil)
+			table.AddField(channel.Resource, nil, nil)
+			table.EndRow()
+		}
+
+		if err := table.Render(); err != nil {
+			return err
+		}
+	}
+
+	if len(info.Versions) > 0 {
+		fmt.Fprintf(io.Out, "\n%s\n", cs.Bold("Versions"))
+
+		table := utils.NewTablePrinter(io)
+		table.AddField("VERSION", nil, cs.Bold)
+		table.AddField("TYPE", nil, cs.Bold)
+		table.AddField("UNIKRAFT", nil, cs.Bold)
+		table.AddField("CACHED", nil, cs.Bold)
+		table.AddField("SHA256", nil, cs.Bold)
+		table.AddField("RESOURCE", nil, cs.Bold)
+		table.EndRow()
+
+		for _, version := range info.Versions {
+			table.AddField(version.Version, nil, nil)
+			table.AddField(version.Type, nil, nil)
+			table.AddField(version.Unikraft, nil, nil)
+			table.AddField(yesNo(version.Cached), nil, nil)
+			table.AddField(version.Sha256, nil, nil)
+			table.AddField(version.Resource, nil, nil)
+			table.EndRow()
+		}
+
+		if err := table.Render(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -32,8 +32,14 @@
 package list
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
 	"kraftkit.sh/config"
 
@@ -55,7 +61,8 @@ type ListOptions struct {
 	IO             *iostreams.IOStreams
 
 	LimitResults int
-	AsJSON       bool
+	NoLimit      bool
+	Output       string
 	Update       bool
 	ShowCore     bool
 	ShowArchs    bool
@@ -83,11 +90,28 @@ func ListCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd.Args = cmdutil.MaxDirArgs(1)
 	cmd.Long = heredoc.Doc(`
 		List installed Unikraft component packages.
+
+		By default packages are listed in a table.  Alternatively, all the details
+		of each package can be printed as JSON or YAML, or each package can be
+		formatted with a Go template which is provided the package options, e.g.
+		{{.Type}}, {{.Name}}, {{.Version}}, {{.RemoteLocation}} or {{.Sha256}}.
 	`)
 	cmd.Example = heredoc.Doc(`
 		$ kraft pkg list
+
+		# List all libraries as JSON
+		$ kraft pkg list --libs --no-limit --output json
+
+		# Print the name and version of each package
+		$ kraft pkg list --output 'template={{.Name}} {{.Version}}'
 	`)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// Structured output is meant to be consumed by other programs, so only
+		// truncate it when a limit was explicitly requested.
+		if opts.NoLimit || (len(opts.Output) > 0 && !cmd.Flags().Changed("limit")) {
+			opts.LimitResults = -1
+		}
+
 		workdir := ""
 		if len(args) > 0 {
 			workdir = args[0]
@@ -99,19 +123,22 @@ func ListCmd(f *cmdfactory.Factory) *cobra.Command {
 		&opts.LimitResults,
 		"limit", "l",
 		30,
-		"Maximum number of items to print (-1 returns all)",
+		"Maximum number of items to print, only applied to --output formats when set (-1 returns all)",
 	)
 
-	noLimitResults := false
 	cmd.Flags().BoolVarP(
-		&noLimitResults,
+		&opts.NoLimit,
 		"no-limit", "T",
 		false,
 		"Do not limit the number of items to print",
 	)
-	if noLimitResults {
-		opts.LimitResults = -1
-	}
+
+	cmd.Flags().StringVarP(
+		&opts.Output,
+		"output", "o",
+		"",
+		"Set the output format (json, yaml, template=<go template>)",
+	)
 
 	cmd.Flags().BoolVarP(
 		&opts.Update,
@@ -161,6 +188,15 @@ func ListCmd(f *cmdfactory.Factory) *cobra.Command {
 func listRun(opts *ListOptions, workdir string) error {
 	var err error
 
+	format, tmpl, err := parseOutput(opts.Output)
+	if err != nil {
+		return err
+	}
+
+	if len(format) > 0 && len(workdir) > 0 {
+		return fmt.Errorf("the --output flag is not supported when listing the packages of a project")
+	}
+
 	pm, err := opts.PackageManager()
 	if err != nil {
 		return err
@@ -218,6 +254,15 @@ func listRun(opts *ListOptions, workdir string) error {
 		}
 	}
 
+	if opts.LimitResults >= 0 && len(packages) > opts.LimitResults {
+		plog.Infof("showing %d of %d packages, use --no-limit to list all", opts.LimitResults, len(packages))
+		packages = packages[:opts.LimitResults]
+	}
+
+	if len(format) > 0 {
+		return printPackages(opts, packages, format, tmpl)
+	}
+
 	err = opts.IO.StartPager()
 	if err != nil {
 		plog.Errorf("error starting pager: %v", err)
@@ -248,3 +293,66 @@ func listRun(opts *ListOptions, workdir string) error {
 
 	return nil
 }
+
+// parseOutput validates the requested output format and parses the template
+// of the `template=` format
+func parseOutput(output string) (string, *template.Template, error) {
+	switch {
+	case len(output) == 0, output == "json", output == "yaml":
+		return output, nil, nil
+
+	case strings.HasPrefix(output, "template="):
+		tmpl, err := template.New("package").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(strings.TrimPrefix(output, "template="))
+		if err != nil {
+			return "", nil, fmt.Errorf("could not parse output template: %v", err)
+		}
+
+		return "template", tmpl, nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported output format: %s", output)
+}
+
+// printPackages prints the options of the packages in the structured format
+func printPackages(opts *ListOptions, packages []pack.Package, format string, tmpl *template.Template) error {
+	options := make([]*pack.PackageOptions, 0, len(packages))
+	for _, p := range packages {
+		options = append(options, p.Options())
+	}
+
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(options, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not marshal packages: %v", err)
+		}
+
+		_, err = fmt.Fprintf(opts.IO.Out, "%s\n", b)
+		return err
+
+	case "yaml":
+		b, err := yaml.Marshal(options)
+		if err != nil {
+			return fmt.Errorf("could not marshal packages: %v", err)
+		}
+
+		_, err = opts.IO.Out.Write(b)
+		return err
+	}
+
+	// Templates are executed for every package and separated by newlines
+	for _, o := range options {
+		if err := tmpl.Execute(opts.IO.Out, o); err != nil {
+			return fmt.Errorf("could not execute output template: %v", err)
+		}
+
+		fmt.Fprintln(opts.IO.Out)
+	}
+
+	return nil
+}
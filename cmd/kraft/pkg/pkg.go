@@ -35,6 +35,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -49,18 +50,25 @@ import (
 	"kraftkit.sh/initrd"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/tui/processtree"
+	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 
+	"kraftkit.sh/cmd/kraft/pkg/info"
 	"kraftkit.sh/cmd/kraft/pkg/list"
+	"kraftkit.sh/cmd/kraft/pkg/prune"
 	"kraftkit.sh/cmd/kraft/pkg/pull"
+	"kraftkit.sh/cmd/kraft/pkg/push"
+	"kraftkit.sh/cmd/kraft/pkg/search"
 	"kraftkit.sh/cmd/kraft/pkg/source"
 	"kraftkit.sh/cmd/kraft/pkg/update"
 
 	// Additional initializers
-	_ "kraftkit.sh/manifest"
+	_ "kraftkit.sh/oci"
 )
 
 type pkgOptions struct {
@@ -81,13 +89,22 @@ type pkgOptions struct {
 	Volumes      []string
 	KernelDbg    bool
 	WithDbg      bool
+	Source       string
+	Name         string
+	OutputDir    string
+	ResourceURL  string
+	Channel      string
 }
 
 func PkgCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd, err := cmdutil.NewCmd(f, "pkg",
 		cmdutil.WithSubcmds(
+			info.InfoCmd(f),
 			list.ListCmd(f),
+			prune.PruneCmd(f),
 			pull.PullCmd(f),
+			push.PushCmd(f),
+			search.SearchCmd(f),
 			source.SourceCmd(f),
 			update.UpdateCmd(f),
 		),
@@ -134,12 +151,32 @@ func PkgCmd(f *cmdfactory.Factory) *cobra.Command {
 
 		# Same as above but also save the resulting CPIO artifact locally
 		$ kraft pkg --initrd ./root-fs:./root-fs.cpio .
+
+		# Package the source of a library into a tarball and manifest
+		$ kraft pkg --source path/to/lib-musl --name lib/musl:1.2.3 --output dist/
 	`)
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if (len(opts.Architecture) > 0 || len(opts.Platform) > 0) && len(opts.Target) > 0 {
 			return fmt.Errorf("the `--arch` and `--plat` options are not supported in addition to `--target`")
 		}
 
+		if len(opts.Source) > 0 && (len(args) > 0 ||
+			len(opts.Target) > 0 ||
+			len(opts.Architecture) > 0 ||
+			len(opts.Platform) > 0 ||
+			len(opts.Kernel) > 0 ||
+			len(opts.Initrd) > 0 ||
+			len(opts.Volumes) > 0) {
+			return fmt.Errorf("the `--source` option is not supported with a project directory or its targets")
+		}
+
+		if len(opts.Source) == 0 && (len(opts.Name) > 0 ||
+			len(opts.OutputDir) > 0 ||
+			len(opts.ResourceURL) > 0 ||
+			len(opts.Channel) > 0) {
+			return fmt.Errorf("the `--name`, `--output`, `--url` and `--channel` options require `--source`")
+		}
+
 		var err error
 		var workdir string
 		if len(args) == 0 {
@@ -230,7 +267,42 @@ func PkgCmd(f *cmdfactory.Factory) *cobra.Command {
 		&opts.Volumes,
 		"volumes", "v",
 		[]string{},
-		"Additional volumes to bundle within the package",
+		"Additional volumes to bundle within the package (e.g. --volumes fs0=./rootfs)",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Source,
+		"source",
+		"",
+		"Package the source directory of a component into a tarball and manifest instead of a project",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Name,
+		"name",
+		"",
+		"Type, name and version of the packaged source (e.g. lib/musl:1.2.3)",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.OutputDir,
+		"output",
+		"",
+		"Directory to write the tarball and manifest of the source to (default is the parent of the source)",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.ResourceURL,
+		"url",
+		"",
+		"Base URL which the tarball of the source is published at",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Channel,
+		"channel",
+		"",
+		"Channel which points to the packaged version of the source",
 	)
 
 	return cmd
@@ -249,6 +321,11 @@ func pkgRun(opts *pkgOptions, workdir string) error {
 		return err
 	}
 
+	// Sources are packaged into tarballs which are described by manifests
+	if len(opts.Source) > 0 && opts.Format == "auto" {
+		opts.Format = "manifest"
+	}
+
 	// Force a particular package manager
 	if len(opts.Format) > 0 && opts.Format != "auto" {
 		pm, err = pm.From(opts.Format)
@@ -257,6 +334,79 @@ func pkgRun(opts *pkgOptions, workdir string) error {
 		}
 	}
 
+	var packages []pack.Package
+	if len(opts.Source) > 0 {
+		packages, err = initSourcePackage(pm, opts)
+	} else {
+		packages, err = initProjectPackages(pm, plog, workdir, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		plog.Info("nothing to package")
+		return nil
+	}
+
+	cfgm, err := opts.ConfigManager()
+	if err != nil {
+		return err
+	}
+
+	parallel := !cfgm.Config.NoParallel
+	norender := logger.LoggerTypeFromString(cfgm.Config.Log.Type) != logger.FANCY
+	if norender {
+		parallel = false
+	} else {
+		plog.SetOutput(ioutil.Discard)
+	}
+
+	var tree []*processtree.ProcessTreeItem
+	for _, p := range packages {
+		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
+		p := p
+
+		tree = append(tree, processtree.NewProcessTreeItem(
+			"Packaging "+p.CanonicalName(),
+			p.Options().ArchPlatString(),
+			func(l log.Logger) error {
+				// Apply the incoming logger which is tailored to display as a
+				// sub-terminal within the fancy processtree.
+				p.ApplyOptions(
+					pack.WithLogger(l),
+				)
+
+				return p.Pack()
+			},
+		))
+	}
+
+	model, err := processtree.NewProcessTree(
+		[]processtree.ProcessTreeOption{
+			processtree.WithVerb("Packaging"),
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithLogger(plog),
+		},
+		tree...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := model.Start(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// initProjectPackages initializes a package for every target of the project
+// which matches the requested architecture, platform or target
+func initProjectPackages(pm packmanager.PackageManager, plog log.Logger, workdir string, opts *pkgOptions) ([]pack.Package, error) {
+	var packages []pack.Package
+
 	projectOpts, err := schema.NewProjectOptions(
 		nil,
 		schema.WithLogger(plog),
@@ -267,16 +417,21 @@ func pkgRun(opts *pkgOptions, workdir string) error {
 		schema.WithDotConfig(true),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Interpret the application
 	app, err := schema.NewApplicationFromOptions(projectOpts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var packages []pack.Package
+	// Volumes supplied via the command-line are bundled alongside those
+	// defined in the project
+	volumes, err := app.Volumes.Extend(projectOpts.WorkingDir, opts.Volumes...)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse --volumes flag: %v", err)
+	}
 
 	// Generate a package for every matching requested target
 	for _, targ := range app.Targets {
@@ -307,9 +462,9 @@ func pkgRun(opts *pkgOptions, workdir string) error {
 				targ.Architecture.Name() == opts.Architecture &&
 				targ.Platform.Name() == opts.Platform:
 
-			packs, err := initPackage(app.Name(), targ, projectOpts, pm, opts)
+			packs, err := initPackage(app.Name(), targ, volumes, projectOpts, pm, opts)
 			if err != nil {
-				return fmt.Errorf("could not create package: %s", err)
+				return nil, fmt.Errorf("could not create package: %s", err)
 			}
 
 			packages = append(packages, packs...)
@@ -319,66 +474,51 @@ func pkgRun(opts *pkgOptions, workdir string) error {
 		}
 	}
 
-	if len(packages) == 0 {
-		plog.Info("nothing to package")
-		return nil
-	}
+	return packages, nil
+}
 
-	cfgm, err := opts.ConfigManager()
+// initSourcePackage initializes a package of the source directory of a
+// component, which is packed into a tarball and described by a manifest
+func initSourcePackage(pm packmanager.PackageManager, opts *pkgOptions) ([]pack.Package, error) {
+	ctype, name, version, err := unikraft.GuessTypeNameVersion(opts.Name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	parallel := !cfgm.Config.NoParallel
-	norender := logger.LoggerTypeFromString(cfgm.Config.Log.Type) != logger.FANCY
-	if norender {
-		parallel = false
-	} else {
-		plog.SetOutput(ioutil.Discard)
+	// Sources are assumed to be libraries unless stated otherwise
+	if ctype == unikraft.ComponentTypeUnknown {
+		ctype = unikraft.ComponentTypeLib
 	}
 
-	var tree []*processtree.ProcessTreeItem
-	for _, p := range packages {
-		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
-		p := p
-
-		tree = append(tree, processtree.NewProcessTreeItem(
-			"Packaging "+p.CanonicalName(),
-			p.Options().ArchPlatString(),
-			func(l log.Logger) error {
-				// Apply the incoming logger which is tailored to display as a
-				// sub-terminal within the fancy processtree.
-				p.ApplyOptions(
-					pack.WithLogger(l),
-				)
-
-				return p.Pack()
-			},
-		))
+	if len(name) == 0 {
+		name = filepath.Base(filepath.Clean(opts.Source))
 	}
 
-	model, err := processtree.NewProcessTree(
-		[]processtree.ProcessTreeOption{
-			processtree.WithVerb("Packaging"),
-			processtree.IsParallel(parallel),
-			processtree.WithRenderer(norender),
-			processtree.WithLogger(plog),
-		},
-		tree...,
-	)
+	plog, err := opts.Logger()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := model.Start(); err != nil {
-		return err
+	packOpts, err := pack.NewPackageOptions(
+		pack.WithName(name),
+		pack.WithType(ctype),
+		pack.WithVersion(version),
+		pack.WithSource(opts.Source),
+		pack.WithLogger(plog),
+		manifest.WithPackOutputDir(opts.OutputDir),
+		manifest.WithPackResourceURL(opts.ResourceURL),
+		manifest.WithPackChannel(opts.Channel),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not prepare package for source: %v", err)
 	}
 
-	return nil
+	return pm.NewPackageFromOptions(packOpts)
 }
 
 func initPackage(name string,
 	targ target.TargetConfig,
+	volumes []volume.VolumeConfig,
 	projectOpts *schema.ProjectOptions,
 	pm packmanager.PackageManager,
 	opts *pkgOptions,
@@ -425,6 +565,12 @@ func initPackage(name string,
 		pack.WithInitrdConfig(initrdConfig),
 	)
 
+	if len(volumes) > 0 {
+		extraPackOpts = append(extraPackOpts,
+			pack.WithVolumes(volumes...),
+		)
+	}
+
 	packOpts, err := pack.NewPackageOptions(extraPackOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not prepare package for target: %s: %v", targ.Name(), err)
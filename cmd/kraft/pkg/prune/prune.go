@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package prune
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/schema"
+	"kraftkit.sh/store"
+)
+
+type PruneOptions struct {
+	ConfigManager func() (*config.ConfigManager, error)
+	Logger        func() (log.Logger, error)
+	IO            *iostreams.IOStreams
+
+	// Command-line arguments
+	DryRun bool
+}
+
+func PruneCmd(f *cmdfactory.Factory) *cobra.Command {
+	opts := &PruneOptions{
+		ConfigManager: f.ConfigManager,
+		Logger:        f.Logger,
+		IO:            f.IOStreams,
+	}
+
+	cmd, err := cmdutil.NewCmd(f, "prune")
+	if err != nil {
+		panic("could not initialize subcommmand")
+	}
+
+	cmd.Short = "Remove unreferenced sources from the shared source store"
+	cmd.Use = "prune [FLAGS]"
+	cmd.Args = cobra.NoArgs
+	cmd.Long = heredoc.Doc(`
+		Remove unreferenced sources from the shared source store
+
+		Pulled components are unpacked once into a content-addressed store within
+		the sources directory and are placed into projects from there.  An entry
+		of the store is removed when it is no longer referenced by the lockfile of
+		any known project.  Projects retain their own copy of the sources and are
+		not affected by pruning.
+	`)
+	cmd.Example = heredoc.Doc(`
+		# Remove all unreferenced sources
+		$ kraft pkg prune
+
+		# Show which sources would be removed
+		$ kraft pkg prune --dry-run
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return pruneRun(opts)
+	}
+
+	cmd.Flags().BoolVarP(
+		&opts.DryRun,
+		"dry-run", "n",
+		false,
+		"Only show which sources would be removed",
+	)
+
+	return cmd
+}
+
+func pruneRun(opts *PruneOptions) error {
+	cfgm, err := opts.ConfigManager()
+	if err != nil {
+		return err
+	}
+
+	plog, err := opts.Logger()
+	if err != nil {
+		return err
+	}
+
+	st := store.NewStore(cfgm.Config.Paths.Sources)
+
+	projects, err := st.Projects()
+	if err != nil {
+		return fmt.Errorf("could not read known projects: %v", err)
+	}
+
+	// Collect the checksums referenced by the lockfiles of all projects which
+	// still exist, forgetting about those which have since been removed
+	var known []string
+	referenced := map[string]bool{}
+
+	for _, project := range projects {
+		if f, err := os.Stat(project); err != nil || !f.IsDir() {
+			plog.Debugf("forgetting removed project: %s", project)
+			continue
+		}
+
+		known = append(known, project)
+
+		lockfile, err := schema.NewLockfileFromFile(schema.LockfilePath(project))
+		if err != nil {
+			plog.Warnf("could not read lockfile of %s: %v", project, err)
+			continue
+		}
+
+		for _, component := range lockfile.Components {
+			if len(component.Sha256) > 0 {
+				referenced[strings.ToLower(strings.TrimPrefix(component.Sha256, "sha256:"))] = true
+			}
+		}
+	}
+
+	entries, err := st.Entries()
+	if err != nil {
+		return fmt.Errorf("could not read source store: %v", err)
+	}
+
+	var removed int
+	var reclaimed int64
+
+	for _, sum := range entries {
+		if referenced[sum] {
+			continue
+		}
+
+		size := dirSize(st.Path(sum))
+
+		if opts.DryRun {
+			fmt.Fprintf(opts.IO.Out, "would remove %s\n", sum)
+		} else {
+			if err := st.Remove(sum); err != nil {
+				return fmt.Errorf("could not remove %s: %v", sum, err)
+			}
+
+			fmt.Fprintf(opts.IO.Out, "removed %s\n", sum)
+		}
+
+		removed++
+		reclaimed += size
+	}
+
+	if !opts.DryRun {
+		if err := st.SetProjects(known); err != nil {
+			return fmt.Errorf("could not update known projects: %v", err)
+		}
+	}
+
+	if opts.DryRun {
+		plog.Infof("%d of %d entries unreferenced, %s reclaimable", removed, len(entries), formatSize(reclaimed))
+	} else {
+		plog.Infof("removed %d of %d entries, %s reclaimed", removed, len(entries), formatSize(reclaimed))
+	}
+
+	return nil
+}
+
+// dirSize returns the total size of the regular files within a directory
+func dirSize(dir string) int64 {
+	var size int64
+
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+// formatSize returns a human-readable representation of a number of bytes
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -33,6 +33,7 @@ package pull
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -45,6 +46,7 @@ import (
 	"kraftkit.sh/internal/cmdutil"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/schema"
@@ -57,6 +59,7 @@ type PullOptions struct {
 	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
 	ConfigManager  func() (*config.ConfigManager, error)
 	Logger         func() (log.Logger, error)
+	HttpClient     func() (*http.Client, error)
 	IO             *iostreams.IOStreams
 
 	// Command-line arguments
@@ -70,6 +73,7 @@ type PullOptions struct {
 	AllVersions  bool
 	NoChecksum   bool
 	NoCache      bool
+	Update       bool
 }
 
 func PullCmd(f *cmdfactory.Factory) *cobra.Command {
@@ -77,6 +81,7 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 		PackageManager: f.PackageManager,
 		ConfigManager:  f.ConfigManager,
 		Logger:         f.Logger,
+		HttpClient:     f.HttpClient,
 		IO:             f.IOStreams,
 	}
 
@@ -89,8 +94,14 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 	cmd.Use = "pull [FLAGS] [PACKAGE|DIR]"
 	cmd.Aliases = []string{"p"}
 	// cmd.Args = cmdutil(1)
-	cmd.Long = heredoc.Doc(`
-		Pull a Unikraft unikernel, component microlibrary from a remote location`)
+	cmd.Long = heredoc.Docf(`
+		Pull a Unikraft unikernel, component microlibrary from a remote location.
+
+		When pulling the dependencies of a project, the exact version, source and
+		checksum of every component are recorded in %[1]sKraftfile.lock%[1]s.
+		Subsequent pulls retrieve exactly these sources, regardless of changes to
+		the package index, until the lockfile is refreshed with %[1]s--update%[1]s.
+	`, "`")
 	cmd.Example = heredoc.Doc(`
 		# Pull the dependencies for a project in the current working directory
 		$ kraft pkg pull
@@ -98,6 +109,12 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 		# Pull dependencies for a project at a path
 		$ kraft pkg pull path/to/app
 
+		# Re-resolve the dependencies of a project and update its lockfile
+		$ kraft pkg pull --update
+
+		# Pull the dependencies of a project including those of its libraries
+		$ kraft pkg pull --with-deps
+
 		# Pull a source repository
 		$ kraft pkg pull github.com/unikraft/app-nginx.git
 
@@ -120,10 +137,12 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 			}
 		}
 
+		// Dependencies are not pulled by default, so only an explicit `--no-deps`
+		// conflicts with `--with-deps`
 		if err := cmdutil.MutuallyExclusive(
 			"the `--with-deps` option is not supported with `--no-deps`",
 			opts.WithDeps,
-			opts.NoDeps,
+			opts.NoDeps && cmd.Flags().Changed("no-deps"),
 		); err != nil {
 			return err
 		}
@@ -143,7 +162,7 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 		&opts.WithDeps,
 		"with-deps", "d",
 		false,
-		"Pull dependencies",
+		"Resolve and pull the dependencies declared by the components' manifests",
 	)
 
 	cmd.Flags().StringVarP(
@@ -195,6 +214,13 @@ func PullCmd(f *cmdfactory.Factory) *cobra.Command {
 		"Do not use cache and pull directly from source",
 	)
 
+	cmd.Flags().BoolVarP(
+		&opts.Update,
+		"update", "U",
+		false,
+		"Resolve the dependencies of a project against the package index and update its lockfile",
+	)
+
 	return cmd
 }
 
@@ -203,6 +229,8 @@ func pullRun(opts *PullOptions, query string) error {
 	var project *app.ApplicationConfig
 	var processes []*paraprogress.Process
 	var queries []packmanager.CatalogQuery
+	var lockfile *schema.Lockfile
+	var requiredBy string
 
 	workdir := opts.Workdir
 
@@ -216,6 +244,11 @@ func pullRun(opts *PullOptions, query string) error {
 		return err
 	}
 
+	client, err := opts.HttpClient()
+	if err != nil {
+		return err
+	}
+
 	// Force a particular package manager
 	if len(opts.Manager) > 0 && opts.Manager != "auto" {
 		pm, err = pm.From(opts.Manager)
@@ -240,11 +273,18 @@ func pullRun(opts *PullOptions, query string) error {
 		}
 
 		// Interpret the application
-		project, err := schema.NewApplicationFromOptions(projectOpts)
+		project, err = schema.NewApplicationFromOptions(projectOpts)
 		if err != nil {
 			return err
 		}
 
+		lockfile, err = schema.NewLockfileFromFile(schema.LockfilePath(workdir))
+		if err != nil {
+			return err
+		}
+
+		requiredBy = "Kraftfile"
+
 		// List the components
 		for _, c := range project.Components() {
 			queries = append(queries, packmanager.CatalogQuery{
@@ -256,6 +296,8 @@ func pullRun(opts *PullOptions, query string) error {
 
 		// Is this a list (space delimetered) of packages to pull?
 	} else {
+		requiredBy = "command-line"
+
 		for _, c := range strings.Split(query, " ") {
 			query := packmanager.CatalogQuery{}
 			t, n, v, err := unikraft.GuessTypeNameVersion(c)
@@ -279,7 +321,30 @@ func pullRun(opts *PullOptions, query string) error {
 		}
 	}
 
+	// Remember the versions as requested, as these are recorded in the lockfile
+	// rather than the versions they resolve to
+	requested := make(map[string]string, len(queries))
 	for _, c := range queries {
+		requested[queryKey(c)] = c.Version
+	}
+
+	// Resolve the requested components to a consistent set of versions which
+	// includes their dependencies
+	if opts.WithDeps {
+		queries, err = resolveDependencies(pm, queries, requiredBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	var pulled []lockedPull
+
+	for _, c := range queries {
+		version, ok := requested[queryKey(c)]
+		if !ok {
+			version = c.Version
+		}
+
 		next, err := pm.Catalog(c)
 		if err != nil {
 			return err
@@ -290,8 +355,37 @@ func pullRun(opts *PullOptions, query string) error {
 			continue
 		}
 
+		// Pin the package to the locked source unless the version requested by
+		// the project has since changed or an update is requested
+		var locked *schema.LockedComponent
+		if lockfile != nil && !opts.Update && len(c.Types) == 1 {
+			if l, ok := lockfile.Find(c.Types[0], c.Name); ok && l.Version == version {
+				locked = &l
+			}
+		}
+
 		for _, p := range next {
 			p := p
+
+			if locked != nil {
+				plog.Debugf("using locked %s (%s)", p.Options().TypeNameVersion(), locked.Resolved)
+
+				if err := p.ApplyOptions(
+					pack.WithRemoteLocation(locked.Source),
+					pack.WithSha256(locked.Sha256),
+					pack.WithResolvedVersion(locked.Resolved),
+				); err != nil {
+					return err
+				}
+			}
+
+			// Each process only marks its own entry as successful
+			i := len(pulled)
+			pulled = append(pulled, lockedPull{
+				pkg:     p,
+				version: version,
+			})
+
 			processes = append(processes, paraprogress.NewProcess(
 				fmt.Sprintf("pulling %s", p.Options().TypeNameVersion()),
 				func(l log.Logger, w func(progress float64)) error {
@@ -301,13 +395,20 @@ func pullRun(opts *PullOptions, query string) error {
 						pack.WithLogger(l),
 					)
 
-					return p.Pull(
+					if err := p.Pull(
 						pack.WithPullProgressFunc(w),
 						pack.WithPullWorkdir(workdir),
 						pack.WithPullLogger(l),
 						pack.WithPullChecksum(!opts.NoChecksum),
 						pack.WithPullCache(!opts.NoCache),
-					)
+						pack.WithPullHTTPClient(client),
+					); err != nil {
+						return err
+					}
+
+					pulled[i].ok = true
+
+					return nil
 				},
 			))
 		}
@@ -326,9 +427,98 @@ func pullRun(opts *PullOptions, query string) error {
 		return err
 	}
 
+	if lockfile != nil {
+		if err := updateLockfile(lockfile, project, pulled, workdir); err != nil {
+			return err
+		}
+	}
+
 	if project != nil {
 		project.PrintInfo(opts.IO)
 	}
 
 	return nil
 }
+
+// lockedPull tracks a package pulled for a project such that its resolved
+// state can be recorded in the project's lockfile
+type lockedPull struct {
+	pkg     pack.Package
+	version string
+	ok      bool
+}
+
+// updateLockfile records the resolved state of all successfully pulled
+// packages and removes components which are no longer part of the project
+func updateLockfile(lockfile *schema.Lockfile, project *app.ApplicationConfig, pulled []lockedPull, workdir string) error {
+	lockfile.Retain(project.Components())
+
+	for _, p := range pulled {
+		if p.ok {
+			lockfile.SetPackage(p.version, p.pkg)
+		}
+	}
+
+	return lockfile.Save(schema.LockfilePath(workdir))
+}
+
+// queryKey uniquely identifies the component of a query
+func queryKey(query packmanager.CatalogQuery) string {
+	if len(query.Types) == 1 {
+		return string(query.Types[0]) + "/" + query.Name
+	}
+
+	return query.Name
+}
+
+// resolveDependencies replaces the queries with the consistent set of versions
+// computed from the dependencies declared by the components' manifests
+func resolveDependencies(pm packmanager.PackageManager, queries []packmanager.CatalogQuery, requiredBy string) ([]packmanager.CatalogQuery, error) {
+	if pm.String() != "manifest" {
+		var err error
+		pm, err = pm.From("manifest")
+		if err != nil {
+			return nil, fmt.Errorf("resolving dependencies requires the manifest package manager: %v", err)
+		}
+	}
+
+	mm, ok := pm.(manifest.ManifestManager)
+	if !ok {
+		return nil, fmt.Errorf("resolving dependencies requires the manifest package manager")
+	}
+
+	var reqs []manifest.Requirement
+	for _, query := range queries {
+		if len(query.Types) > 1 {
+			return nil, fmt.Errorf("cannot resolve dependencies of %s with multiple types", query.String())
+		}
+
+		req := manifest.Requirement{
+			Name:       query.Name,
+			Constraint: query.Version,
+			RequiredBy: requiredBy,
+		}
+
+		if len(query.Types) == 1 {
+			req.Type = query.Types[0]
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	resolutions, err := mm.Resolve(reqs...)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]packmanager.CatalogQuery, len(resolutions))
+	for i, res := range resolutions {
+		resolved[i] = packmanager.CatalogQuery{
+			Types:   []unikraft.ComponentType{res.Manifest.Type},
+			Name:    res.Manifest.Name,
+			Version: res.Version,
+		}
+	}
+
+	return resolved, nil
+}
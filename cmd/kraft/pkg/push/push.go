@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package push
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/log"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/tui/processtree"
+)
+
+type PushOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	ConfigManager  func() (*config.ConfigManager, error)
+	Logger         func() (log.Logger, error)
+	HttpClient     func() (*http.Client, error)
+
+	// Command-line arguments
+	Manager     string
+	Destination string
+	ResourceURL string
+}
+
+func PushCmd(f *cmdfactory.Factory) *cobra.Command {
+	opts := &PushOptions{
+		PackageManager: f.PackageManager,
+		ConfigManager:  f.ConfigManager,
+		Logger:         f.Logger,
+		HttpClient:     f.HttpClient,
+	}
+
+	cmd, err := cmdutil.NewCmd(f, "push")
+	if err != nil {
+		panic("could not initialize subcommmand")
+	}
+
+	cmd.Short = "Push a Unikraft package to a registry"
+	cmd.Use = "push [FLAGS] PACKAGE"
+	cmd.Args = cmdutil.ExactArgs(1, "must specify package to push")
+	cmd.Long = heredoc.Docf(`
+		Push a packaged Unikraft component or unikernel to a registry.
+
+		The package, for example a manifest generated by %[1]skraft pkg%[1]s, is
+		uploaded alongside the resources it references which are found locally.
+		The index at the root of the destination is created or updated such that
+		the destination can be added as a source with %[1]skraft pkg source%[1]s.
+
+		The destination can either be a local directory or an HTTP endpoint which
+		accepts PUT requests, such as a WebDAV server.  Credentials for an HTTP
+		endpoint are looked up by its host name in the %[1]sauth%[1]s section of the
+		configuration.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Push a manifest package to a local directory index
+		$ kraft pkg push --to /srv/unikraft ./libfoo.yaml
+
+		# Push to a local directory which is served at a different URL
+		$ kraft pkg push --to /srv/unikraft --url https://pkg.example.com ./libfoo.yaml
+
+		# Push a manifest package to a WebDAV server
+		$ kraft pkg push --to https://dav.example.com/unikraft ./libfoo.yaml
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return pushRun(opts, args[0])
+	}
+
+	cmd.Flags().StringVarP(
+		&opts.Manager,
+		"manager", "M",
+		"auto",
+		"Force the handler type (Omittion will attempt auto-detect)",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Destination,
+		"to",
+		"",
+		"Destination directory or URL of the registry",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.ResourceURL,
+		"url",
+		"",
+		"Base URL which the pushed resources are publicly accessible from",
+	)
+
+	return cmd
+}
+
+func pushRun(opts *PushOptions, source string) error {
+	if len(opts.Destination) == 0 {
+		return fmt.Errorf("the `--to` flag must be set to the destination of the package")
+	}
+
+	plog, err := opts.Logger()
+	if err != nil {
+		return err
+	}
+
+	cfgm, err := opts.ConfigManager()
+	if err != nil {
+		return err
+	}
+
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	client, err := opts.HttpClient()
+	if err != nil {
+		return err
+	}
+
+	// Force a particular package manager
+	if len(opts.Manager) > 0 && opts.Manager != "auto" {
+		pm, err = pm.From(opts.Manager)
+		if err != nil {
+			return err
+		}
+	}
+
+	parallel := !cfgm.Config.NoParallel
+	norender := logger.LoggerTypeFromString(cfgm.Config.Log.Type) != logger.FANCY
+	if norender {
+		parallel = false
+	}
+
+	model, err := processtree.NewProcessTree(
+		[]processtree.ProcessTreeOption{
+			processtree.IsParallel(parallel),
+			processtree.WithRenderer(norender),
+			processtree.WithLogger(plog),
+		},
+		[]*processtree.ProcessTreeItem{
+			processtree.NewProcessTreeItem(
+				"Pushing "+source,
+				"",
+				func(l log.Logger) error {
+					// Apply the incoming logger which is tailored to display as a
+					// sub-terminal within the fancy processtree.
+					popts, err := pack.NewPushPackageOptions(
+						pack.WithPushDestination(opts.Destination),
+						pack.WithPushResourceURL(opts.ResourceURL),
+						pack.WithPushLogger(l),
+						pack.WithPushHTTPClient(client),
+					)
+					if err != nil {
+						return err
+					}
+
+					return pm.Push(source, popts)
+				},
+			),
+		}...,
+	)
+	if err != nil {
+		return err
+	}
+
+	return model.Start()
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/utils"
+)
+
+type SearchOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	ConfigManager  func() (*config.ConfigManager, error)
+	Logger         func() (log.Logger, error)
+	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	LimitResults int
+	NoLimit      bool
+}
+
+func SearchCmd(f *cmdfactory.Factory) *cobra.Command {
+	opts := &SearchOptions{
+		PackageManager: f.PackageManager,
+		ConfigManager:  f.ConfigManager,
+		Logger:         f.Logger,
+		IO:             f.IOStreams,
+	}
+
+	cmd, err := cmdutil.NewCmd(f, "search")
+	if err != nil {
+		panic("could not initialize 'kraft pkg search' commmand")
+	}
+
+	cmd.Short = "Search for Unikraft component packages"
+	cmd.Use = "search [FLAGS] QUERY..."
+	cmd.Aliases = []string{"s", "find"}
+	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Long = heredoc.Docf(`
+		Search for Unikraft component packages.
+
+		The query consists of one or more terms.  A name is matched approximately
+		against the names, tags and keywords of packages and as part of their
+		description, with the best matches listed first.  A name containing %[1]s*%[1]s
+		is matched as a glob pattern instead.  The results can be narrowed down
+		with the following qualifiers:
+
+		  type:TYPE        only list packages of this type, e.g. type:lib
+		  tag:TAG          only list packages with this tag, e.g. tag:network
+		  name:NAME        match the name of packages
+		  version:VERSION  select a version, channel or range, e.g. version:^1.0
+
+		Packages can also be provided as %[1]s[TYPE/]NAME[:VERSION]%[1]s, e.g.
+		%[1]slib/*ssl*:>=1.0%[1]s.  Comparisons of a version range are separated by
+		commas, e.g. %[1]s>=1.0,<2.0%[1]s.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Search for packages related to TLS
+		$ kraft pkg search tls
+
+		# Search for libraries providing networking
+		$ kraft pkg search type:lib tag:network
+
+		# Search for libraries with "ssl" in their name in a version range
+		$ kraft pkg search 'lib/*ssl*:>=1.0'
+	`)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.NoLimit {
+			opts.LimitResults = -1
+		}
+
+		return searchRun(opts, strings.Join(args, " "))
+	}
+
+	cmd.Flags().IntVarP(
+		&opts.LimitResults,
+		"limit", "l",
+		30,
+		"Maximum number of items to print (-1 returns all)",
+	)
+
+	cmd.Flags().BoolVarP(
+		&opts.NoLimit,
+		"no-limit", "T",
+		false,
+		"Do not limit the number of items to print",
+	)
+
+	return cmd
+}
+
+func searchRun(opts *SearchOptions, q string) error {
+	query, err := packmanager.NewCatalogQuery(q)
+	if err != nil {
+		return fmt.Errorf("could not parse query: %v", err)
+	}
+
+	query.Fuzzy = true
+
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	packages, err := pm.Catalog(query)
+	if err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages match %s", q)
+	}
+
+	if opts.LimitResults >= 0 && len(packages) > opts.LimitResults {
+		packages = packages[:opts.LimitResults]
+	}
+
+	cs := opts.IO.ColorScheme()
+	table := utils.NewTablePrinter(opts.IO)
+
+	// Header row
+	table.AddField("TYPE", nil, cs.Bold)
+	table.AddField("PACKAGE", nil, cs.Bold)
+	table.AddField("VERSION", nil, cs.Bold)
+	table.AddField("TAGS", nil, cs.Bold)
+	table.AddField("DESCRIPTION", nil, cs.Bold)
+	table.EndRow()
+
+	for _, p := range packages {
+		// Only the first line of the description is shown
+		description, _ := p.Options().Metadata["manifest.description"].(string)
+		description = strings.TrimSpace(description)
+		if i := strings.Index(description, "\n"); i >= 0 {
+			description = description[:i]
+		}
+
+		tags, _ := p.Options().Metadata["manifest.tags"].([]string)
+
+		table.AddField(string(p.Options().Type), nil, nil)
+		table.AddField(p.Name(), nil, nil)
+		table.AddField(p.Options().Version, nil, nil)
+		table.AddField(strings.Join(tags, ", "), nil, cs.Gray)
+		table.AddField(description, nil, nil)
+		table.EndRow()
+	}
+
+	return table.Render()
+}
@@ -0,0 +1,555 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/exec"
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/internal/cmdfactory"
+	"kraftkit.sh/internal/cmdutil"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine"
+	"kraftkit.sh/network"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/schema"
+	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/utils"
+
+	// Additional initializers
+	_ "kraftkit.sh/machine/qemu"
+	_ "kraftkit.sh/manifest"
+)
+
+type runOptions struct {
+	PackageManager func(opts ...packmanager.PackageManagerOption) (packmanager.PackageManager, error)
+	ConfigManager  func() (*config.ConfigManager, error)
+	Logger         func() (log.Logger, error)
+	IO             *iostreams.IOStreams
+
+	// Command-line arguments
+	Driver       string
+	Architecture string
+	Platform     string
+	Target       string
+	Kernel       string
+	Initrd       string
+	CPUs         int
+	Memory       string
+	Volumes      []string
+	KernelDbg    bool
+}
+
+func RunCmd(f *cmdfactory.Factory) *cobra.Command {
+	cmd, err := cmdutil.NewCmd(f, "run")
+	if err != nil {
+		panic("could not initialize 'run' commmand")
+	}
+
+	opts := &runOptions{
+		PackageManager: f.PackageManager,
+		ConfigManager:  f.ConfigManager,
+		Logger:         f.Logger,
+		IO:             f.IOStreams,
+	}
+
+	cmd.Short = "Run a Unikraft unikernel"
+	cmd.Use = "run [FLAGS] [DIR] [-- ARGS...]"
+	cmd.Long = heredoc.Docf(`
+		Run a Unikraft unikernel.
+
+		The kernel image of a target built with %[1]skraft build%[1]s is booted with a
+		machine driver, by default QEMU.  The console of the unikernel is attached
+		to the terminal and signals are forwarded to the hypervisor.  Additional
+		arguments passed after %[1]s--%[1]s are appended to the kernel command-line.
+	`, "`")
+	cmd.Example = heredoc.Doc(`
+		# Run the current project (cwd)
+		$ kraft run
+
+		# Run a particular target of a project at a path
+		$ kraft run --target helloworld-qemu path/to/app
+
+		# Run with additional resources and command-line arguments
+		$ kraft run --cpus 2 --memory 128M . -- -c /nginx/conf/nginx.conf
+
+		# Share a directory with the unikernel via 9pfs
+		$ kraft run --volumes fs0=./rootfs
+	`)
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args = args[:dash]
+		}
+
+		return cmdutil.MaxDirArgs(1)(cmd, args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if (len(opts.Architecture) > 0 || len(opts.Platform) > 0) && len(opts.Target) > 0 {
+			return fmt.Errorf("the `--arch` and `--plat` options are not supported in addition to `--target`")
+		}
+
+		var kargs []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			kargs = args[dash:]
+			args = args[:dash]
+		}
+
+		var err error
+		var workdir string
+		if len(args) == 0 {
+			workdir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		} else {
+			workdir = args[0]
+		}
+
+		return runRun(opts, workdir, kargs)
+	}
+
+	cmd.Flags().StringVarP(
+		&opts.Driver,
+		"driver", "d",
+		"qemu",
+		"Set the machine driver used to boot the unikernel",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Architecture,
+		"arch", "m",
+		"",
+		"Filter the target to run by architecture",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Platform,
+		"plat", "p",
+		"",
+		"Filter the target to run by platform",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Target,
+		"target", "t",
+		"",
+		"Run a particular known target",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Kernel,
+		"kernel", "k",
+		"",
+		"Override the path to the unikernel image",
+	)
+
+	cmd.Flags().BoolVar(
+		&opts.KernelDbg,
+		"dbg",
+		false,
+		"Run the debuggable (symbolic) kernel image instead of the stripped image",
+	)
+
+	cmd.Flags().StringVarP(
+		&opts.Initrd,
+		"initrd", "i",
+		"",
+		"Path to init ramdisk to boot with (passing a directory will "+
+			"automatically generate a CPIO image)",
+	)
+
+	cmd.Flags().StringSliceVarP(
+		&opts.Volumes,
+		"volumes", "v",
+		[]string{},
+		"Attach additional volumes to the unikernel (e.g. --volumes fs0=./rootfs)",
+	)
+
+	cmd.Flags().IntVar(
+		&opts.CPUs,
+		"cpus",
+		0,
+		"Number of virtual CPUs to allocate to the unikernel",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Memory,
+		"memory",
+		"",
+		"Amount of memory to allocate to the unikernel, e.g. 64M",
+	)
+
+	return cmd
+}
+
+func runRun(opts *runOptions, workdir string, kargs []string) error {
+	pm, err := opts.PackageManager()
+	if err != nil {
+		return err
+	}
+
+	plog, err := opts.Logger()
+	if err != nil {
+		return err
+	}
+
+	projectOpts, err := schema.NewProjectOptions(
+		nil,
+		schema.WithLogger(plog),
+		schema.WithWorkingDirectory(workdir),
+		schema.WithDefaultConfigPath(),
+		schema.WithPackageManager(&pm),
+		schema.WithResolvedPaths(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Interpret the application
+	project, err := schema.NewApplicationFromOptions(projectOpts)
+	if err != nil {
+		return err
+	}
+
+	var targets []target.TargetConfig
+
+	// Filter the targets by CLI selection
+	for _, targ := range project.Targets {
+		switch true {
+		case
+			// If no arguments are supplied
+			len(opts.Target) == 0 &&
+				len(opts.Architecture) == 0 &&
+				len(opts.Platform) == 0,
+
+			// If the --target flag is supplied and the target name match
+			len(opts.Target) > 0 &&
+				targ.Name() == opts.Target,
+
+			// If only the --arch flag is supplied and the target's arch matches
+			len(opts.Architecture) > 0 &&
+				len(opts.Platform) == 0 &&
+				targ.Architecture.Name() == opts.Architecture,
+
+			// If only the --plat flag is supplied and the target's platform matches
+			len(opts.Platform) > 0 &&
+				len(opts.Architecture) == 0 &&
+				targ.Platform.Name() == opts.Platform,
+
+			// If both the --arch and --plat flag are supplied and match the target
+			len(opts.Platform) > 0 &&
+				len(opts.Architecture) > 0 &&
+				targ.Architecture.Name() == opts.Architecture &&
+				targ.Platform.Name() == opts.Platform:
+
+			targets = append(targets, targ)
+
+		default:
+			continue
+		}
+	}
+
+	if len(targets) == 0 {
+		return fmt.Errorf("no matching target to run")
+	} else if len(targets) > 1 {
+		return fmt.Errorf("multiple targets match, select one with `--target` or `--arch` and `--plat`")
+	}
+
+	targ := targets[0]
+
+	mcfg := machine.MachineConfig{
+		Name:         targ.Name(),
+		Architecture: targ.Architecture.Name(),
+		Platform:     targ.Platform.Name(),
+		Kernel:       targ.Kernel,
+		Arguments:    append(append([]string{}, targ.Command...), kargs...),
+		CPUs:         targ.Platform.CPUs,
+		Memory:       targ.Platform.Memory,
+	}
+
+	mcfg.Volumes, err = project.Volumes.Extend(projectOpts.WorkingDir, opts.Volumes...)
+	if err != nil {
+		return fmt.Errorf("could not parse --volumes flag: %v", err)
+	}
+
+	if opts.KernelDbg {
+		mcfg.Kernel = targ.KernelDbg
+	}
+
+	if len(opts.Kernel) > 0 {
+		mcfg.Kernel = opts.Kernel
+	}
+
+	if opts.CPUs > 0 {
+		mcfg.CPUs = opts.CPUs
+	}
+
+	if len(opts.Memory) > 0 {
+		mcfg.Memory = opts.Memory
+	}
+
+	if _, err := os.Stat(mcfg.Kernel); err != nil {
+		return fmt.Errorf("cannot find kernel image, did you run `kraft build`?: %s", mcfg.Kernel)
+	}
+
+	initrdConfig := targ.Initrd
+	if len(opts.Initrd) > 0 {
+		initrdConfig, err = initrd.ParseInitrdConfig(projectOpts.WorkingDir, opts.Initrd)
+		if err != nil {
+			return fmt.Errorf("could not parse --initrd flag with value %s: %s", opts.Initrd, err)
+		}
+	}
+
+	if initrdConfig != nil {
+		mcfg.Initrd, err = initrdConfig.Build()
+		if err != nil {
+			return fmt.Errorf("could not build initramfs: %v", err)
+		}
+
+		// Only remove the initramfs if it was generated to a temporary location
+		if len(initrdConfig.Output) == 0 {
+			defer os.Remove(mcfg.Initrd)
+		}
+	}
+
+	netArgs, netKargs, err := networkArgs(opts.Driver, project.Networks)
+	if err != nil {
+		return err
+	}
+
+	// Network configuration for Unikraft is passed as library parameters which
+	// must precede the application's arguments
+	if len(netKargs) > 0 {
+		if !utils.Contains(mcfg.Arguments, "--") {
+			mcfg.Arguments = append([]string{"--"}, mcfg.Arguments...)
+		}
+
+		mcfg.Arguments = append(netKargs, mcfg.Arguments...)
+	}
+
+	driver, err := machine.NewDriver(opts.Driver,
+		machine.WithMachineConfig(mcfg),
+		machine.WithLogger(plog),
+		machine.WithStdin(opts.IO.In),
+		machine.WithStdout(opts.IO.Out),
+		machine.WithStderr(opts.IO.ErrOut),
+		machine.WithArgs(netArgs...),
+	)
+	if err != nil {
+		return err
+	}
+
+	teardown, err := setupNetworks(project.Networks, opts.IO, plog)
+	defer teardown()
+	if err != nil {
+		return err
+	}
+
+	if err := runHook(targ.Platform.PreUp, opts.IO, plog); err != nil {
+		return fmt.Errorf("could not run pre_up command: %v", err)
+	}
+
+	defer func() {
+		if err := runHook(targ.Platform.PostDown, opts.IO, plog); err != nil {
+			plog.Errorf("could not run post_down command: %v", err)
+		}
+	}()
+
+	return runMachine(driver, plog)
+}
+
+// runMachine starts the machine and waits for it to exit whilst forwarding
+// interrupts and termination signals to the hypervisor
+func runMachine(driver machine.Driver, plog log.Logger) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := driver.Start(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if err := driver.Signal(sig.(syscall.Signal)); err != nil {
+					plog.Errorf("could not forward %s to %s: %v", sig, driver, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return driver.Wait()
+}
+
+// sortedNetworks returns the networks ordered by their name such that each is
+// consistently assigned the same interface index
+func sortedNetworks(networks network.Networks) []network.NetworkConfig {
+	var names []string
+	for name := range networks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var sorted []network.NetworkConfig
+	for _, name := range names {
+		sorted = append(sorted, networks[name])
+	}
+
+	return sorted
+}
+
+// networkArgs returns the hypervisor arguments and kernel command-line
+// arguments which attach the machine to each network
+func networkArgs(hypervisor string, networks network.Networks) ([]string, []string, error) {
+	var args []string
+	var kargs []string
+
+	for i, net := range sortedNetworks(networks) {
+		driver, err := network.NewDriver(net)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		netArgs, err := driver.Args(hypervisor, net, i)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		args = append(args, netArgs...)
+
+		// Unikraft currently only accepts the address of the first interface via
+		// its command-line
+		if i == 0 {
+			kargs = append(kargs, net.KernelArgs()...)
+		}
+	}
+
+	return args, kargs, nil
+}
+
+// setupNetworks creates each network on the host and returns a function which
+// tears down what has been created, even if setup has only partially succeeded
+func setupNetworks(networks network.Networks, io *iostreams.IOStreams, l log.Logger) (func(), error) {
+	var teardowns []func()
+	teardown := func() {
+		for i := len(teardowns) - 1; i >= 0; i-- {
+			teardowns[i]()
+		}
+	}
+
+	for i, net := range sortedNetworks(networks) {
+		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
+		i, net := i, net
+
+		driver, err := network.NewDriver(net)
+		if err != nil {
+			return teardown, err
+		}
+
+		up, state, err := driver.Up(net, i)
+		if err != nil {
+			return teardown, err
+		}
+
+		teardowns = append(teardowns, func() {
+			down, err := driver.Down(net, i, state)
+			if err != nil {
+				l.Errorf("could not tear down network %s: %v", net.Name, err)
+				return
+			}
+
+			for _, cmd := range down {
+				if err := runHook(cmd, io, l); err != nil {
+					l.Errorf("could not tear down network %s: %v", net.Name, err)
+				}
+			}
+
+			if err := runHook(net.PostDown, io, l); err != nil {
+				l.Errorf("could not run post_down command of network %s: %v", net.Name, err)
+			}
+		})
+
+		for _, cmd := range up {
+			if err := runHook(cmd, io, l); err != nil {
+				return teardown, fmt.Errorf("could not set up network %s: %v", net.Name, err)
+			}
+		}
+
+		if err := runHook(net.PreUp, io, l); err != nil {
+			return teardown, fmt.Errorf("could not run pre_up command of network %s: %v", net.Name, err)
+		}
+	}
+
+	return teardown, nil
+}
+
+// runHook executes a platform hook command on the host
+func runHook(command []string, io *iostreams.IOStreams, l log.Logger) error {
+	if len(command) == 0 {
+		return nil
+	}
+
+	executable, err := exec.NewExecutable(command[0], nil, command[1:]...)
+	if err != nil {
+		return err
+	}
+
+	process, err := exec.NewProcessFromExecutable(executable,
+		exec.WithStdout(io.Out),
+		exec.WithStderr(io.ErrOut),
+		exec.WithLogger(l),
+	)
+	if err != nil {
+		return err
+	}
+
+	return process.StartAndWait()
+}
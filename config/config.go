@@ -63,6 +63,7 @@ type Config struct {
 		Config    string `json:"config"    yaml:"config,omitempty"    env:"KRAFTKIT_PATHS_CONFIG"`
 		Manifests string `json:"manifests" yaml:"manifests,omitempty" env:"KRAFTKIT_PATHS_MANIFESTS"`
 		Sources   string `json:"sources"   yaml:"sources,omitempty"   env:"KRAFTKIT_PATHS_SOURCES"`
+		OCI       string `json:"oci"       yaml:"oci,omitempty"       env:"KRAFTKIT_PATHS_OCI"`
 	} `json:"paths" yaml:"paths,omitempty"`
 
 	Log struct {
@@ -74,6 +75,12 @@ type Config struct {
 	Unikraft struct {
 		Mirrors   []string `json:"mirrors"   yaml:"mirrors"   env:"KRAFTKIT_UNIKRAFT_MIRRORS"`
 		Manifests []string `json:"manifests" yaml:"manifests" env:"KRAFTKIT_UNIKRAFT_MANIFESTS"`
+
+		// TrustedKeys is a list of ed25519 public keys (either raw and base64
+		// encoded or in minisign format) which are used to verify the signatures
+		// of manifest indexes and manifests.  When set, unsigned or incorrectly
+		// signed manifests are rejected.
+		TrustedKeys []string `json:"trusted_keys" yaml:"trusted_keys,omitempty" env:"KRAFTKIT_UNIKRAFT_TRUSTED_KEYS"`
 	} `json:"unikraft" yaml:"unikraft"`
 
 	Auth map[string]AuthConfig `json:"auth" yaml:"auth,omitempty"`
@@ -167,11 +174,16 @@ func NewDefaultConfig() (*Config, error) {
 		c.Paths.Manifests = filepath.Join(DataDir(), "manifests")
 	}
 
-	// ..and for cached source files
+	// ..for cached source files..
 	if len(c.Paths.Sources) == 0 {
 		c.Paths.Sources = filepath.Join(DataDir(), "sources")
 	}
 
+	// ..and for the local OCI image layout
+	if len(c.Paths.OCI) == 0 {
+		c.Paths.OCI = filepath.Join(DataDir(), "oci")
+	}
+
 	return c, nil
 }
 
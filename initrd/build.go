@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package initrd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+// inputPath returns the absolute path on the host of a given input entry.
+// Entries may be prefixed by a base directory followed by the InputDelimeter,
+// as generated by ParseInitrdConfig, otherwise they are resolved relative to
+// the working directory of the configuration.
+func (i *InitrdConfig) inputPath(input string) string {
+	split := strings.SplitN(input, InputDelimeter, 2)
+	if len(split) == 2 {
+		if filepath.IsAbs(split[1]) {
+			return split[1]
+		}
+
+		return filepath.Join(split[0], split[1])
+	}
+
+	return i.RelativePath(input)
+}
+
+// Build serializes all the inputs of the initramfs configuration into a CPIO
+// archive.  If the configuration does not specify an output, a temporary file
+// is created.  The path to the resulting archive is returned.
+func (i *InitrdConfig) Build() (string, error) {
+	if len(i.Input) == 0 {
+		// Nothing to build but an existing output may already be provided
+		if len(i.Output) > 0 {
+			if f, err := os.Stat(i.Output); err == nil && !f.IsDir() {
+				return i.Output, nil
+			}
+		}
+
+		return "", fmt.Errorf("initramfs has no inputs")
+	}
+
+	var f *os.File
+	var err error
+
+	if len(i.Output) > 0 {
+		if err := os.MkdirAll(filepath.Dir(i.Output), 0o755); err != nil {
+			return "", fmt.Errorf("could not create parent directories: %v", err)
+		}
+
+		f, err = os.OpenFile(i.Output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	} else {
+		f, err = ioutil.TempFile("", "initramfs-*.cpio")
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not open initramfs file: %v", err)
+	}
+
+	defer f.Close()
+
+	writer, err := i.NewWriter(f)
+	if err != nil {
+		return "", err
+	}
+
+	for _, input := range i.Input {
+		if err := writeInput(writer, i.inputPath(input)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not finalize initramfs: %v", err)
+	}
+
+	return f.Name(), nil
+}
+
+// writeInput walks the provided path and writes each entry into the CPIO
+// archive relative to the root of the path.  If the path is a single file, it
+// is placed at the root of the archive.
+func writeInput(writer *cpio.Writer, root string) error {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("could not access initramfs input: %v", err)
+	}
+
+	base := root
+	if !fi.IsDir() {
+		base = filepath.Dir(root)
+	}
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		header, err := cpio.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("could not create header for %s: %v", path, err)
+		}
+
+		// CPIO entries always use forward slashes
+		header.Name = filepath.ToSlash(rel)
+
+		// The contents of a symbolic link is the path it points to
+		if len(link) > 0 {
+			header.Size = int64(len(link))
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write header for %s: %v", path, err)
+		}
+
+		switch {
+		case info.Mode().IsRegular():
+			data, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+
+			defer data.Close()
+
+			if _, err := io.Copy(writer, data); err != nil {
+				return fmt.Errorf("could not write %s: %v", path, err)
+			}
+
+		case link != "":
+			if _, err := writer.Write([]byte(link)); err != nil {
+				return fmt.Errorf("could not write link %s: %v", path, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffKind describes how the value of a symbol in a `.config` file differs
+// from the value which was requested
+type DiffKind string
+
+const (
+	// DiffMissing is a requested symbol which is not present
+	DiffMissing DiffKind = "missing"
+
+	// DiffOverridden is a requested symbol which has a different value
+	DiffOverridden DiffKind = "overridden"
+
+	// DiffExtra is a symbol which was not requested but differs from its
+	// default value
+	DiffExtra DiffKind = "extra"
+)
+
+// Diff is a difference between a `.config` file and the requested values
+type Diff struct {
+	Kind      DiffKind
+	Name      string
+	Requested string
+	Actual    string
+
+	// Reason explains the difference, e.g. unmet dependencies
+	Reason string
+}
+
+// Compare compares the requested values of symbols, keyed by their name in the
+// `.config` file, with the `.config` file.  Symbols which have not been
+// requested are reported if they differ from their default value.  The
+// differences are sorted by their kind and name.
+func Compare(tree *Tree, requested map[string]string, dc *DotConfig) []Diff {
+	var diffs []Diff
+	lookup := tree.Lookup(dc)
+
+	for name, want := range requested {
+		want = trimQuotes(want)
+		actual, ok := dc.Get(name)
+
+		switch {
+		case !ok && want == "n":
+			// Symbols which are disabled may be omitted entirely
+			continue
+		case !ok:
+			diffs = append(diffs, Diff{
+				Kind:      DiffMissing,
+				Name:      name,
+				Requested: want,
+				Reason:    tree.reason(name, want, lookup),
+			})
+		case actual != want:
+			diffs = append(diffs, Diff{
+				Kind:      DiffOverridden,
+				Name:      name,
+				Requested: want,
+				Actual:    actual,
+				Reason:    tree.reason(name, want, lookup),
+			})
+		}
+	}
+
+	for _, name := range dc.Names() {
+		if _, ok := requested[name]; ok {
+			continue
+		}
+
+		actual, _ := dc.Get(name)
+
+		sym, ok := tree.Symbol(name)
+		if !ok || !strings.HasPrefix(name, Prefix) {
+			diffs = append(diffs, Diff{
+				Kind:   DiffExtra,
+				Name:   name,
+				Actual: actual,
+				Reason: "unknown option",
+			})
+			continue
+		}
+
+		if def := tree.defaultValue(sym, lookup); def != actual {
+			diffs = append(diffs, Diff{
+				Kind:   DiffExtra,
+				Name:   name,
+				Actual: actual,
+				Reason: fmt.Sprintf("default is %s", displayValue(def)),
+			})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Kind != diffs[j].Kind {
+			return diffs[i].Kind < diffs[j].Kind
+		}
+
+		return diffs[i].Name < diffs[j].Name
+	})
+
+	return diffs
+}
+
+// reason explains why the requested value of a symbol was not applied
+func (t *Tree) reason(name, want string, lookup Lookup) string {
+	if !strings.HasPrefix(name, Prefix) {
+		return fmt.Sprintf("missing %s prefix", Prefix)
+	}
+
+	sym, ok := t.Symbol(name)
+	if !ok {
+		return "unknown option"
+	}
+
+	if err := t.Validate(NewDotConfig(), name, want); err != nil {
+		return strings.TrimPrefix(err.Error(), "invalid value of "+name+": ")
+	}
+
+	v, _ := ParseTristate(want)
+	if deps := sym.DependsOn(); deps != nil && (!sym.IsTristate() || v > No) && deps.Eval(lookup) < v {
+		return fmt.Sprintf("depends on %s", deps)
+	}
+
+	if sym.IsTristate() && v == No {
+		for _, by := range t.selectors[sym.Name] {
+			if t.selects(by, lookup) > No {
+				return fmt.Sprintf("selected by %s", by.symbol.ConfigName())
+			}
+		}
+	}
+
+	if sym.Choice != nil && v == Yes {
+		for _, node := range sym.Choice.Children {
+			if node.Symbol == nil || node.Symbol == sym {
+				continue
+			}
+
+			if value, _ := lookup(node.Symbol.Name); value == "y" {
+				return fmt.Sprintf("%s is chosen instead", node.Symbol.ConfigName())
+			}
+		}
+	}
+
+	return ""
+}
+
+// Default returns the default value of the named symbol given the values of
+// the other symbols in the `.config` file
+func (t *Tree) Default(dc *DotConfig, name string) (string, bool) {
+	sym, ok := t.Symbol(name)
+	if !ok {
+		return "", false
+	}
+
+	return t.defaultValue(sym, t.Lookup(dc)), true
+}
+
+// trimQuotes removes the quotes around a string value
+func trimQuotes(value string) string {
+	if unquoted, ok := unquote(value); ok {
+		return unquoted
+	}
+
+	return value
+}
+
+// displayValue formats a value for display, where empty values are quoted
+func displayValue(value string) string {
+	if len(value) == 0 {
+		return `""`
+	}
+
+	return value
+}
@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	dotConfigSetRe   = regexp.MustCompile(`^([A-Za-z0-9_]+)=(.*)$`)
+	dotConfigUnsetRe = regexp.MustCompile(`^# ([A-Za-z0-9_]+) is not set$`)
+)
+
+// dotConfigLine is a single line of a `.config` file.  Lines which do not
+// assign an option, e.g. comments, are retained verbatim.
+type dotConfigLine struct {
+	raw    string
+	name   string
+	value  string
+	quoted bool
+	notSet bool
+}
+
+// String formats the line as it appears in a `.config` file
+func (l dotConfigLine) String() string {
+	switch {
+	case len(l.name) == 0:
+		return l.raw
+	case l.notSet:
+		return fmt.Sprintf("# %s is not set", l.name)
+	case l.quoted:
+		return fmt.Sprintf("%s=%s", l.name, quote(l.value))
+	}
+
+	return fmt.Sprintf("%s=%s", l.name, l.value)
+}
+
+// DotConfig is the contents of a `.config` file.  The order of its lines and
+// any comments are preserved such that the file can be edited precisely.
+type DotConfig struct {
+	lines []*dotConfigLine
+}
+
+// NewDotConfig returns an empty `.config` file
+func NewDotConfig() *DotConfig {
+	return &DotConfig{}
+}
+
+// ParseDotConfig reads the contents of a `.config` file
+func ParseDotConfig(r io.Reader) (*DotConfig, error) {
+	dc := &DotConfig{}
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		raw := scanner.Text()
+		line := &dotConfigLine{raw: raw}
+
+		if matches := dotConfigUnsetRe.FindStringSubmatch(raw); matches != nil {
+			line.name = matches[1]
+			line.value = "n"
+			line.notSet = true
+		} else if matches := dotConfigSetRe.FindStringSubmatch(raw); matches != nil {
+			line.name = matches[1]
+			line.value = matches[2]
+
+			if strings.HasPrefix(line.value, "\"") {
+				value, ok := unquote(line.value)
+				if !ok {
+					return nil, fmt.Errorf("line %d: malformed value of %s: %s", n, line.name, line.value)
+				}
+
+				line.value = value
+				line.quoted = true
+			}
+		} else if len(strings.TrimSpace(raw)) > 0 && !strings.HasPrefix(strings.TrimSpace(raw), "#") {
+			return nil, fmt.Errorf("line %d: malformed option: %s", n, raw)
+		}
+
+		dc.lines = append(dc.lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+// NewDotConfigFromFile reads the `.config` file at the provided path
+func NewDotConfigFromFile(path string) (*DotConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	dc, err := ParseDotConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	return dc, nil
+}
+
+// find returns the line which assigns the named option
+func (dc *DotConfig) find(name string) *dotConfigLine {
+	for _, line := range dc.lines {
+		if line.name == name {
+			return line
+		}
+	}
+
+	return nil
+}
+
+// Get returns the value of the named option and whether it is present in the
+// file.  An option which "is not set" has the value "n".
+func (dc *DotConfig) Get(name string) (string, bool) {
+	line := dc.find(name)
+	if line == nil {
+		return "", false
+	}
+
+	return line.value, true
+}
+
+// Set assigns the value of the named option, replacing its previous value in
+// place or otherwise appending it to the file.  Numbers and tristate values
+// are written verbatim, "n" is written as "is not set" and any other value is
+// written as a string.
+func (dc *DotConfig) Set(name, value string) {
+	_, err := strconv.ParseFloat(value, 64)
+	dc.set(name, value, err != nil && value != "y" && value != "m" && value != "n")
+}
+
+// set assigns the value of the named option which is written as a string if
+// quoted
+func (dc *DotConfig) set(name, value string, quoted bool) {
+	line := dc.find(name)
+	if line == nil {
+		line = &dotConfigLine{name: name}
+		dc.lines = append(dc.lines, line)
+	}
+
+	line.value = value
+	line.quoted = quoted
+	line.notSet = !quoted && value == "n"
+}
+
+// Unset removes the named option from the file and returns whether it was
+// present
+func (dc *DotConfig) Unset(name string) bool {
+	for i, line := range dc.lines {
+		if line.name == name {
+			dc.lines = append(dc.lines[:i], dc.lines[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Names returns the sorted names of the options present in the file
+func (dc *DotConfig) Names() []string {
+	var names []string
+
+	for _, line := range dc.lines {
+		if len(line.name) > 0 {
+			names = append(names, line.name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Map returns the values of the options present in the file
+func (dc *DotConfig) Map() map[string]string {
+	values := map[string]string{}
+
+	for _, line := range dc.lines {
+		if len(line.name) > 0 {
+			values[line.name] = line.value
+		}
+	}
+
+	return values
+}
+
+// WriteTo writes the contents of the `.config` file to the writer
+func (dc *DotConfig) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+
+	for _, line := range dc.lines {
+		n, err := fmt.Fprintln(w, line.String())
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// WriteFile writes the contents of the `.config` file to the provided path
+func (dc *DotConfig) WriteFile(path string) error {
+	var b strings.Builder
+
+	if _, err := dc.WriteTo(&b); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// quote formats the value as a string of a `.config` file, where only
+// backslashes and double quotes are escaped
+func quote(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+
+	return "\"" + value + "\""
+}
+
+// unquote parses a string of a `.config` file, where a backslash escapes the
+// following character
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+
+	var b strings.Builder
+	s = s[1 : len(s)-1]
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", false
+			}
+		case '"':
+			return "", false
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String(), true
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotConfig(t *testing.T) {
+	dc, err := ParseDotConfig(strings.NewReader(`#
+# Automatically generated file; DO NOT EDIT.
+#
+CONFIG_UK_NAME="hello \"world\""
+# CONFIG_LIBUKDEBUG is not set
+CONFIG_LIBUKALLOC=y
+
+CONFIG_STACK_SIZE_PAGE_ORDER=4
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := dc.Get("CONFIG_UK_NAME"); !ok || v != `hello "world"` {
+		t.Errorf("unexpected value of CONFIG_UK_NAME: %q", v)
+	}
+
+	if v, ok := dc.Get("CONFIG_LIBUKDEBUG"); !ok || v != "n" {
+		t.Errorf("expected CONFIG_LIBUKDEBUG to not be set")
+	}
+
+	dc.Set("CONFIG_LIBUKDEBUG", "y")
+	dc.Set("CONFIG_LIBUKALLOC", "n")
+	dc.Set("CONFIG_LIBDEVFS_DEV_STDOUT", "/dev/null")
+
+	if !dc.Unset("CONFIG_STACK_SIZE_PAGE_ORDER") || dc.Unset("CONFIG_STACK_SIZE_PAGE_ORDER") {
+		t.Errorf("expected CONFIG_STACK_SIZE_PAGE_ORDER to be removed once")
+	}
+
+	var b strings.Builder
+	if _, err := dc.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#
+# Automatically generated file; DO NOT EDIT.
+#
+CONFIG_UK_NAME="hello \"world\""
+CONFIG_LIBUKDEBUG=y
+# CONFIG_LIBUKALLOC is not set
+
+CONFIG_LIBDEVFS_DEV_STDOUT="/dev/null"
+`
+	if b.String() != expected {
+		t.Errorf("unexpected .config:\n%s", b.String())
+	}
+
+	for _, invalid := range []string{"CONFIG_FOO", `CONFIG_FOO="bar`} {
+		if _, err := ParseDotConfig(strings.NewReader(invalid)); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
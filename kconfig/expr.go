@@ -0,0 +1,426 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tristate is the value of a bool or tristate symbol or of an expression
+type Tristate int
+
+const (
+	No Tristate = iota
+	Mod
+	Yes
+)
+
+// String returns the value as it appears in a `.config` file
+func (t Tristate) String() string {
+	switch t {
+	case Yes:
+		return "y"
+	case Mod:
+		return "m"
+	}
+
+	return "n"
+}
+
+// ParseTristate parses the value of a bool or tristate symbol
+func ParseTristate(value string) (Tristate, bool) {
+	switch value {
+	case "y":
+		return Yes, true
+	case "m":
+		return Mod, true
+	case "n":
+		return No, true
+	}
+
+	return No, false
+}
+
+// Lookup returns the value of the named symbol, or false if there is no such
+// symbol in which case the name is interpreted as a constant
+type Lookup func(name string) (string, bool)
+
+// Expr is an expression of the KConfig language, e.g. the condition of a
+// `depends on` or an `if`
+type Expr interface {
+	// Eval returns the value of the expression
+	Eval(lookup Lookup) Tristate
+
+	// Symbols returns the names of the symbols referenced by the expression
+	Symbols() []string
+
+	// String returns the expression as written in a `Config.uk` file
+	String() string
+}
+
+// exprSymbol is a reference to a symbol or a constant
+type exprSymbol struct {
+	name   string
+	quoted bool
+}
+
+// value returns the string value of the symbol or constant
+func (e exprSymbol) value(lookup Lookup) string {
+	if e.quoted {
+		return e.name
+	}
+
+	if value, ok := lookup(e.name); ok {
+		return value
+	}
+
+	return e.name
+}
+
+func (e exprSymbol) Eval(lookup Lookup) Tristate {
+	if t, ok := ParseTristate(e.value(lookup)); ok {
+		return t
+	}
+
+	// Symbols which are not of type bool or tristate evaluate to n
+	return No
+}
+
+func (e exprSymbol) Symbols() []string {
+	if e.quoted {
+		return nil
+	}
+
+	if _, ok := ParseTristate(e.name); ok {
+		return nil
+	}
+
+	return []string{e.name}
+}
+
+func (e exprSymbol) String() string {
+	if e.quoted {
+		return quote(e.name)
+	}
+
+	return e.name
+}
+
+// exprNot is the negation of an expression
+type exprNot struct {
+	expr Expr
+}
+
+func (e exprNot) Eval(lookup Lookup) Tristate {
+	return Yes - e.expr.Eval(lookup)
+}
+
+func (e exprNot) Symbols() []string {
+	return e.expr.Symbols()
+}
+
+func (e exprNot) String() string {
+	switch e.expr.(type) {
+	case exprSymbol, exprCompare:
+		return "!" + e.expr.String()
+	}
+
+	return "!(" + e.expr.String() + ")"
+}
+
+// exprAnd is the conjunction of two expressions
+type exprAnd struct {
+	left, right Expr
+}
+
+func (e exprAnd) Eval(lookup Lookup) Tristate {
+	left, right := e.left.Eval(lookup), e.right.Eval(lookup)
+	if left < right {
+		return left
+	}
+
+	return right
+}
+
+func (e exprAnd) Symbols() []string {
+	return append(e.left.Symbols(), e.right.Symbols()...)
+}
+
+func (e exprAnd) String() string {
+	return parenthesize(e.left) + " && " + parenthesize(e.right)
+}
+
+// exprOr is the disjunction of two expressions
+type exprOr struct {
+	left, right Expr
+}
+
+func (e exprOr) Eval(lookup Lookup) Tristate {
+	left, right := e.left.Eval(lookup), e.right.Eval(lookup)
+	if left > right {
+		return left
+	}
+
+	return right
+}
+
+func (e exprOr) Symbols() []string {
+	return append(e.left.Symbols(), e.right.Symbols()...)
+}
+
+func (e exprOr) String() string {
+	return e.left.String() + " || " + e.right.String()
+}
+
+// exprCompare compares the values of two symbols or constants
+type exprCompare struct {
+	op          string
+	left, right exprSymbol
+}
+
+func (e exprCompare) Eval(lookup Lookup) Tristate {
+	left, right := e.left.value(lookup), e.right.value(lookup)
+
+	// Values are compared numerically if both are numbers
+	cmp := strings.Compare(left, right)
+	if l, err := strconv.ParseInt(left, 0, 64); err == nil {
+		if r, err := strconv.ParseInt(right, 0, 64); err == nil {
+			cmp = 0
+			if l < r {
+				cmp = -1
+			} else if l > r {
+				cmp = 1
+			}
+		}
+	}
+
+	var ok bool
+	switch e.op {
+	case "=":
+		ok = cmp == 0
+	case "!=":
+		ok = cmp != 0
+	case "<":
+		ok = cmp < 0
+	case "<=":
+		ok = cmp <= 0
+	case ">":
+		ok = cmp > 0
+	case ">=":
+		ok = cmp >= 0
+	}
+
+	if ok {
+		return Yes
+	}
+
+	return No
+}
+
+func (e exprCompare) Symbols() []string {
+	return append(e.left.Symbols(), e.right.Symbols()...)
+}
+
+func (e exprCompare) String() string {
+	return fmt.Sprintf("%s %s %s", e.left, e.op, e.right)
+}
+
+// parenthesize formats the operand of a conjunction
+func parenthesize(e Expr) string {
+	if _, ok := e.(exprOr); ok {
+		return "(" + e.String() + ")"
+	}
+
+	return e.String()
+}
+
+// and returns the conjunction of the expressions, either of which may be nil
+func and(left, right Expr) Expr {
+	if left == nil {
+		return right
+	} else if right == nil {
+		return left
+	}
+
+	return exprAnd{left, right}
+}
+
+// or returns the disjunction of the expressions, where nil is always satisfied
+func or(left, right Expr) Expr {
+	if left == nil || right == nil {
+		return nil
+	}
+
+	return exprOr{left, right}
+}
+
+// exprParser parses an expression from the tokens of a line
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// ParseExpr parses an expression of the KConfig language
+func ParseExpr(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseExpr(tokens)
+}
+
+// parseExpr parses all of the tokens as a single expression
+func parseExpr(tokens []token) (Expr, error) {
+	p := &exprParser{tokens: tokens}
+
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s in expression", p.tokens[p.pos].text)
+	}
+
+	return expr, nil
+}
+
+func (p *exprParser) peek() (token, bool) {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos], true
+	}
+
+	return token{}, false
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t, ok := p.peek(); ok && t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *exprParser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		left = exprOr{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) and() (Expr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+
+		left = exprAnd{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) not() (Expr, error) {
+	if p.accept("!") {
+		expr, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+
+		return exprNot{expr}, nil
+	}
+
+	if p.accept("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ) in expression")
+		}
+
+		return expr, nil
+	}
+
+	left, err := p.symbol()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"=", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.symbol()
+			if err != nil {
+				return nil, err
+			}
+
+			return exprCompare{op, left, right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) symbol() (exprSymbol, error) {
+	t, ok := p.peek()
+	if !ok {
+		return exprSymbol{}, fmt.Errorf("unexpected end of expression")
+	} else if t.kind == tokenOp {
+		return exprSymbol{}, fmt.Errorf("unexpected %s in expression", t.text)
+	}
+
+	p.pos++
+
+	return exprSymbol{name: t.text, quoted: t.kind == tokenString}, nil
+}
@@ -0,0 +1,599 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package kconfig parses the KConfig language as used by the `Config.uk` files
+// of Unikraft and its components and reads and writes `.config` files, such
+// that configuration options can be validated and edited without invoking
+// Unikraft's build system.
+package kconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefix is prepended to the names of symbols in `.config` files
+const Prefix = "CONFIG_"
+
+// Type is the type of the value of a symbol
+type Type int
+
+const (
+	TypeUnknown Type = iota
+	TypeBool
+	TypeTristate
+	TypeString
+	TypeHex
+	TypeInt
+)
+
+// String returns the type as written in a `Config.uk` file
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeTristate:
+		return "tristate"
+	case TypeString:
+		return "string"
+	case TypeHex:
+		return "hex"
+	case TypeInt:
+		return "int"
+	}
+
+	return "unknown"
+}
+
+// NodeKind is the kind of an entry of the menu tree
+type NodeKind int
+
+const (
+	NodeMenu NodeKind = iota
+	NodeConfig
+	NodeChoice
+	NodeComment
+)
+
+// Node is an entry of the menu tree, i.e. a menu, a symbol definition, a
+// choice or a comment
+type Node struct {
+	Kind       NodeKind
+	Symbol     *Symbol
+	Prompt     string
+	PromptIf   Expr
+	Help       string
+	MenuConfig bool
+	Optional   bool
+
+	// DependsOn is the condition of the entry's own `depends on` attributes,
+	// whereas Dependencies also includes those of the enclosing menus, choices
+	// and `if` blocks
+	DependsOn    Expr
+	Dependencies Expr
+
+	// Visible is the condition of a menu's `visible if` attribute
+	Visible Expr
+
+	Parent   *Node
+	Children []*Node
+
+	File string
+	Line int
+}
+
+// Default is a `default` attribute of a symbol
+type Default struct {
+	Value Expr
+	If    Expr
+}
+
+// Reverse is a `select` or `imply` attribute of a symbol
+type Reverse struct {
+	Symbol string
+	If     Expr
+}
+
+// Range is a `range` attribute of an int or hex symbol
+type Range struct {
+	Min, Max exprSymbol
+	If       Expr
+}
+
+// Symbol is a configuration option which may be defined by multiple entries of
+// the menu tree
+type Symbol struct {
+	Name     string
+	Type     Type
+	Defaults []Default
+	Selects  []Reverse
+	Implies  []Reverse
+	Ranges   []Range
+	Nodes    []*Node
+
+	// Choice is the choice the symbol is a member of, if any
+	Choice *Node
+}
+
+// ConfigName returns the name of the symbol as it appears in `.config` files
+func (s *Symbol) ConfigName() string {
+	return Prefix + s.Name
+}
+
+// Prompt returns the first prompt of the symbol, which is empty if the symbol
+// cannot be set by the user
+func (s *Symbol) Prompt() string {
+	for _, node := range s.Nodes {
+		if len(node.Prompt) > 0 {
+			return node.Prompt
+		}
+	}
+
+	return ""
+}
+
+// Help returns the first help text of the symbol
+func (s *Symbol) Help() string {
+	for _, node := range s.Nodes {
+		if len(node.Help) > 0 {
+			return node.Help
+		}
+	}
+
+	return ""
+}
+
+// DependsOn returns the dependencies of the symbol, i.e. those of any of its
+// definitions, or nil if the symbol does not depend on other symbols
+func (s *Symbol) DependsOn() Expr {
+	var deps Expr
+
+	for i, node := range s.Nodes {
+		if i == 0 {
+			deps = node.Dependencies
+		} else {
+			deps = or(deps, node.Dependencies)
+		}
+	}
+
+	return deps
+}
+
+// IsTristate returns whether the value of the symbol is a bool or a tristate
+func (s *Symbol) IsTristate() bool {
+	return s.Type == TypeBool || s.Type == TypeTristate
+}
+
+// zero returns the value of the symbol when it is disabled
+func (s *Symbol) zero() string {
+	if s.IsTristate() {
+		return "n"
+	}
+
+	return ""
+}
+
+// Symbol returns the named symbol, with or without the `CONFIG_` prefix
+func (t *Tree) Symbol(name string) (*Symbol, bool) {
+	sym, ok := t.symbols[strings.TrimPrefix(name, Prefix)]
+	return sym, ok
+}
+
+// Symbols returns the symbols of the tree in the order of their definition
+func (t *Tree) Symbols() []*Symbol {
+	return t.order
+}
+
+// Lookup returns the values of the symbols of the tree, which are read from
+// the `.config` file or otherwise computed from their defaults
+func (t *Tree) Lookup(dc *DotConfig) Lookup {
+	visiting := map[string]bool{}
+
+	var lookup Lookup
+	lookup = func(name string) (string, bool) {
+		sym, ok := t.symbols[name]
+		if !ok {
+			return "", false
+		}
+
+		if value, ok := dc.Get(sym.ConfigName()); ok {
+			return value, true
+		}
+
+		// Break cycles between the defaults of symbols
+		if visiting[name] {
+			return sym.zero(), true
+		}
+
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		return t.defaultValue(sym, lookup), true
+	}
+
+	return lookup
+}
+
+// defaultValue computes the value of a symbol which is not present in the
+// `.config` file
+func (t *Tree) defaultValue(sym *Symbol, lookup Lookup) string {
+	limit := Yes
+	if deps := sym.DependsOn(); deps != nil {
+		limit = deps.Eval(lookup)
+	}
+
+	if limit == No {
+		return sym.zero()
+	}
+
+	// Exactly one member of a choice is enabled by default
+	if sym.Choice != nil && sym.IsTristate() {
+		if t.chosen(sym.Choice, lookup) == sym {
+			return "y"
+		}
+
+		return "n"
+	}
+
+	value := No
+	for _, d := range sym.Defaults {
+		if d.If != nil && d.If.Eval(lookup) == No {
+			continue
+		}
+
+		if !sym.IsTristate() {
+			if s, ok := d.Value.(exprSymbol); ok {
+				return s.value(lookup)
+			}
+
+			return d.Value.Eval(lookup).String()
+		}
+
+		value = d.Value.Eval(lookup)
+		break
+	}
+
+	if !sym.IsTristate() {
+		return ""
+	}
+
+	if value > limit {
+		value = limit
+	}
+
+	// Symbols which are selected are enabled regardless of their defaults
+	for _, by := range t.selectors[sym.Name] {
+		if v := t.selects(by, lookup); v > value {
+			value = v
+		}
+	}
+
+	if sym.Type == TypeBool && value == Mod {
+		value = Yes
+	}
+
+	return value.String()
+}
+
+// chosen returns the member of a choice which is enabled by default, i.e. its
+// first default whose condition holds or otherwise its first visible member
+func (t *Tree) chosen(choice *Node, lookup Lookup) *Symbol {
+	visible := func(sym *Symbol) bool {
+		deps := sym.DependsOn()
+		return deps == nil || deps.Eval(lookup) > No
+	}
+
+	for _, d := range choice.Symbol.Defaults {
+		if d.If != nil && d.If.Eval(lookup) == No {
+			continue
+		}
+
+		if s, ok := d.Value.(exprSymbol); ok {
+			if sym, ok := t.symbols[s.name]; ok && sym.Choice == choice && visible(sym) {
+				return sym
+			}
+		}
+	}
+
+	for _, node := range choice.Children {
+		if node.Kind == NodeConfig && node.Symbol != nil && visible(node.Symbol) {
+			return node.Symbol
+		}
+	}
+
+	return nil
+}
+
+// selects returns the value a symbol is selected with
+func (t *Tree) selects(by selector, lookup Lookup) Tristate {
+	value, _ := lookup(by.symbol.Name)
+
+	v, _ := ParseTristate(value)
+	if by.cond != nil {
+		if c := by.cond.Eval(lookup); c < v {
+			v = c
+		}
+	}
+
+	return v
+}
+
+// Validate checks that the named symbol exists and that the value is valid for
+// its type and range
+func (t *Tree) Validate(dc *DotConfig, name, value string) error {
+	sym, ok := t.Symbol(name)
+	if !ok {
+		return t.unknown(name)
+	}
+
+	switch sym.Type {
+	case TypeBool:
+		if value != "y" && value != "n" {
+			return fmt.Errorf("invalid value of %s: %s is not a bool (y or n)", sym.ConfigName(), value)
+		}
+
+	case TypeTristate:
+		if _, ok := ParseTristate(value); !ok {
+			return fmt.Errorf("invalid value of %s: %s is not a tristate (y, m or n)", sym.ConfigName(), value)
+		}
+
+	case TypeInt, TypeHex:
+		n, err := parseNumber(sym.Type, value)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %s is not of type %s", sym.ConfigName(), value, sym.Type)
+		}
+
+		lookup := t.Lookup(dc)
+
+		for _, r := range sym.Ranges {
+			if r.If != nil && r.If.Eval(lookup) == No {
+				continue
+			}
+
+			min, err := parseNumber(sym.Type, r.Min.value(lookup))
+			if err != nil {
+				break
+			}
+
+			max, err := parseNumber(sym.Type, r.Max.value(lookup))
+			if err != nil {
+				break
+			}
+
+			if n < min || n > max {
+				return fmt.Errorf("invalid value of %s: %s is not within %s and %s", sym.ConfigName(), value, r.Min.value(lookup), r.Max.value(lookup))
+			}
+
+			break
+		}
+
+	case TypeString:
+
+	default:
+		return fmt.Errorf("%s has no type and cannot be set", sym.ConfigName())
+	}
+
+	return nil
+}
+
+// parseNumber parses the value of an int or hex symbol
+func parseNumber(typ Type, value string) (int64, error) {
+	if typ == TypeHex {
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+		n, err := strconv.ParseUint(value, 16, 63)
+		return int64(n), err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Set validates the value of the named symbol and assigns it in the `.config`
+// file if the symbol's dependencies are satisfied and it is not selected by
+// an enabled symbol.  The symbols which it selects are enabled accordingly and
+// the other members of its choice are disabled.
+func (t *Tree) Set(dc *DotConfig, name, value string) error {
+	if err := t.Validate(dc, name, value); err != nil {
+		return err
+	}
+
+	sym, _ := t.Symbol(name)
+
+	if deps := sym.DependsOn(); deps != nil {
+		want := Yes
+		if sym.IsTristate() {
+			want, _ = ParseTristate(value)
+		}
+
+		if want > No && deps.Eval(t.Lookup(dc)) < want {
+			return fmt.Errorf("cannot set %s: depends on %s", sym.ConfigName(), deps)
+		}
+	}
+
+	// A symbol cannot be disabled below the value it is selected with
+	if want, ok := ParseTristate(value); ok && sym.IsTristate() {
+		lookup := t.Lookup(dc)
+
+		for _, by := range t.selectors[sym.Name] {
+			if t.selects(by, lookup) > want {
+				return fmt.Errorf("cannot set %s=%s: selected by %s", sym.ConfigName(), value, by.symbol.ConfigName())
+			}
+		}
+	}
+
+	t.assign(dc, sym, value, map[string]bool{})
+
+	return nil
+}
+
+// assign writes the value of the symbol to the `.config` file and propagates
+// it to the symbols it selects and the other members of its choice
+func (t *Tree) assign(dc *DotConfig, sym *Symbol, value string, visited map[string]bool) {
+	if visited[sym.Name] {
+		return
+	}
+
+	visited[sym.Name] = true
+	dc.set(sym.ConfigName(), value, sym.Type == TypeString)
+
+	v, ok := ParseTristate(value)
+	if !ok || v == No || !sym.IsTristate() {
+		return
+	}
+
+	if sym.Choice != nil && v == Yes {
+		for _, node := range sym.Choice.Children {
+			if node.Symbol != nil && node.Symbol != sym && node.Kind == NodeConfig {
+				dc.set(node.Symbol.ConfigName(), "n", false)
+			}
+		}
+	}
+
+	lookup := t.Lookup(dc)
+
+	for _, sel := range sym.Selects {
+		target, ok := t.symbols[sel.Symbol]
+		if !ok || !target.IsTristate() {
+			continue
+		}
+
+		want := v
+		if sel.If != nil {
+			if c := sel.If.Eval(lookup); c < want {
+				want = c
+			}
+		}
+
+		if target.Type == TypeBool && want == Mod {
+			want = Yes
+		}
+
+		// Selected symbols are written explicitly, as Unikraft's build system
+		// would when synchronizing the configuration
+		current, ok := dc.Get(target.ConfigName())
+		if c, _ := ParseTristate(current); !ok || c < want {
+			t.assign(dc, target, want.String(), visited)
+		}
+	}
+}
+
+// Unset removes the named symbol from the `.config` file such that it takes
+// its default value again.  A symbol which is selected by an enabled symbol
+// cannot be unset.
+func (t *Tree) Unset(dc *DotConfig, name string) error {
+	sym, ok := t.Symbol(name)
+	if !ok {
+		return t.unknown(name)
+	}
+
+	lookup := t.Lookup(dc)
+
+	for _, by := range t.selectors[sym.Name] {
+		if t.selects(by, lookup) > No {
+			return fmt.Errorf("cannot unset %s: selected by %s", sym.ConfigName(), by.symbol.ConfigName())
+		}
+	}
+
+	dc.Unset(sym.ConfigName())
+
+	return nil
+}
+
+// SelectedBy returns the symbols which select the named symbol
+func (t *Tree) SelectedBy(name string) []*Symbol {
+	var symbols []*Symbol
+
+	for _, by := range t.selectors[strings.TrimPrefix(name, Prefix)] {
+		symbols = append(symbols, by.symbol)
+	}
+
+	return symbols
+}
+
+// unknown returns an error for an unknown symbol which suggests the most
+// similar known symbol
+func (t *Tree) unknown(name string) error {
+	name = strings.TrimPrefix(name, Prefix)
+
+	var candidates []string
+	best := len(name)/3 + 1
+
+	for known := range t.symbols {
+		d := distance(strings.ToUpper(name), known)
+		if d < best {
+			best = d
+			candidates = []string{known}
+		} else if d == best {
+			candidates = append(candidates, known)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return fmt.Errorf("unknown option %s%s", Prefix, name)
+	}
+
+	sort.Strings(candidates)
+
+	return fmt.Errorf("unknown option %s%s, did you mean %s%s?", Prefix, name, Prefix, candidates[0])
+}
+
+// distance returns the Levenshtein distance between two strings
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = cur[j-1] + 1
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
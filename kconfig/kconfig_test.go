@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigUk = `mainmenu "Unikraft/$(UK_FULLVERSION) Configuration"
+
+menu "Architecture Selection"
+choice
+	prompt "Architecture"
+	default ARCH_X86_64
+
+config ARCH_X86_64
+	bool "x86 compatible (64 bits)"
+
+config ARCH_ARM_64
+	bool "Armv8 compatible (64 bits)"
+endchoice
+endmenu
+
+config UK_NAME
+	string "Application name"
+
+source "$(UK_BASE)/lib/Config.uk"
+source "$(KCONFIG_LIB_IN)"
+`
+
+const testLibConfigUk = `menuconfig LIBUKNETDEV
+	bool "uknetdev: Network driver interface"
+	select LIBUKALLOC
+	default n
+	help
+	  A network driver interface.
+
+	  Drivers register themselves here.
+
+if LIBUKNETDEV
+config LIBUKNETDEV_MAXNBQUEUES
+	int "Maximum number of queues"
+	range 1 256
+	default 1
+
+config LIBUKNETDEV_DISPATCHERTHREADS
+	bool "Dispatcher threads"
+	depends on LIBUKSCHED && \
+		LIBUKLOCK
+endif
+
+config LIBUKALLOC
+	bool "ukalloc: Memory allocator"
+
+config LIBUKSCHED
+	bool "uksched: Scheduler"
+
+config LIBUKLOCK
+	bool "uklock: Locks"
+	default y if ARCH_ARM_64 || LIBUKSCHED = y
+
+config LIBUKDEBUG_PRINTK_LEVEL
+	hex "Log level"
+	default 0x3
+`
+
+func testTree(t *testing.T) *Tree {
+	dir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Config.uk"), []byte(testConfigUk), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "lib", "Config.uk"), []byte(testLibConfigUk), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := Parse(filepath.Join(dir, "Config.uk"), WithEnv(map[string]string{
+		"UK_BASE":        dir,
+		"UK_FULLVERSION": "0.11.0",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tree
+}
+
+func TestParse(t *testing.T) {
+	tree := testTree(t)
+
+	if tree.MainMenu != "Unikraft/0.11.0 Configuration" {
+		t.Errorf("unexpected main menu: %s", tree.MainMenu)
+	}
+
+	if len(tree.Unresolved) != 1 || tree.Unresolved[0] != "$(KCONFIG_LIB_IN)" {
+		t.Errorf("unexpected unresolved sources: %v", tree.Unresolved)
+	}
+
+	x86, ok := tree.Symbol("CONFIG_ARCH_X86_64")
+	if !ok || x86.Type != TypeBool || x86.Choice == nil || x86.Choice.Prompt != "Architecture" {
+		t.Errorf("expected ARCH_X86_64 to be a bool member of the architecture choice")
+	}
+
+	netdev, _ := tree.Symbol("LIBUKNETDEV")
+	if help := netdev.Help(); help != "A network driver interface.\n\nDrivers register themselves here." {
+		t.Errorf("unexpected help: %q", help)
+	}
+
+	dispatch, _ := tree.Symbol("LIBUKNETDEV_DISPATCHERTHREADS")
+	if deps := dispatch.DependsOn().String(); deps != "LIBUKNETDEV && LIBUKSCHED && LIBUKLOCK" {
+		t.Errorf("unexpected dependencies: %s", deps)
+	}
+
+	if by := tree.SelectedBy("LIBUKALLOC"); len(by) != 1 || by[0] != netdev {
+		t.Errorf("expected LIBUKALLOC to be selected by LIBUKNETDEV")
+	}
+
+	for _, invalid := range []string{
+		"config",
+		"menu \"unterminated\n",
+		"if FOO\nconfig BAR\n\tbool\n",
+		"config FOO\n\tbool\n\tfrobnicate\n",
+		"endmenu\n",
+	} {
+		file := filepath.Join(t.TempDir(), "Config.uk")
+		if err := os.WriteFile(file, []byte(invalid), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Parse(file); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tree := testTree(t)
+
+	dc, err := ParseDotConfig(strings.NewReader("CONFIG_ARCH_X86_64=y\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, invalid := range [][2]string{
+		{"LIBUKNETDEVV", "y"},
+		{"LIBUKNETDEV", "yes"},
+		{"LIBUKNETDEV_MAXNBQUEUES", "512"},
+		{"LIBUKDEBUG_PRINTK_LEVEL", "xyz"},
+		{"LIBUKNETDEV_MAXNBQUEUES", "4"},
+	} {
+		if err := tree.Set(dc, invalid[0], invalid[1]); err == nil {
+			t.Errorf("%s=%s: expected error", invalid[0], invalid[1])
+		}
+	}
+
+	if err := tree.Set(dc, "LIBUKNETDEVV", "y"); err == nil || !strings.Contains(err.Error(), "did you mean CONFIG_LIBUKNETDEV?") {
+		t.Errorf("expected a suggestion, got: %v", err)
+	}
+
+	if err := tree.Set(dc, "CONFIG_LIBUKNETDEV", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := dc.Get("CONFIG_LIBUKALLOC"); v != "y" {
+		t.Errorf("expected LIBUKALLOC to be selected")
+	}
+
+	if err := tree.Set(dc, "LIBUKNETDEV_MAXNBQUEUES", "4"); err != nil {
+		t.Error(err)
+	}
+
+	// LIBUKLOCK defaults to y once LIBUKSCHED is enabled
+	if err := tree.Set(dc, "LIBUKNETDEV_DISPATCHERTHREADS", "y"); err == nil {
+		t.Errorf("expected unmet dependencies")
+	}
+
+	if err := tree.Set(dc, "LIBUKSCHED", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Set(dc, "LIBUKNETDEV_DISPATCHERTHREADS", "y"); err != nil {
+		t.Error(err)
+	}
+
+	if err := tree.Set(dc, "ARCH_ARM_64", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := dc.Get("CONFIG_ARCH_X86_64"); v != "n" {
+		t.Errorf("expected the other member of the choice to be disabled")
+	}
+
+	if err := tree.Set(dc, "UK_NAME", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.Set(dc, "LIBUKALLOC", "n"); err == nil {
+		t.Errorf("expected LIBUKALLOC to be selected")
+	}
+
+	if err := tree.Unset(dc, "LIBUKALLOC"); err == nil {
+		t.Errorf("expected LIBUKALLOC to be selected")
+	}
+
+	if err := tree.Unset(dc, "LIBUKSCHED"); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if _, err := dc.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# CONFIG_ARCH_X86_64 is not set
+CONFIG_LIBUKNETDEV=y
+CONFIG_LIBUKALLOC=y
+CONFIG_LIBUKNETDEV_MAXNBQUEUES=4
+CONFIG_LIBUKNETDEV_DISPATCHERTHREADS=y
+CONFIG_ARCH_ARM_64=y
+CONFIG_UK_NAME="y"
+`
+	if b.String() != expected {
+		t.Errorf("unexpected .config:\n%s", b.String())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tree := testTree(t)
+
+	dc, err := ParseDotConfig(strings.NewReader(`CONFIG_ARCH_X86_64=y
+# CONFIG_ARCH_ARM_64 is not set
+CONFIG_UK_NAME="other"
+CONFIG_LIBUKNETDEV=y
+CONFIG_LIBUKALLOC=y
+CONFIG_LIBUKDEBUG_PRINTK_LEVEL=0x3
+CONFIG_STALE=y
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs := Compare(tree, map[string]string{
+		"CONFIG_ARCH_X86_64":                   "y",
+		"CONFIG_UK_NAME":                       `"app"`,
+		"CONFIG_LIBUKNETDEV_DISPATCHERTHREADS": "y",
+		"CONFIG_LIBUKLOCK":                     "n",
+		"LIBUKSCHED":                           "y",
+	}, dc)
+
+	expected := []Diff{
+		{Kind: DiffExtra, Name: "CONFIG_LIBUKNETDEV", Actual: "y", Reason: "default is n"},
+		{Kind: DiffExtra, Name: "CONFIG_STALE", Actual: "y", Reason: "unknown option"},
+		{Kind: DiffMissing, Name: "CONFIG_LIBUKNETDEV_DISPATCHERTHREADS", Requested: "y", Reason: "depends on LIBUKNETDEV && LIBUKSCHED && LIBUKLOCK"},
+		{Kind: DiffMissing, Name: "LIBUKSCHED", Requested: "y", Reason: "missing CONFIG_ prefix"},
+		{Kind: DiffOverridden, Name: "CONFIG_UK_NAME", Requested: "app", Actual: "other"},
+	}
+
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %+v", len(expected), diffs)
+	}
+
+	for i := range expected {
+		if diffs[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], diffs[i])
+		}
+	}
+}
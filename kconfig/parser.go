@@ -0,0 +1,803 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package kconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Tree is the menu tree and the symbols defined by a `Config.uk` file and the
+// files it sources
+type Tree struct {
+	// Root is the top-level menu of the tree
+	Root *Node
+
+	// MainMenu is the title of the tree as set by `mainmenu`
+	MainMenu string
+
+	// Unresolved lists the sources which could not be included as they refer to
+	// macros which are not defined
+	Unresolved []string
+
+	env       map[string]string
+	baseDir   string
+	symbols   map[string]*Symbol
+	order     []*Symbol
+	selectors map[string][]selector
+	files     map[string]bool
+}
+
+// selector is a symbol which selects another symbol
+type selector struct {
+	symbol *Symbol
+	cond   Expr
+}
+
+// TreeOption is an option for parsing a tree of `Config.uk` files
+type TreeOption func(*Tree) error
+
+// WithEnv sets the macros which are expanded within the `Config.uk` files,
+// e.g. `UK_BASE`
+func WithEnv(env map[string]string) TreeOption {
+	return func(t *Tree) error {
+		for k, v := range env {
+			t.env[k] = v
+		}
+
+		return nil
+	}
+}
+
+// WithBaseDir sets the directory relative to which the paths of `source`
+// statements are resolved, which defaults to the directory of the first file
+func WithBaseDir(dir string) TreeOption {
+	return func(t *Tree) error {
+		t.baseDir = dir
+		return nil
+	}
+}
+
+// NewTree returns an empty tree to which `Config.uk` files can be added with
+// Include
+func NewTree(opts ...TreeOption) (*Tree, error) {
+	t := &Tree{
+		Root:      &Node{Kind: NodeMenu},
+		env:       map[string]string{},
+		symbols:   map[string]*Symbol{},
+		selectors: map[string][]selector{},
+		files:     map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// Parse parses the `Config.uk` file at the provided path and the files it
+// sources
+func Parse(file string, opts ...TreeOption) (*Tree, error) {
+	t, err := NewTree(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Include(file); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Include parses the `Config.uk` file at the provided path and adds its entries
+// to the top-level menu of the tree.  Files which have already been included
+// are skipped.
+func (t *Tree) Include(file string) error {
+	if len(t.baseDir) == 0 {
+		t.baseDir = filepath.Dir(file)
+	}
+
+	return t.include(file, []frame{{node: t.Root}})
+}
+
+// include parses the file within the provided enclosing blocks
+func (t *Tree) include(file string, stack []frame) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+
+	if t.files[abs] {
+		return nil
+	}
+
+	t.files[abs] = true
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %v", file, err)
+	}
+
+	p := &parser{
+		tree:  t,
+		file:  file,
+		lines: strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n"),
+		stack: append([]frame{}, stack...),
+		depth: len(stack),
+	}
+
+	return p.parse()
+}
+
+// symbol returns the named symbol, which is created if it is not yet known
+func (t *Tree) symbol(name string) *Symbol {
+	sym, ok := t.symbols[name]
+	if !ok {
+		sym = &Symbol{Name: name}
+		t.symbols[name] = sym
+		t.order = append(t.order, sym)
+	}
+
+	return sym
+}
+
+// expand replaces the macros of the form `$(NAME)` which are defined
+func (t *Tree) expand(s string) string {
+	return macroRe.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := t.env[m[2:len(m)-1]]; ok {
+			return v
+		}
+
+		return m
+	})
+}
+
+var (
+	macroRe      = regexp.MustCompile(`\$\([A-Za-z0-9_]+\)`)
+	assignmentRe = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*(:=|\+=|=)\s*(.*)$`)
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOp
+)
+
+// token is a word, a quoted string or an operator of a line
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a line into tokens, ignoring comments
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+
+		case c == '#':
+			return tokens, nil
+
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+
+				b.WriteByte(s[j])
+			}
+
+			if j == len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+
+			tokens = append(tokens, token{tokenString, b.String()})
+			i = j + 1
+
+		case strings.HasPrefix(s[i:], "&&"),
+			strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="),
+			strings.HasPrefix(s[i:], ">="):
+			tokens = append(tokens, token{tokenOp, s[i : i+2]})
+			i += 2
+
+		case strings.ContainsRune("!=()<>", rune(c)):
+			tokens = append(tokens, token{tokenOp, string(c)})
+			i++
+
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\r\"'#!=()<>&|", rune(s[j])) {
+				// Macros may contain otherwise reserved characters
+				if strings.HasPrefix(s[j:], "$(") {
+					depth := 0
+					for ; j < len(s); j++ {
+						if s[j] == '(' {
+							depth++
+						} else if s[j] == ')' {
+							depth--
+							if depth == 0 {
+								break
+							}
+						}
+					}
+				}
+
+				j++
+			}
+
+			if j == i {
+				return nil, fmt.Errorf("unexpected %c", c)
+			}
+
+			tokens = append(tokens, token{tokenWord, s[i:j]})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+// frame is a block which encloses entries, i.e. the top-level menu, a menu, a
+// choice or an `if`
+type frame struct {
+	node    *Node
+	cond    Expr
+	keyword string
+	line    int
+}
+
+// parser parses the lines of a single file
+type parser struct {
+	tree  *Tree
+	file  string
+	lines []string
+	pos   int
+	line  int
+	stack []frame
+	depth int
+	entry *Node
+}
+
+// errorf returns an error which refers to the current line
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.file, p.line, fmt.Sprintf(format, args...))
+}
+
+// next returns the next logical line, joining lines which end in a backslash
+func (p *parser) next() (string, bool) {
+	if p.pos >= len(p.lines) {
+		return "", false
+	}
+
+	p.line = p.pos + 1
+	line := p.lines[p.pos]
+	p.pos++
+
+	for strings.HasSuffix(line, "\\") && p.pos < len(p.lines) {
+		line = strings.TrimSuffix(line, "\\") + p.lines[p.pos]
+		p.pos++
+	}
+
+	return line, true
+}
+
+// parent returns the innermost menu or choice
+func (p *parser) parent() *Node {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].node != nil {
+			return p.stack[i].node
+		}
+	}
+
+	return p.tree.Root
+}
+
+// inherited returns the dependencies of the enclosing blocks
+func (p *parser) inherited() Expr {
+	var deps Expr
+
+	for _, f := range p.stack {
+		if f.node != nil {
+			deps = f.node.Dependencies
+		} else {
+			deps = and(deps, f.cond)
+		}
+	}
+
+	return deps
+}
+
+// add appends a new entry to the innermost menu or choice
+func (p *parser) add(kind NodeKind, sym *Symbol) *Node {
+	parent := p.parent()
+	node := &Node{
+		Kind:         kind,
+		Symbol:       sym,
+		Dependencies: p.inherited(),
+		Parent:       parent,
+		File:         p.file,
+		Line:         p.line,
+	}
+
+	parent.Children = append(parent.Children, node)
+
+	if sym != nil {
+		sym.Nodes = append(sym.Nodes, node)
+
+		if kind == NodeConfig && parent.Kind == NodeChoice {
+			sym.Choice = parent
+		}
+	}
+
+	p.entry = node
+
+	return node
+}
+
+// push opens a block
+func (p *parser) push(keyword string, node *Node, cond Expr) {
+	p.stack = append(p.stack, frame{
+		node:    node,
+		cond:    cond,
+		keyword: keyword,
+		line:    p.line,
+	})
+}
+
+// pop closes the innermost block, which must have been opened by the keyword
+func (p *parser) pop(keyword string) error {
+	if len(p.stack) <= p.depth || p.stack[len(p.stack)-1].keyword != keyword {
+		return p.errorf("unexpected end%s", keyword)
+	}
+
+	p.stack = p.stack[:len(p.stack)-1]
+	p.entry = nil
+
+	return nil
+}
+
+// parse parses all lines of the file
+func (p *parser) parse() error {
+	for {
+		line, ok := p.next()
+		if !ok {
+			break
+		}
+
+		// Macros can be defined alongside the entries
+		if m := assignmentRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			value := p.tree.expand(m[3])
+			if m[2] == "+=" {
+				value = strings.TrimSpace(p.tree.env[m[1]] + " " + value)
+			}
+
+			p.tree.env[m[1]] = value
+			continue
+		}
+
+		tokens, err := tokenize(line)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		for i := range tokens {
+			if tokens[i].kind != tokenOp {
+				tokens[i].text = p.tree.expand(tokens[i].text)
+			}
+		}
+
+		if err := p.statement(line, tokens); err != nil {
+			return err
+		}
+	}
+
+	if len(p.stack) > p.depth {
+		f := p.stack[len(p.stack)-1]
+		return fmt.Errorf("%s:%d: missing end%s", p.file, f.line, f.keyword)
+	}
+
+	return nil
+}
+
+// argument returns the single argument of a statement
+func (p *parser) argument(keyword string, tokens []token) (string, error) {
+	if len(tokens) != 1 || tokens[0].kind == tokenOp {
+		return "", p.errorf("%s expects a single argument", keyword)
+	}
+
+	return tokens[0].text, nil
+}
+
+// condition splits the tokens at a trailing `if` and parses its condition
+func (p *parser) condition(tokens []token) ([]token, Expr, error) {
+	for i, tok := range tokens {
+		if tok.kind == tokenWord && tok.text == "if" {
+			cond, err := parseExpr(tokens[i+1:])
+			if err != nil {
+				return nil, nil, p.errorf("%v", err)
+			}
+
+			return tokens[:i], cond, nil
+		}
+	}
+
+	return tokens, nil, nil
+}
+
+// statement parses a line which has been split into tokens
+func (p *parser) statement(line string, tokens []token) error {
+	keyword, args := tokens[0].text, tokens[1:]
+	if tokens[0].kind != tokenWord {
+		return p.errorf("unexpected %s", keyword)
+	}
+
+	switch keyword {
+	case "mainmenu":
+		prompt, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		p.tree.MainMenu = prompt
+
+	case "config", "menuconfig":
+		name, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		node := p.add(NodeConfig, p.tree.symbol(name))
+		node.MenuConfig = keyword == "menuconfig"
+
+	case "choice":
+		// Choices are rarely named, in which case their symbol is not registered
+		sym := &Symbol{Type: TypeBool}
+		if len(args) > 0 {
+			name, err := p.argument(keyword, args)
+			if err != nil {
+				return err
+			}
+
+			sym = p.tree.symbol(name)
+			sym.Type = TypeBool
+		}
+
+		node := p.add(NodeChoice, sym)
+		if len(sym.Name) == 0 {
+			sym.Nodes = []*Node{node}
+		}
+
+		p.push("choice", node, nil)
+
+	case "menu":
+		prompt, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		node := p.add(NodeMenu, nil)
+		node.Prompt = prompt
+		p.push("menu", node, nil)
+
+	case "comment":
+		prompt, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		node := p.add(NodeComment, nil)
+		node.Prompt = prompt
+
+	case "if":
+		cond, err := parseExpr(args)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+
+		p.push("if", nil, cond)
+		p.entry = nil
+
+	case "endchoice", "endmenu", "endif":
+		return p.pop(strings.TrimPrefix(keyword, "end"))
+
+	case "source", "osource", "rsource", "orsource":
+		path, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		return p.source(keyword, path)
+
+	default:
+		if p.entry == nil {
+			return p.errorf("unexpected %s outside of an entry", keyword)
+		}
+
+		return p.attribute(line, keyword, args)
+	}
+
+	return nil
+}
+
+// source includes the files matching the path
+func (p *parser) source(keyword, path string) error {
+	// Sources which refer to undefined macros are typically generated by
+	// Unikraft's build system and cannot be resolved
+	if strings.Contains(path, "$(") {
+		p.tree.Unresolved = append(p.tree.Unresolved, path)
+		return nil
+	}
+
+	if !filepath.IsAbs(path) {
+		if strings.HasPrefix(keyword, "r") || strings.HasPrefix(keyword, "or") {
+			path = filepath.Join(filepath.Dir(p.file), path)
+		} else {
+			path = filepath.Join(p.tree.baseDir, path)
+		}
+	}
+
+	files, err := filepath.Glob(path)
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+
+	if len(files) == 0 && !strings.HasPrefix(keyword, "o") && !strings.ContainsAny(path, "*?[") {
+		return p.errorf("could not find %s", path)
+	}
+
+	for _, file := range files {
+		if err := p.tree.include(file, p.stack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attribute parses a line which belongs to the current entry
+func (p *parser) attribute(line, keyword string, args []token) error {
+	node := p.entry
+	sym := node.Symbol
+
+	// Attributes of symbols are not applicable to menus and comments
+	if sym == nil {
+		switch keyword {
+		case "depends", "visible", "help", "---help---":
+		default:
+			return p.errorf("unexpected %s for a %s", keyword, map[NodeKind]string{
+				NodeMenu:    "menu",
+				NodeComment: "comment",
+			}[node.Kind])
+		}
+	}
+
+	switch keyword {
+	case "bool", "tristate", "string", "hex", "int", "def_bool", "def_tristate", "prompt":
+		switch keyword {
+		case "bool", "def_bool":
+			sym.Type = TypeBool
+		case "tristate", "def_tristate":
+			sym.Type = TypeTristate
+		case "string":
+			sym.Type = TypeString
+		case "hex":
+			sym.Type = TypeHex
+		case "int":
+			sym.Type = TypeInt
+		}
+
+		args, cond, err := p.condition(args)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(keyword, "def_") {
+			value, err := parseExpr(args)
+			if err != nil {
+				return p.errorf("%v", err)
+			}
+
+			sym.Defaults = append(sym.Defaults, Default{Value: value, If: cond})
+		} else if len(args) > 0 {
+			prompt, err := p.argument(keyword, args)
+			if err != nil {
+				return err
+			}
+
+			node.Prompt = prompt
+			node.PromptIf = cond
+		} else if keyword == "prompt" {
+			return p.errorf("prompt expects a single argument")
+		}
+
+	case "default":
+		args, cond, err := p.condition(args)
+		if err != nil {
+			return err
+		}
+
+		value, err := parseExpr(args)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+
+		sym.Defaults = append(sym.Defaults, Default{Value: value, If: cond})
+
+	case "depends", "visible":
+		want := map[string]string{"depends": "on", "visible": "if"}[keyword]
+		if len(args) == 0 || args[0].kind != tokenWord || args[0].text != want {
+			return p.errorf("expected %s %s", keyword, want)
+		}
+
+		cond, err := parseExpr(args[1:])
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+
+		if keyword == "visible" {
+			node.Visible = and(node.Visible, cond)
+		} else {
+			node.DependsOn = and(node.DependsOn, cond)
+			node.Dependencies = and(node.Dependencies, cond)
+		}
+
+	case "select", "imply":
+		args, cond, err := p.condition(args)
+		if err != nil {
+			return err
+		}
+
+		name, err := p.argument(keyword, args)
+		if err != nil {
+			return err
+		}
+
+		rev := Reverse{Symbol: name, If: cond}
+		if keyword == "imply" {
+			sym.Implies = append(sym.Implies, rev)
+		} else {
+			sym.Selects = append(sym.Selects, rev)
+			p.tree.selectors[name] = append(p.tree.selectors[name], selector{sym, cond})
+		}
+
+	case "range":
+		args, cond, err := p.condition(args)
+		if err != nil {
+			return err
+		}
+
+		if len(args) != 2 || args[0].kind == tokenOp || args[1].kind == tokenOp {
+			return p.errorf("range expects two arguments")
+		}
+
+		sym.Ranges = append(sym.Ranges, Range{
+			Min: exprSymbol{name: args[0].text, quoted: args[0].kind == tokenString},
+			Max: exprSymbol{name: args[1].text, quoted: args[1].kind == tokenString},
+			If:  cond,
+		})
+
+	case "help", "---help---":
+		node.Help = p.help(indentation(line))
+
+	case "optional":
+		node.Optional = true
+
+	case "option", "modules", "transitional":
+		// These attributes do not affect the configuration of Unikraft
+
+	default:
+		return p.errorf("unknown statement %s", keyword)
+	}
+
+	return nil
+}
+
+// help consumes the lines of a help text, which ends at the first line that is
+// indented less than its first line
+func (p *parser) help(min int) string {
+	var lines []string
+	indent := -1
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+
+		if len(strings.TrimSpace(line)) == 0 {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+
+		n := indentation(line)
+		if indent < 0 {
+			if n <= min {
+				break
+			}
+
+			indent = n
+		} else if n < indent {
+			break
+		}
+
+		lines = append(lines, strings.TrimRight(expandTabs(line)[indent:], " \t"))
+		p.pos++
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// indentation returns the width of the leading whitespace of the line, where
+// tabs are expanded to multiples of eight columns
+func indentation(line string) int {
+	expanded := expandTabs(line)
+	return len(expanded) - len(strings.TrimLeft(expanded, " "))
+}
+
+// expandTabs replaces the tabs of the leading whitespace of the line
+func expandTabs(line string) string {
+	var b strings.Builder
+
+	for i, c := range line {
+		switch c {
+		case ' ':
+			b.WriteByte(' ')
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 8-b.Len()%8))
+		default:
+			return b.String() + line[i:]
+		}
+	}
+
+	return b.String()
+}
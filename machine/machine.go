@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package machine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"syscall"
+
+	"kraftkit.sh/unikraft/volume"
+)
+
+// MachineConfig describes a unikernel instance which is to be booted by a
+// Driver.  It is independent of the hypervisor which is ultimately used.
+type MachineConfig struct {
+	// Name of the machine
+	Name string
+
+	// Architecture of the kernel image
+	Architecture string
+
+	// Platform the kernel image was built for
+	Platform string
+
+	// Kernel is the path to the kernel image
+	Kernel string
+
+	// Initrd is the (optional) path to the init ramdisk
+	Initrd string
+
+	// Arguments are passed to the kernel via its command-line
+	Arguments []string
+
+	// CPUs is the number of virtual CPUs to allocate to the machine
+	CPUs int
+
+	// Memory is the amount of memory to allocate to the machine, either in
+	// megabytes or with a unit suffix, e.g. "64M"
+	Memory string
+
+	// Volumes are attached to the machine as storage devices
+	Volumes []volume.VolumeConfig
+}
+
+// Driver is the interface which is implemented by each hypervisor which is
+// able to boot a MachineConfig
+type Driver interface {
+	// Start boots the machine and returns once the hypervisor has been started
+	Start(context.Context) error
+
+	// Wait blocks until the machine has exited
+	Wait() error
+
+	// Signal sends the provided signal to the running hypervisor
+	Signal(syscall.Signal) error
+
+	// String returns the name of the driver
+	String() string
+}
+
+// DriverConstructor instantiates a Driver from the provided options
+type DriverConstructor func(*DriverOptions) (Driver, error)
+
+var drivers = make(map[string]DriverConstructor)
+
+// RegisterDriver makes a Driver available by its name
+func RegisterDriver(name string, constructor DriverConstructor) error {
+	if _, ok := drivers[name]; ok {
+		return fmt.Errorf("machine driver already registered: %s", name)
+	}
+
+	drivers[name] = constructor
+
+	return nil
+}
+
+// Drivers returns the sorted list of names of the registered drivers
+func Drivers() []string {
+	var names []string
+	for name := range drivers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewDriver instantiates the registered Driver with the given name
+func NewDriver(name string, dopts ...DriverOption) (Driver, error) {
+	constructor, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown machine driver: %s", name)
+	}
+
+	opts, err := NewDriverOptions(dopts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return constructor(opts)
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package machine
+
+import (
+	"io"
+
+	"kraftkit.sh/log"
+)
+
+// DriverOptions contains the configuration which is passed to a Driver
+type DriverOptions struct {
+	Config MachineConfig
+	Log    log.Logger
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Args are additional hypervisor-specific arguments
+	Args []string
+}
+
+type DriverOption func(opts *DriverOptions) error
+
+// NewDriverOptions creates DriverOptions
+func NewDriverOptions(opts ...DriverOption) (*DriverOptions, error) {
+	options := &DriverOptions{}
+
+	for _, o := range opts {
+		if err := o(options); err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+// WithMachineConfig sets the description of the machine which is to be booted
+func WithMachineConfig(config MachineConfig) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Config = config
+		return nil
+	}
+}
+
+// WithLogger defines the log.Logger
+func WithLogger(l log.Logger) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Log = l
+		return nil
+	}
+}
+
+// WithStdin sets the input of the machine's console
+func WithStdin(stdin io.Reader) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Stdin = stdin
+		return nil
+	}
+}
+
+// WithStdout sets the output of the machine's console
+func WithStdout(stdout io.Writer) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Stdout = stdout
+		return nil
+	}
+}
+
+// WithStderr sets the output for diagnostics of the hypervisor
+func WithStderr(stderr io.Writer) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Stderr = stderr
+		return nil
+	}
+}
+
+// WithArgs appends additional hypervisor-specific arguments
+func WithArgs(args ...string) DriverOption {
+	return func(opts *DriverOptions) error {
+		opts.Args = append(opts.Args, args...)
+		return nil
+	}
+}
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"kraftkit.sh/exec"
+	"kraftkit.sh/machine"
+	"kraftkit.sh/unikraft/volume"
+)
+
+const (
+	// DriverName is the name under which the QEMU driver is registered
+	DriverName = "qemu"
+
+	// kvmDevice is the path to the device which is used to determine whether
+	// hardware acceleration is available on the host
+	kvmDevice = "/dev/kvm"
+)
+
+// QemuConfig represents the command-line arguments of QEMU which are used to
+// boot a Unikraft unikernel
+type QemuConfig struct {
+	NoDefaults bool     `flag:"-nodefaults"`
+	NoReboot   bool     `flag:"-no-reboot"`
+	Display    string   `flag:"-display"`
+	Serial     string   `flag:"-serial"`
+	EnableKVM  bool     `flag:"-enable-kvm"`
+	Machine    string   `flag:"-machine"`
+	CPU        string   `flag:"-cpu"`
+	SMP        string   `flag:"-smp"`
+	Memory     string   `flag:"-m"`
+	Kernel     string   `flag:"-kernel"`
+	Initrd     string   `flag:"-initrd"`
+	Append     string   `flag:"-append"`
+	FsDev      []string `flag:"-fsdev"`
+	Drive      []string `flag:"-drive"`
+	Device     []string `flag:"-device"`
+}
+
+// Qemu boots unikernels built for the KVM platform using QEMU
+type Qemu struct {
+	opts    *machine.DriverOptions
+	process *exec.Process
+}
+
+func init() {
+	if err := machine.RegisterDriver(DriverName, NewQemuDriver); err != nil {
+		panic(err)
+	}
+}
+
+// NewQemuDriver instantiates the QEMU machine driver
+func NewQemuDriver(opts *machine.DriverOptions) (machine.Driver, error) {
+	switch opts.Config.Platform {
+	case "kvm", "qemu":
+	default:
+		return nil, fmt.Errorf("platform is not supported by the qemu driver: %s", opts.Config.Platform)
+	}
+
+	if len(opts.Config.Kernel) == 0 {
+		return nil, fmt.Errorf("cannot boot machine without kernel")
+	}
+
+	for _, vol := range opts.Config.Volumes {
+		if err := vol.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Qemu{opts: opts}, nil
+}
+
+// Binary returns the name of the QEMU system emulator for the architecture
+func Binary(arch string) (string, error) {
+	switch arch {
+	case "x86_64":
+		return "qemu-system-x86_64", nil
+	case "arm64":
+		return "qemu-system-aarch64", nil
+	case "arm":
+		return "qemu-system-arm", nil
+	}
+
+	return "", fmt.Errorf("architecture is not supported by the qemu driver: %s", arch)
+}
+
+// kvmAvailable determines whether the host is able to accelerate the machine
+func kvmAvailable() bool {
+	f, err := os.OpenFile(kvmDevice, os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+
+	return true
+}
+
+// QemuConfig returns the configuration of QEMU for the machine
+func (q *Qemu) QemuConfig() QemuConfig {
+	mcfg := q.opts.Config
+
+	qcfg := QemuConfig{
+		NoDefaults: true,
+		NoReboot:   true,
+		Display:    "none",
+		Serial:     "stdio",
+		Memory:     mcfg.Memory,
+		Kernel:     mcfg.Kernel,
+		Initrd:     mcfg.Initrd,
+		Append:     strings.Join(mcfg.Arguments, " "),
+	}
+
+	for _, vol := range mcfg.Volumes {
+		switch vol.Type {
+		case volume.VolumeType9pfs:
+			qcfg.FsDev = append(qcfg.FsDev, fmt.Sprintf(
+				"local,id=%s,path=%s,security_model=passthrough",
+				vol.Name, vol.Source,
+			))
+			qcfg.Device = append(qcfg.Device, fmt.Sprintf(
+				"virtio-9p-pci,fsdev=%s,mount_tag=%s",
+				vol.Name, vol.Name,
+			))
+		case volume.VolumeTypeRaw:
+			qcfg.Drive = append(qcfg.Drive, fmt.Sprintf(
+				"file=%s,if=virtio,format=raw,id=%s",
+				vol.Source, vol.Name,
+			))
+		}
+	}
+
+	if mcfg.CPUs > 0 {
+		qcfg.SMP = strconv.Itoa(mcfg.CPUs)
+	}
+
+	switch mcfg.Architecture {
+	case "arm64":
+		qcfg.Machine = "virt"
+		qcfg.CPU = "cortex-a57"
+	case "arm":
+		qcfg.Machine = "virt"
+		qcfg.CPU = "cortex-a15"
+	}
+
+	if mcfg.Platform == "kvm" {
+		if kvmAvailable() {
+			qcfg.EnableKVM = true
+			qcfg.CPU = "host"
+		} else if q.opts.Log != nil {
+			q.opts.Log.Warnf("%s is not available, falling back to emulation", kvmDevice)
+		}
+	}
+
+	return qcfg
+}
+
+// Start boots the machine with QEMU
+func (q *Qemu) Start(ctx context.Context) error {
+	bin, err := Binary(q.opts.Config.Architecture)
+	if err != nil {
+		return err
+	}
+
+	executable, err := exec.NewExecutable(bin, q.QemuConfig(), q.opts.Args...)
+	if err != nil {
+		return fmt.Errorf("could not prepare qemu: %v", err)
+	}
+
+	eopts := []exec.ExecOption{
+		exec.WithContext(ctx),
+		exec.WithStdin(q.opts.Stdin),
+		exec.WithStdout(q.opts.Stdout),
+		exec.WithStderr(q.opts.Stderr),
+	}
+
+	if q.opts.Log != nil {
+		eopts = append(eopts, exec.WithLogger(q.opts.Log))
+	}
+
+	q.process, err = exec.NewProcessFromExecutable(executable, eopts...)
+	if err != nil {
+		return err
+	}
+
+	if err := q.process.Start(); err != nil {
+		return fmt.Errorf("could not start qemu: %v", err)
+	}
+
+	return nil
+}
+
+// Wait blocks until QEMU has exited
+func (q *Qemu) Wait() error {
+	if q.process == nil {
+		return fmt.Errorf("machine has not been started")
+	}
+
+	return q.process.Wait()
+}
+
+// Signal forwards the signal to the QEMU process
+func (q *Qemu) Signal(signal syscall.Signal) error {
+	if q.process == nil {
+		return fmt.Errorf("machine has not been started")
+	}
+
+	return q.process.Signal(signal)
+}
+
+// String returns the name of the driver
+func (q *Qemu) String() string {
+	return DriverName
+}
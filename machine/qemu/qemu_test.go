@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package qemu
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kraftkit.sh/machine"
+	"kraftkit.sh/unikraft/volume"
+)
+
+// fakeQemu installs an executable in place of the QEMU system emulator which
+// prints the arguments it was called with
+func fakeQemu(t *testing.T, bin string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, bin), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestQemuCmdline(t *testing.T) {
+	fakeQemu(t, "qemu-system-x86_64")
+
+	var stdout bytes.Buffer
+	driver, err := machine.NewDriver(DriverName,
+		machine.WithMachineConfig(machine.MachineConfig{
+			Name:         "helloworld",
+			Architecture: "x86_64",
+			Platform:     "qemu",
+			Kernel:       "/path/to/kernel",
+			Initrd:       "/path/to/initrd",
+			Arguments:    []string{"--", "hello", "world"},
+			CPUs:         2,
+			Memory:       "64M",
+		}),
+		machine.WithStdout(&stdout),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := driver.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := driver.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdline := strings.TrimSpace(stdout.String())
+	for _, want := range []string{
+		"-nodefaults",
+		"-serial stdio",
+		"-smp 2",
+		"-m 64M",
+		"-kernel /path/to/kernel",
+		"-initrd /path/to/initrd",
+		"-append -- hello world",
+	} {
+		if !strings.Contains(cmdline, want) {
+			t.Errorf("expected %q in command line: %s", want, cmdline)
+		}
+	}
+
+	if strings.Contains(cmdline, "-enable-kvm") {
+		t.Errorf("did not expect acceleration for the qemu platform: %s", cmdline)
+	}
+}
+
+func TestQemuVolumes(t *testing.T) {
+	fakeQemu(t, "qemu-system-x86_64")
+
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "disk.img")
+	if err := ioutil.WriteFile(disk, make([]byte, 512), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	driver, err := machine.NewDriver(DriverName,
+		machine.WithMachineConfig(machine.MachineConfig{
+			Architecture: "x86_64",
+			Platform:     "qemu",
+			Kernel:       "/path/to/kernel",
+			Volumes: []volume.VolumeConfig{
+				{Name: "fs0", Type: volume.VolumeType9pfs, Source: dir},
+				{Name: "disk0", Type: volume.VolumeTypeRaw, Source: disk},
+			},
+		}),
+		machine.WithStdout(&stdout),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := driver.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := driver.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdline := strings.TrimSpace(stdout.String())
+	for _, want := range []string{
+		"-fsdev local,id=fs0,path=" + dir + ",security_model=passthrough",
+		"-device virtio-9p-pci,fsdev=fs0,mount_tag=fs0",
+		"-drive file=" + disk + ",if=virtio,format=raw,id=disk0",
+	} {
+		if !strings.Contains(cmdline, want) {
+			t.Errorf("expected %q in command line: %s", want, cmdline)
+		}
+	}
+
+	if _, err := machine.NewDriver(DriverName,
+		machine.WithMachineConfig(machine.MachineConfig{
+			Architecture: "x86_64",
+			Platform:     "qemu",
+			Kernel:       "/path/to/kernel",
+			Volumes: []volume.VolumeConfig{
+				{Name: "fs0", Type: volume.VolumeType9pfs, Source: disk},
+			},
+		}),
+	); err == nil {
+		t.Errorf("expected error for 9pfs volume backed by a file")
+	}
+}
+
+func TestQemuUnsupported(t *testing.T) {
+	if _, err := machine.NewDriver(DriverName,
+		machine.WithMachineConfig(machine.MachineConfig{
+			Architecture: "x86_64",
+			Platform:     "xen",
+			Kernel:       "/path/to/kernel",
+		}),
+	); err == nil {
+		t.Errorf("expected error for unsupported platform")
+	}
+
+	if _, err := Binary("riscv64"); err == nil {
+		t.Errorf("expected error for unsupported architecture")
+	}
+}
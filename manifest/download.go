@@ -0,0 +1,346 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+)
+
+var (
+	// downloadAttempts is the number of times a download is attempted from a
+	// single location before moving onto the next mirror
+	downloadAttempts = 3
+
+	// downloadBackoff is the initial duration waited between two attempts which
+	// is doubled after every failed attempt
+	downloadBackoff = 2 * time.Second
+)
+
+// permanentError represents a failed download which will not succeed when
+// retried from the same location, e.g. because the resource does not exist
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// mirrorLocations returns the location of the resource on each of the mirrors
+// of the manifest.  Mirrors follow the structure of the sources directory, such
+// that the resource is found under its type and cached file name.
+func mirrorLocations(manifest *Manifest, resource, cache string) []string {
+	name := filepath.Base(cache)
+	if len(cache) == 0 {
+		u, err := url.Parse(resource)
+		if err != nil {
+			return nil
+		}
+
+		name = path.Base(u.Path)
+	}
+
+	if manifest.Type != unikraft.ComponentTypeCore {
+		name = manifest.Type.Plural() + "/" + name
+	}
+
+	var locations []string
+	for _, mirror := range manifest.mirrors {
+		if len(mirror) == 0 {
+			continue
+		}
+
+		locations = append(locations, strings.TrimSuffix(mirror, "/")+"/"+name)
+	}
+
+	return locations
+}
+
+// copyLocalResource copies a resource residing on the host to the destination
+func copyLocalResource(source, dest string, pp *pullProgressArchive) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("could not open resource: %v", err)
+	}
+
+	defer src.Close()
+
+	if fi, err := src.Stat(); err == nil {
+		pp.total = int(fi.Size())
+	}
+
+	f, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not create cache file: %v", err)
+	}
+
+	defer f.Close()
+
+	// With io.TeeReader we are able to pass in the implementing io.Writer such
+	// that we are able to call the onProgress method
+	_, err = io.Copy(f, io.TeeReader(src, pp))
+	return err
+}
+
+// downloadResource retrieves the resource from the first of the provided
+// locations which succeeds.  Each location is attempted multiple times with an
+// exponential backoff and any partially downloaded content at the destination
+// is resumed.
+func downloadResource(popts *pack.PullPackageOptions, auths map[string]config.AuthConfig, locations []string, dest string, pp *pullProgressArchive) error {
+	var err error
+
+	for i, location := range locations {
+		if i > 0 {
+			popts.Log().Infof("trying mirror %s", location)
+		}
+
+		backoff := downloadBackoff
+
+		for attempt := 1; attempt <= downloadAttempts; attempt++ {
+			if err = fetchResource(popts.HTTPClient(), auths, location, dest, pp); err == nil {
+				return nil
+			}
+
+			var perr *permanentError
+			if errors.As(err, &perr) || attempt == downloadAttempts {
+				break
+			}
+
+			popts.Log().Warnf("could not download %s: %v: retrying in %s", location, err, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		popts.Log().Warnf("could not download %s: %v", location, err)
+	}
+
+	return fmt.Errorf("could not download package: %v", err)
+}
+
+// fetchResource performs a single attempt at downloading the resource at the
+// location into the destination.  When the destination already contains
+// content, only the remainder is requested via an HTTP Range request.
+func fetchResource(client *http.Client, auths map[string]config.AuthConfig, location, dest string, pp *pullProgressArchive) error {
+	f, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return &permanentError{fmt.Errorf("could not create cache file: %v", err)}
+	}
+
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	req, err := newRequest(auths, location)
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := clientFor(client, auths, req.URL.Host).Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusPartialContent:
+		start, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			// The server returned a different range than the one requested, start
+			// over on the next attempt
+			f.Truncate(0)
+			return fmt.Errorf("unexpected range in response: %s", res.Header.Get("Content-Range"))
+		}
+
+		pp.total = int(total)
+
+	case res.StatusCode == http.StatusOK:
+		// The server does not support range requests or nothing was downloaded
+		// yet, so start from the beginning
+		if err := f.Truncate(0); err != nil {
+			return &permanentError{err}
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return &permanentError{err}
+		}
+
+		offset = 0
+		pp.total = int(res.ContentLength)
+
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial is already complete; its checksum is verified afterwards
+		return nil
+
+	case res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("received %d error", res.StatusCode)
+
+	default:
+		return &permanentError{fmt.Errorf("received %d error", res.StatusCode)}
+	}
+
+	if pp.total <= 0 {
+		pp.total = 0
+	}
+
+	pp.downloaded = int(offset)
+
+	// With io.TeeReader we are able to pass in the implementing io.Writer such
+	// that we are able to call the onProgress method
+	_, err = io.Copy(f, io.TeeReader(res.Body, pp))
+	return err
+}
+
+// parseContentRange parses the value of a Content-Range header in the form of
+// "bytes START-END/TOTAL" and returns the start and total size, where the
+// total is -1 when unknown.
+func parseContentRange(value string) (int64, int64, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "bytes ") {
+		return 0, 0, fmt.Errorf("unsupported content range: %s", value)
+	}
+
+	span, size, ok := strings.Cut(strings.TrimPrefix(value, "bytes "), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed content range: %s", value)
+	}
+
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed content range: %s", value)
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed content range: %s", value)
+	}
+
+	total := int64(-1)
+	if size != "*" {
+		total, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed content range: %s", value)
+		}
+	}
+
+	return start, total, nil
+}
+
+// newRequest creates a GET request for the location which carries the
+// credentials configured for its host
+func newRequest(auths map[string]config.AuthConfig, location string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth, ok := auths[req.URL.Host]; ok {
+		if len(auth.User) > 0 {
+			req.SetBasicAuth(auth.User, auth.Token)
+		} else if len(auth.Token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		}
+	}
+
+	return req, nil
+}
+
+// clientFor returns the client which is used for requests to the host, which
+// does not verify the host's certificate if disabled by its credentials
+func clientFor(client *http.Client, auths map[string]config.AuthConfig, host string) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	auth, ok := auths[host]
+	if !ok || auth.VerifySSL {
+		return client
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport = transport.Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	insecure := *client
+	insecure.Transport = transport
+
+	return &insecure
+}
+
+// getDocument retrieves a remote manifest, manifest index or signature using
+// the client and credentials set by the provided ManifestOptions
+func getDocument(location string, mopts []ManifestOption) (*http.Response, error) {
+	probe := probeOptions(mopts)
+
+	req, err := newRequest(probe.auths, location)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := clientFor(probe.httpClient, probe.auths, req.URL.Host).Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("received %d error when retreiving: %s", resp.StatusCode, location)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+)
+
+func TestDownloadResourceResumeAndMirrors(t *testing.T) {
+	downloadBackoff = time.Millisecond
+
+	content := bytes.Repeat([]byte("unikraft"), 4096)
+
+	var ranges []string
+	failures := 1
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/origin/libfoo.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/mirror/libs/libfoo-0.1.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "libfoo-0.1.0.tar.gz", time.Time{}, bytes.NewReader(content))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manifest := &Manifest{
+		Name:    "libfoo",
+		Type:    unikraft.ComponentTypeLib,
+		mirrors: []string{server.URL + "/mirror/"},
+	}
+
+	cache := filepath.Join(t.TempDir(), "libs", "libfoo-0.1.0.tar.gz")
+	resource := server.URL + "/origin/libfoo.tar.gz"
+
+	locations := append([]string{resource}, mirrorLocations(manifest, resource, cache)...)
+	if len(locations) != 2 || locations[1] != server.URL+"/mirror/libs/libfoo-0.1.0.tar.gz" {
+		t.Fatalf("unexpected locations: %v", locations)
+	}
+
+	// Simulate a previously interrupted download
+	part := filepath.Join(t.TempDir(), "libfoo.tar.gz.part")
+	if err := ioutil.WriteFile(part, content[:1000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	popts, err := pack.NewPullPackageOptions(
+		pack.WithPullLogger(logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := downloadResource(popts, nil, locations, part, &pullProgressArchive{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=1000-" {
+		t.Errorf("expected download to be resumed, got ranges: %v", ranges)
+	}
+
+	downloaded, err := ioutil.ReadFile(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("downloaded content does not match: got %d bytes, expected %d", len(downloaded), len(content))
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, total, err := parseContentRange("bytes 1000-32767/32768")
+	if err != nil || start != 1000 || total != 32768 {
+		t.Errorf("unexpected result: %d %d %v", start, total, err)
+	}
+
+	start, total, err = parseContentRange("bytes 5-9/*")
+	if err != nil || start != 5 || total != -1 {
+		t.Errorf("unexpected result: %d %d %v", start, total, err)
+	}
+
+	if _, _, err := parseContentRange("items 1-2/3"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected unsupported content range, got: %v", err)
+	}
+}
@@ -36,39 +36,39 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/go-git/go-git/v5"
-	gitconfig "github.com/go-git/go-git/v5/config"
 	gitplumbing "github.com/go-git/go-git/v5/plumbing"
 	"kraftkit.sh/unikraft"
 )
 
 type GitProvider struct {
-	repo   string
-	remote *git.Remote
-	refs   []*gitplumbing.Reference
-	mopts  []ManifestOption
+	repo  string
+	refs  []*gitplumbing.Reference
+	mopts []ManifestOption
 }
 
 // NewGitProvider attempts to parse a provided path as a Git repository
 func NewGitProvider(path string, mopts ...ManifestOption) (Provider, error) {
-	// Check if the remote URL is a Git repository
-	remote := git.NewRemote(nil, &gitconfig.RemoteConfig{
-		Name: "origin",
-		URLs: []string{path},
-	})
+	// Cheap hack to get authentication details and the Git protocol
+	manifest := &Manifest{GitRepo: path}
+	for _, o := range mopts {
+		if err := o(manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	repo := manifest.gitRepo()
 
 	// If this is a valid Git repository then let's generate a Manifest based on
 	// what we can read from the remote
-	refs, err := remote.List(&git.ListOptions{})
+	refs, err := listGitRefs(repo, manifest.Auths())
 	if err != nil {
 		return nil, fmt.Errorf("could not access access path as Git repository: %s", path)
 	}
 
 	return GitProvider{
-		repo:   path,
-		remote: remote,
-		refs:   refs,
-		mopts:  mopts,
+		repo:  path,
+		refs:  refs,
+		mopts: mopts,
 	}, nil
 }
 
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"kraftkit.sh/config"
+)
+
+const (
+	// GitProtocolHTTPS retrieves Git repositories over HTTPS
+	GitProtocolHTTPS = "https"
+
+	// GitProtocolSSH retrieves Git repositories over SSH
+	GitProtocolSSH = "ssh"
+
+	// defaultGitUser is the user used for SSH and for token-based HTTPS
+	// authentication when none is configured.  Git hosting services ignore the
+	// user when a token is provided.
+	defaultGitUser = "git"
+)
+
+// gitRemote is the parsed representation of the location of a Git repository
+type gitRemote struct {
+	scheme string
+	user   string
+	host   string
+	path   string
+}
+
+// parseGitRemote parses a Git repository location which is either a URL or an
+// scp-like SSH location of the form "user@host:path".  Local paths are
+// returned with an empty scheme and host.
+func parseGitRemote(repo string) gitRemote {
+	if u, err := url.Parse(repo); err == nil && len(u.Scheme) > 1 && len(u.Host) > 0 {
+		return gitRemote{
+			scheme: u.Scheme,
+			user:   u.User.Username(),
+			host:   u.Host,
+			path:   strings.TrimPrefix(u.Path, "/"),
+		}
+	}
+
+	// scp-like syntax, which is only recognised when no slash precedes the colon
+	// and the colon does not denote a Windows drive letter
+	colon := strings.Index(repo, ":")
+	if colon > 1 && !strings.Contains(repo[:colon], "/") {
+		remote := gitRemote{
+			scheme: GitProtocolSSH,
+			host:   repo[:colon],
+			path:   strings.TrimPrefix(repo[colon+1:], "/"),
+		}
+
+		if at := strings.LastIndex(remote.host, "@"); at >= 0 {
+			remote.user = remote.host[:at]
+			remote.host = remote.host[at+1:]
+		}
+
+		return remote
+	}
+
+	return gitRemote{path: repo}
+}
+
+// isSSH returns whether the remote is accessed via SSH
+func (gr gitRemote) isSSH() bool {
+	return gr.scheme == GitProtocolSSH || gr.scheme == "git+ssh"
+}
+
+// hostname returns the host of the remote without any port
+func (gr gitRemote) hostname() string {
+	if i := strings.LastIndex(gr.host, ":"); i >= 0 {
+		return gr.host[:i]
+	}
+
+	return gr.host
+}
+
+// gitRepoWithProtocol rewrites the location of a remote Git repository to be
+// accessed via the provided protocol, either "https" or "ssh".  Local
+// repositories and unknown protocols are left untouched.
+func gitRepoWithProtocol(repo, protocol string) string {
+	remote := parseGitRemote(repo)
+	if len(remote.host) == 0 {
+		return repo
+	}
+
+	switch {
+	case protocol == GitProtocolSSH && (remote.scheme == "https" || remote.scheme == "http"):
+		return fmt.Sprintf("%s@%s:%s", defaultGitUser, remote.hostname(), remote.path)
+
+	case protocol == GitProtocolHTTPS && remote.isSSH():
+		return fmt.Sprintf("https://%s/%s", remote.hostname(), remote.path)
+	}
+
+	return repo
+}
+
+// gitAuthConfig returns the authentication configured for the host of the
+// provided Git repository, looked up by the host including and excluding the
+// port.
+func gitAuthConfig(repo string, auths map[string]config.AuthConfig) (config.AuthConfig, bool) {
+	remote := parseGitRemote(repo)
+	if len(remote.host) == 0 {
+		return config.AuthConfig{}, false
+	}
+
+	if auth, ok := auths[remote.host]; ok {
+		return auth, true
+	}
+
+	auth, ok := auths[remote.hostname()]
+	return auth, ok
+}
+
+// gitUser returns the user used to authenticate against the provided remote
+func gitUser(remote gitRemote, auth config.AuthConfig) string {
+	if len(remote.user) > 0 {
+		return remote.user
+	} else if len(auth.User) > 0 {
+		return auth.User
+	}
+
+	return defaultGitUser
+}
+
+// gitAuthMethod returns the go-git authentication method for the provided
+// Git repository.  Repositories accessed via SSH authenticate with the keys
+// held by the SSH agent, whereas HTTPS repositories use the token configured
+// for their host, if any.
+func gitAuthMethod(repo string, auths map[string]config.AuthConfig) (transport.AuthMethod, error) {
+	remote := parseGitRemote(repo)
+	auth, ok := gitAuthConfig(repo, auths)
+
+	if remote.isSSH() {
+		method, err := gitssh.NewSSHAgentAuth(gitUser(remote, auth))
+		if err != nil {
+			return nil, fmt.Errorf("could not use SSH agent: %v", err)
+		}
+
+		return method, nil
+	}
+
+	if ok && len(auth.Token) > 0 {
+		return &githttp.BasicAuth{
+			Username: gitUser(remote, auth),
+			Password: auth.Token,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// gitRepo returns the location of the manifest's Git repository using the
+// configured Git protocol
+func (m Manifest) gitRepo() string {
+	return gitRepoWithProtocol(m.GitRepo, m.gitProtocol)
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"kraftkit.sh/config"
+)
+
+func TestGitRepoWithProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		repo     string
+		protocol string
+		expected string
+	}{
+		{"https://github.com/unikraft/unikraft.git", GitProtocolSSH, "git@github.com:unikraft/unikraft.git"},
+		{"https://github.com/unikraft/unikraft.git", GitProtocolHTTPS, "https://github.com/unikraft/unikraft.git"},
+		{"git@github.com:unikraft/unikraft.git", GitProtocolHTTPS, "https://github.com/unikraft/unikraft.git"},
+		{"ssh://git@git.example.com:2222/forks/unikraft.git", GitProtocolHTTPS, "https://git.example.com/forks/unikraft.git"},
+		{"git@github.com:unikraft/unikraft.git", GitProtocolSSH, "git@github.com:unikraft/unikraft.git"},
+		{"/home/user/unikraft", GitProtocolSSH, "/home/user/unikraft"},
+		{"https://github.com/unikraft/unikraft.git", "", "https://github.com/unikraft/unikraft.git"},
+	} {
+		if actual := gitRepoWithProtocol(tc.repo, tc.protocol); actual != tc.expected {
+			t.Errorf("%s via %q: expected %s, got %s", tc.repo, tc.protocol, tc.expected, actual)
+		}
+	}
+}
+
+func TestGitAuthMethod(t *testing.T) {
+	auths := map[string]config.AuthConfig{
+		"git.example.com": {Token: "secret", VerifySSL: true},
+	}
+
+	method, err := gitAuthMethod("https://git.example.com/forks/unikraft.git", auths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	basic, ok := method.(*githttp.BasicAuth)
+	if !ok {
+		t.Fatalf("expected basic authentication, got %T", method)
+	}
+
+	if basic.Username != defaultGitUser || basic.Password != "secret" {
+		t.Errorf("unexpected credentials: %s:%s", basic.Username, basic.Password)
+	}
+
+	method, err = gitAuthMethod("https://github.com/unikraft/unikraft.git", auths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != nil {
+		t.Errorf("expected no authentication for unconfigured host, got %T", method)
+	}
+}
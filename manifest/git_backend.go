@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"strings"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+)
+
+// gitRepository is a local Git repository which tracks a remote repository as
+// its "origin".  It is implemented by go-git and, when built with the
+// `libgit2` build tag, by libgit2.
+type gitRepository interface {
+	// Fetch retrieves the reference described by the checkout from the remote.
+	// The fetch is shallow where the checkout allows it.
+	Fetch(checkout *gitCheckout) error
+
+	// Checkout checks out the commit described by the checkout, which must have
+	// been fetched before, and returns the SHA of the checked out commit.
+	// Local changes to an existing checkout are not overwritten.
+	Checkout(checkout *gitCheckout) (string, error)
+
+	// Close releases any resources held by the repository
+	Close() error
+}
+
+// gitOptions holds the options used when accessing a remote Git repository
+type gitOptions struct {
+	// auths holds the authentication used for the host of the remote
+	auths map[string]config.AuthConfig
+
+	// onProgress is called with the progress of fetching, between 0 and 1
+	onProgress func(progress float64)
+
+	// log is used to report the operations performed on the repository
+	log log.Logger
+}
+
+// gitFetchTarget returns the local reference which a fetched remote reference
+// is stored as, i.e. branches are stored as remote-tracking branches of the
+// origin and tags are stored as-is.
+func gitFetchTarget(ref string) string {
+	if strings.HasPrefix(ref, "refs/heads/") {
+		return "refs/remotes/origin/" + strings.TrimPrefix(ref, "refs/heads/")
+	}
+
+	return ref
+}
+
+// gitRefSpecs returns the refspecs which are fetched for the checkout
+func gitRefSpecs(checkout *gitCheckout) []string {
+	if len(checkout.Ref) == 0 {
+		return []string{
+			"+refs/heads/*:refs/remotes/origin/*",
+			"+refs/tags/*:refs/tags/*",
+		}
+	}
+
+	return []string{"+" + checkout.Ref + ":" + gitFetchTarget(checkout.Ref)}
+}
@@ -0,0 +1,235 @@
+//go:build !libgit2
+// +build !libgit2
+
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	gitplumbing "github.com/go-git/go-git/v5/plumbing"
+)
+
+// goGitRepository is a gitRepository backed by go-git, which requires neither
+// cgo nor a system installation of Git.
+type goGitRepository struct {
+	repo   *git.Repository
+	local  string
+	remote string
+	opts   *gitOptions
+}
+
+// openGitRepository opens the Git repository at the local path, initializing
+// it when it does not exist, and points its origin at the remote repository.
+func openGitRepository(local, remote string, opts *gitOptions) (gitRepository, error) {
+	repo, err := git.PlainOpen(local)
+	if err != nil {
+		if err := os.MkdirAll(local, 0o755); err != nil {
+			return nil, fmt.Errorf("could not create directory: %v", err)
+		}
+
+		repo, err = git.PlainInit(local, false)
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize repository: %v", err)
+		}
+	} else {
+		opts.log.Infof("updating existing checkout in %s", local)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("could not read repository configuration: %v", err)
+	}
+
+	origin, ok := cfg.Remotes["origin"]
+	if !ok {
+		if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{
+			Name: "origin",
+			URLs: []string{remote},
+		}); err != nil {
+			return nil, fmt.Errorf("could not add remote: %v", err)
+		}
+	} else if len(origin.URLs) != 1 || origin.URLs[0] != remote {
+		origin.URLs = []string{remote}
+
+		if err := repo.SetConfig(cfg); err != nil {
+			return nil, fmt.Errorf("could not update remote: %v", err)
+		}
+	}
+
+	return &goGitRepository{
+		repo:   repo,
+		local:  local,
+		remote: remote,
+		opts:   opts,
+	}, nil
+}
+
+// Fetch implements gitRepository
+func (r *goGitRepository) Fetch(checkout *gitCheckout) error {
+	var refspecs []gitconfig.RefSpec
+	for _, refspec := range gitRefSpecs(checkout) {
+		refspecs = append(refspecs, gitconfig.RefSpec(refspec))
+	}
+
+	fopts := &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   refspecs,
+		Tags:       git.NoTags,
+		Force:      true,
+	}
+
+	if checkout.Shallow {
+		fopts.Depth = 1
+	}
+
+	if r.opts.onProgress != nil {
+		fopts.Progress = &gitProgress{onProgress: r.opts.onProgress}
+	}
+
+	if auth, ok := gitAuthConfig(r.remote, r.opts.auths); ok {
+		fopts.InsecureSkipTLS = !auth.VerifySSL
+	}
+
+	var err error
+	fopts.Auth, err = gitAuthMethod(r.remote, r.opts.auths)
+	if err != nil {
+		return err
+	}
+
+	if err := r.repo.Fetch(fopts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	return nil
+}
+
+// Checkout implements gitRepository
+func (r *goGitRepository) Checkout(checkout *gitCheckout) (string, error) {
+	hash := gitplumbing.NewHash(checkout.Commit)
+
+	if len(checkout.Commit) == 0 {
+		ref, err := r.repo.Reference(gitplumbing.ReferenceName(gitFetchTarget(checkout.Ref)), true)
+		if err != nil {
+			return "", fmt.Errorf("could not find fetched reference %s: %v", checkout.Ref, err)
+		}
+
+		hash = ref.Hash()
+
+		// Peel annotated tags to the commit they point to
+		if tag, err := r.repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return "", fmt.Errorf("could not resolve %s to a commit: %v", checkout.Ref, err)
+			}
+
+			hash = commit.Hash
+		}
+	}
+
+	if _, err := r.repo.CommitObject(hash); err != nil {
+		return "", fmt.Errorf("could not find commit %s: %v", hash, err)
+	}
+
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("could not open worktree: %v", err)
+	}
+
+	// Local changes to an existing checkout are not overwritten.  go-git moves
+	// HEAD before refusing to update a modified worktree, so check beforehand.
+	if _, err := r.repo.Head(); err == nil {
+		status, err := worktree.Status()
+		if err != nil {
+			return "", fmt.Errorf("could not determine status of %s: %v", r.local, err)
+		}
+
+		for file, s := range status {
+			if s.Worktree != git.Untracked && (s.Worktree != git.Unmodified || s.Staging != git.Unmodified) {
+				return "", fmt.Errorf("could not check out commit %s: local changes to %s would be overwritten", hash, file)
+			}
+		}
+	}
+
+	if err := worktree.Checkout(&git.CheckoutOptions{
+		Hash: hash,
+	}); err != nil {
+		return "", fmt.Errorf("could not check out commit %s: %v", hash, err)
+	}
+
+	return hash.String(), nil
+}
+
+// Close implements gitRepository
+func (r *goGitRepository) Close() error {
+	return nil
+}
+
+var gitProgressRegexp = regexp.MustCompile(`(Counting|Compressing) objects:\s+\d+% \((\d+)/(\d+)\)`)
+
+// gitProgress parses the progress which the remote reports over the sideband
+// channel.  Counting and compressing objects each account for half of the
+// progress.
+type gitProgress struct {
+	onProgress func(progress float64)
+}
+
+// Write implements io.Writer
+func (p *gitProgress) Write(b []byte) (int, error) {
+	for _, match := range gitProgressRegexp.FindAllSubmatch(b, -1) {
+		done, err := strconv.ParseFloat(string(match[2]), 64)
+		if err != nil {
+			continue
+		}
+
+		total, err := strconv.ParseFloat(string(match[3]), 64)
+		if err != nil || total == 0 {
+			continue
+		}
+
+		progress := done / total / 2
+		if string(match[1]) == "Compressing" {
+			progress += 0.5
+		}
+
+		p.onProgress(progress)
+	}
+
+	return len(b), nil
+}
@@ -0,0 +1,182 @@
+//go:build !libgit2
+// +build !libgit2
+
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitplumbing "github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+)
+
+func TestGoGitRepository(t *testing.T) {
+	remote := t.TempDir()
+
+	upstream, err := git.PlainInit(remote, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := upstream.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(contents string) string {
+		if err := ioutil.WriteFile(filepath.Join(remote, "Makefile.uk"), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := worktree.Add("Makefile.uk"); err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := worktree.Commit(contents, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return hash.String()
+	}
+
+	first := commit("first")
+	second := commit("second")
+
+	if _, err := upstream.CreateTag("RELEASE-0.1.0", gitplumbing.NewHash(first), &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "0.1.0",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := listGitRefs(remote, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := filepath.Join(t.TempDir(), "lib")
+	opts := &gitOptions{
+		log: logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false)),
+	}
+
+	for _, tc := range []struct {
+		version  string
+		expected string
+		contents string
+	}{
+		{"master", second, "second"},
+		{"0.1.0", first, "first"},
+		{second[:7], second, "second"},
+	} {
+		checkout, err := resolveGitCheckout(tc.version, "", refs)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.version, err)
+		}
+
+		repo, err := openGitRepository(local, remote, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.version, err)
+		}
+
+		if err := repo.Fetch(checkout); err != nil {
+			t.Fatalf("%s: could not fetch: %v", tc.version, err)
+		}
+
+		commit, err := repo.Checkout(checkout)
+		if err != nil {
+			t.Fatalf("%s: could not check out: %v", tc.version, err)
+		}
+
+		repo.Close()
+
+		if commit != tc.expected {
+			t.Errorf("%s: expected commit %s, got %s", tc.version, tc.expected, commit)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(local, "Makefile.uk"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(contents) != tc.contents {
+			t.Errorf("%s: expected contents %q, got %q", tc.version, tc.contents, contents)
+		}
+	}
+
+	// Local changes must not be overwritten
+	if err := ioutil.WriteFile(filepath.Join(local, "Makefile.uk"), []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := openGitRepository(local, remote, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer repo.Close()
+
+	if _, err := repo.Checkout(&gitCheckout{Commit: first}); err == nil {
+		t.Errorf("expected local changes to prevent check out")
+	}
+
+	if _, err := os.Stat(filepath.Join(local, ".git")); err != nil {
+		t.Errorf("expected repository in %s: %v", local, err)
+	}
+}
+
+func TestGitProgress(t *testing.T) {
+	var progress []float64
+
+	p := &gitProgress{onProgress: func(f float64) {
+		progress = append(progress, f)
+	}}
+
+	p.Write([]byte("Enumerating objects: 10, done.\nCounting objects:  50% (5/10)\r"))
+	p.Write([]byte("Compressing objects: 100% (4/4), done.\n"))
+
+	if len(progress) != 2 || progress[0] != 0.25 || progress[1] != 1 {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+}
@@ -0,0 +1,214 @@
+//go:build libgit2
+// +build libgit2
+
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/libgit2/git2go/v31"
+)
+
+// libgit2Repository is a gitRepository backed by libgit2
+type libgit2Repository struct {
+	repo   *git.Repository
+	origin *git.Remote
+	remote string
+	opts   *gitOptions
+}
+
+// openGitRepository opens the Git repository at the local path, initializing
+// it when it does not exist, and points its origin at the remote repository.
+func openGitRepository(local, remote string, opts *gitOptions) (gitRepository, error) {
+	repo, err := git.OpenRepository(local)
+	if err != nil {
+		if err := os.MkdirAll(local, 0o755); err != nil {
+			return nil, fmt.Errorf("could not create directory: %v", err)
+		}
+
+		repo, err = git.InitRepository(local, false)
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize repository: %v", err)
+		}
+	} else {
+		opts.log.Infof("updating existing checkout in %s", local)
+	}
+
+	origin, err := repo.Remotes.Lookup("origin")
+	if err != nil {
+		origin, err = repo.Remotes.Create("origin", remote)
+		if err != nil {
+			repo.Free()
+			return nil, fmt.Errorf("could not add remote: %v", err)
+		}
+	} else if origin.Url() != remote {
+		origin.Free()
+
+		if err := repo.Remotes.SetUrl("origin", remote); err != nil {
+			repo.Free()
+			return nil, fmt.Errorf("could not update remote: %v", err)
+		}
+
+		origin, err = repo.Remotes.Lookup("origin")
+		if err != nil {
+			repo.Free()
+			return nil, fmt.Errorf("could not update remote: %v", err)
+		}
+	}
+
+	return &libgit2Repository{
+		repo:   repo,
+		origin: origin,
+		remote: remote,
+		opts:   opts,
+	}, nil
+}
+
+// callbacks returns the remote callbacks which report progress and provide
+// the configured credentials
+func (r *libgit2Repository) callbacks() git.RemoteCallbacks {
+	remote := parseGitRemote(r.remote)
+	auth, hasAuth := gitAuthConfig(r.remote, r.opts.auths)
+
+	// libgit2 repeatedly invokes the credentials callback when authentication
+	// fails, so only provide credentials once per type
+	tried := map[git.CredentialType]bool{}
+
+	callbacks := git.RemoteCallbacks{
+		TransferProgressCallback: func(stats git.TransferProgress) git.ErrorCode {
+			if stats.TotalObjects > 0 && r.opts.onProgress != nil {
+				r.opts.onProgress(float64(stats.ReceivedObjects) / float64(stats.TotalObjects))
+			}
+			return 0
+		},
+		CredentialsCallback: func(url, username string, allowed git.CredentialType) (*git.Credential, error) {
+			if len(username) == 0 {
+				username = gitUser(remote, auth)
+			}
+
+			switch {
+			case allowed&git.CredentialTypeSSHKey != 0 && !tried[git.CredentialTypeSSHKey]:
+				tried[git.CredentialTypeSSHKey] = true
+				return git.NewCredentialSSHKeyFromAgent(username)
+
+			case allowed&git.CredentialTypeUserpassPlaintext != 0 && !tried[git.CredentialTypeUserpassPlaintext] && hasAuth && len(auth.Token) > 0:
+				tried[git.CredentialTypeUserpassPlaintext] = true
+				return git.NewCredentialUserpassPlaintext(username, auth.Token)
+			}
+
+			return nil, fmt.Errorf("no valid credentials for %s", url)
+		},
+	}
+
+	if hasAuth && !auth.VerifySSL {
+		callbacks.CertificateCheckCallback = func(cert *git.Certificate, valid bool, hostname string) git.ErrorCode {
+			return git.ErrorCodeOK
+		}
+	}
+
+	return callbacks
+}
+
+// Fetch implements gitRepository.  libgit2 does not support shallow fetches,
+// so only the reference which is checked out is fetched where it is known.
+func (r *libgit2Repository) Fetch(checkout *gitCheckout) error {
+	return r.origin.Fetch(gitRefSpecs(checkout), &git.FetchOptions{
+		RemoteCallbacks: r.callbacks(),
+		DownloadTags:    git.DownloadTagsNone,
+	}, "")
+}
+
+// Checkout implements gitRepository
+func (r *libgit2Repository) Checkout(checkout *gitCheckout) (string, error) {
+	var oid *git.Oid
+	var err error
+
+	if len(checkout.Commit) > 0 {
+		oid, err = git.NewOid(checkout.Commit)
+		if err != nil {
+			return "", fmt.Errorf("could not parse commit: %v", err)
+		}
+	} else {
+		ref, err := r.repo.References.Lookup(gitFetchTarget(checkout.Ref))
+		if err != nil {
+			return "", fmt.Errorf("could not find fetched reference %s: %v", checkout.Ref, err)
+		}
+
+		defer ref.Free()
+
+		object, err := ref.Peel(git.ObjectCommit)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve %s to a commit: %v", checkout.Ref, err)
+		}
+
+		defer object.Free()
+
+		oid = object.Id()
+	}
+
+	commit, err := r.repo.LookupCommit(oid)
+	if err != nil {
+		return "", fmt.Errorf("could not find commit %s: %v", oid, err)
+	}
+
+	defer commit.Free()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("could not read commit %s: %v", oid, err)
+	}
+
+	defer tree.Free()
+
+	if err := r.repo.CheckoutTree(tree, &git.CheckoutOptions{
+		Strategy: git.CheckoutSafe | git.CheckoutRecreateMissing,
+	}); err != nil {
+		return "", fmt.Errorf("could not check out commit %s: %v", oid, err)
+	}
+
+	if err := r.repo.SetHeadDetached(oid); err != nil {
+		return "", fmt.Errorf("could not check out commit %s: %v", oid, err)
+	}
+
+	return oid.String(), nil
+}
+
+// Close implements gitRepository
+func (r *libgit2Repository) Close() error {
+	r.origin.Free()
+	r.repo.Free()
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	gitplumbing "github.com/go-git/go-git/v5/plumbing"
+
+	"kraftkit.sh/config"
+)
+
+// gitCheckout describes the reference which is fetched from a remote Git
+// repository and the commit which is subsequently checked out.
+type gitCheckout struct {
+	// Ref is the full name of the remote reference to fetch, e.g.
+	// `refs/heads/stable`.  When empty, all branches and tags are fetched.
+	Ref string
+
+	// Commit is the exact commit to check out.  When empty, the commit the
+	// fetched reference points to is checked out.
+	Commit string
+
+	// Shallow indicates that fetching only the tip of the reference suffices to
+	// check out the commit.
+	Shallow bool
+}
+
+var gitShortShaRegexp = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// listGitRefs lists the references of the remote Git repository
+func listGitRefs(repo string, auths map[string]config.AuthConfig) ([]*gitplumbing.Reference, error) {
+	var err error
+
+	remote := git.NewRemote(nil, &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repo},
+	})
+
+	lopts := &git.ListOptions{}
+	if auth, ok := gitAuthConfig(repo, auths); ok {
+		lopts.InsecureSkipTLS = !auth.VerifySSL
+	}
+
+	lopts.Auth, err = gitAuthMethod(repo, auths)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.List(lopts)
+}
+
+// resolveGitCheckout determines what to fetch and check out for the requested
+// version, which is either a branch (channel), a tag or a (short) commit SHA,
+// given the references of the remote repository.  When the version has been
+// pinned to an exact commit, e.g. by a lockfile, this commit is checked out.
+func resolveGitCheckout(version, pinned string, refs []*gitplumbing.Reference) (*gitCheckout, error) {
+	var match *gitplumbing.Reference
+
+	for _, name := range []gitplumbing.ReferenceName{
+		gitplumbing.NewBranchReferenceName(version),
+		gitplumbing.NewTagReferenceName(version),
+		gitplumbing.NewTagReferenceName("RELEASE-" + version),
+	} {
+		for _, ref := range refs {
+			if ref.Name() == name {
+				match = ref
+				break
+			}
+		}
+
+		if match != nil {
+			break
+		}
+	}
+
+	// Fall back to the references pointing at the commit of a short SHA
+	if match == nil && gitShortShaRegexp.MatchString(version) {
+		for _, ref := range refs {
+			if (ref.Name().IsBranch() || ref.Name().IsTag()) && strings.HasPrefix(ref.Hash().String(), version) {
+				match = ref
+				break
+			}
+		}
+	}
+
+	switch {
+	case match != nil && IsGitSha(pinned):
+		return &gitCheckout{
+			Ref:     match.Name().String(),
+			Commit:  pinned,
+			Shallow: match.Hash().String() == pinned,
+		}, nil
+
+	case match != nil && match.Name().IsBranch():
+		return &gitCheckout{
+			Ref:     match.Name().String(),
+			Commit:  match.Hash().String(),
+			Shallow: true,
+		}, nil
+
+	case match != nil:
+		// Annotated tags point to a tag object rather than a commit which is
+		// peeled once fetched
+		return &gitCheckout{
+			Ref:     match.Name().String(),
+			Shallow: true,
+		}, nil
+
+	case IsGitSha(pinned):
+		return &gitCheckout{Commit: pinned}, nil
+
+	case IsGitSha(version):
+		return &gitCheckout{Commit: version}, nil
+	}
+
+	var available []string
+	for _, ref := range refs {
+		if ref.Name().IsBranch() || ref.Name().IsTag() {
+			available = append(available, ref.Name().Short())
+		}
+	}
+
+	sort.Strings(available)
+
+	return nil, fmt.Errorf("could not find %s in remote repository, available: %s", version, strings.Join(available, ", "))
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	gitplumbing "github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestResolveGitCheckout(t *testing.T) {
+	const (
+		stable  = "1111111111111111111111111111111111111111"
+		staging = "2222222222222222222222222222222222222222"
+		release = "3333333333333333333333333333333333333333"
+		older   = "4444444444444444444444444444444444444444"
+	)
+
+	refs := []*gitplumbing.Reference{
+		gitplumbing.NewHashReference("refs/heads/stable", gitplumbing.NewHash(stable)),
+		gitplumbing.NewHashReference("refs/heads/staging", gitplumbing.NewHash(staging)),
+		gitplumbing.NewHashReference("refs/tags/RELEASE-0.11.0", gitplumbing.NewHash(release)),
+	}
+
+	for _, tc := range []struct {
+		version  string
+		pinned   string
+		expected gitCheckout
+	}{
+		{"stable", "", gitCheckout{Ref: "refs/heads/stable", Commit: stable, Shallow: true}},
+		{"stable", stable, gitCheckout{Ref: "refs/heads/stable", Commit: stable, Shallow: true}},
+		{"stable", older, gitCheckout{Ref: "refs/heads/stable", Commit: older}},
+		{"RELEASE-0.11.0", "", gitCheckout{Ref: "refs/tags/RELEASE-0.11.0", Shallow: true}},
+		{"0.11.0", "", gitCheckout{Ref: "refs/tags/RELEASE-0.11.0", Shallow: true}},
+		{"3333333", "", gitCheckout{Ref: "refs/tags/RELEASE-0.11.0", Shallow: true}},
+		{older, "", gitCheckout{Commit: older}},
+	} {
+		checkout, err := resolveGitCheckout(tc.version, tc.pinned, refs)
+		if err != nil {
+			t.Errorf("%s: %v", tc.version, err)
+			continue
+		}
+
+		if *checkout != tc.expected {
+			t.Errorf("%s (pinned %q): expected %+v, got %+v", tc.version, tc.pinned, tc.expected, *checkout)
+		}
+	}
+
+	_, err := resolveGitCheckout("unknown", "", refs)
+	if err == nil || !strings.Contains(err.Error(), "available: RELEASE-0.11.0, stable, staging") {
+		t.Errorf("expected error listing available references, got: %v", err)
+	}
+}
@@ -32,10 +32,10 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -70,6 +70,11 @@ func NewManifestIndexProvider(path string, mopts ...ManifestOption) (Provider, e
 		}, nil
 	}
 
+	var serr *SignatureError
+	if errors.As(err, &serr) {
+		return nil, err
+	}
+
 	index, err = NewManifestIndexFromURL(path, mopts...)
 	if err == nil {
 		return ManifestIndexProvider{
@@ -79,6 +84,10 @@ func NewManifestIndexProvider(path string, mopts ...ManifestOption) (Provider, e
 		}, nil
 	}
 
+	if errors.As(err, &serr) {
+		return nil, err
+	}
+
 	return nil, fmt.Errorf("provided path is not a manifest index: %s", path)
 }
 
@@ -136,7 +145,16 @@ func NewManifestIndexFromFile(path string, mopts ...ManifestOption) (*ManifestIn
 		return nil, err
 	}
 
-	return NewManifestIndexFromBytes(contents, mopts...)
+	index, err := NewManifestIndexFromBytes(contents, mopts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyDocument(path, contents, mopts); err != nil {
+		return nil, err
+	}
+
+	return index, nil
 }
 
 // NewManifestFromURL retrieves a provided path as a ManifestIndex from a remote
@@ -147,16 +165,12 @@ func NewManifestIndexFromURL(path string, mopts ...ManifestOption) (*ManifestInd
 		return nil, err
 	}
 
-	resp, err := http.Get(path)
+	resp, err := getDocument(path, mopts)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("received %d error when retreiving: %s", resp.StatusCode, path)
-	}
-
 	// Check if we're directly pointing to a compatible manifest file
 	ext := filepath.Ext(path)
 	if ext != ".yml" && ext != ".yaml" {
@@ -173,6 +187,10 @@ func NewManifestIndexFromURL(path string, mopts ...ManifestOption) (*ManifestInd
 		return nil, err
 	}
 
+	if err := verifyDocument(path, contents, mopts); err != nil {
+		return nil, err
+	}
+
 	return index, nil
 }
 
@@ -33,9 +33,13 @@ package manifest
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gobwas/glob"
@@ -132,7 +136,10 @@ func (mm ManifestManager) Update() error {
 	mopts := []ManifestOption{
 		WithAuthConfig(mm.Options().ConfigManager.Config.Auth),
 		WithSourcesRootDir(mm.Options().ConfigManager.Config.Paths.Sources),
+		WithMirrors(mm.Options().ConfigManager.Config.Unikraft.Mirrors),
+		WithGitProtocol(mm.Options().ConfigManager.Config.GitProtocol),
 		WithLogger(mm.Options().Log),
+		WithTrustedKeys(cfm.Config.Unikraft.TrustedKeys),
 	}
 
 	for _, manipath := range cfm.Config.Unikraft.Manifests {
@@ -145,7 +152,10 @@ func (mm ManifestManager) Update() error {
 		// }
 
 		manifests, err := FindManifestsFromSource(manipath, mopts...)
-		if err != nil {
+		var serr *SignatureError
+		if errors.As(err, &serr) {
+			return err
+		} else if err != nil {
 			mm.opts.Log.Warnf("%s", err)
 		}
 
@@ -216,11 +226,6 @@ func (mm ManifestManager) RemoveSource(source string) error {
 	return cfm.Write(false)
 }
 
-// Push the resulting package to the supported registry of the implementation.
-func (mm ManifestManager) Push(path string) error {
-	return fmt.Errorf("not implemented pack.ManifestManager.Pushh")
-}
-
 // Pull a package from the support registry of the implementation.
 func (mm ManifestManager) Pull(path string, opts *pack.PullPackageOptions) ([]pack.Package, error) {
 	if _, err := mm.IsCompatible(path); err != nil {
@@ -234,7 +239,8 @@ func (um ManifestManager) From(sub string) (packmanager.PackageManager, error) {
 	return nil, fmt.Errorf("method not applicable to manifest manager")
 }
 
-func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.PackageOption) ([]pack.Package, error) {
+// Manifests returns all manifests referenced by the local manifest index
+func (mm ManifestManager) Manifests() ([]*Manifest, error) {
 	index, err := NewManifestIndexFromFile(mm.LocalManifestIndex())
 	if err != nil {
 		return nil, err
@@ -243,6 +249,8 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 	mopts := []ManifestOption{
 		WithAuthConfig(mm.Options().ConfigManager.Config.Auth),
 		WithSourcesRootDir(mm.Options().ConfigManager.Config.Paths.Sources),
+		WithMirrors(mm.Options().ConfigManager.Config.Unikraft.Mirrors),
+		WithGitProtocol(mm.Options().ConfigManager.Config.GitProtocol),
 		WithLogger(mm.Options().Log),
 	}
 
@@ -261,11 +269,35 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 		}
 	}
 
+	return allManifests, nil
+}
+
+// Resolve computes a consistent set of component versions which satisfies the
+// provided requirements and the dependencies declared by the manifests
+func (mm ManifestManager) Resolve(reqs ...Requirement) ([]Resolution, error) {
+	manifests, err := mm.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewResolver(manifests).Resolve(reqs...)
+}
+
+func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.PackageOption) ([]pack.Package, error) {
+	allManifests, err := mm.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
 	var packages []pack.Package
+	var scores []int
 	var g glob.Glob
 
 	if len(query.Name) > 0 {
-		g = glob.MustCompile(query.Name)
+		g, err = glob.Compile(query.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern %s: %v", query.Name, err)
+		}
 	}
 
 	for _, manifest := range allManifests {
@@ -282,30 +314,27 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 			}
 		}
 
-		if len(query.Name) > 0 && !g.Match(manifest.Name) {
+		score, ok := rank(manifest, query, g)
+		if !ok {
 			continue
 		}
 
+		// Resolve channels, exact versions and version ranges such as `^0.10`
+		// against the versions of the manifest
 		var versions []string
 		if len(query.Version) > 0 {
-			for _, version := range manifest.Versions {
-				if version.Version == query.Version {
-					versions = append(versions, version.Version)
-					break
-				}
-			}
-			if len(versions) == 0 {
-				for _, channel := range manifest.Channels {
-					if channel.Name == query.Version {
-						versions = append(versions, channel.Name)
-						break
-					}
+			version, err := manifest.ResolveVersion(query.Version)
+			if err != nil {
+				// Only report why a specifically requested component could not be
+				// resolved rather than every component matching a pattern
+				if query.Name == manifest.Name {
+					return nil, err
 				}
-			}
 
-			if len(versions) == 0 {
-				break
+				continue
 			}
+
+			versions = append(versions, version)
 		}
 
 		if len(versions) > 0 {
@@ -319,6 +348,7 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 				}
 
 				packages = append(packages, p)
+				scores = append(scores, score)
 			}
 		} else {
 			packs, err := NewPackageFromManifest(manifest, popts...)
@@ -330,9 +360,15 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 			}
 
 			packages = append(packages, packs)
+			scores = append(scores, score)
 		}
 	}
 
+	// Present the best matches first
+	if query.Fuzzy {
+		sort.Stable(byScore{packages, scores})
+	}
+
 	for i := range packages {
 		packages[i].ApplyOptions(
 			pack.WithLogger(mm.Options().Log),
@@ -342,6 +378,66 @@ func (mm ManifestManager) Catalog(query packmanager.CatalogQuery, popts ...pack.
 	return packages, nil
 }
 
+// rank returns whether the manifest matches the tags and name of the query
+// and, for fuzzy queries, how well it does.  Names are matched exactly or by
+// glob pattern first and otherwise approximately against the name, tags and
+// keywords of the manifest, or as part of its description.
+func rank(manifest *Manifest, query packmanager.CatalogQuery, g glob.Glob) (int, bool) {
+	for _, tag := range query.Tags {
+		found := false
+		for _, t := range manifest.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, false
+		}
+	}
+
+	if len(query.Name) == 0 {
+		return 0, true
+	}
+
+	if g.Match(manifest.Name) {
+		return math.MaxInt32, true
+	}
+
+	if !query.Fuzzy || packmanager.IsGlob(query.Name) {
+		return 0, false
+	}
+
+	best, ok := packmanager.FuzzyScore(query.Name, manifest.Name)
+
+	// Matches of tags and keywords rank below similar matches of the name
+	for _, term := range append(append([]string{}, manifest.Tags...), manifest.Keywords...) {
+		if score, found := packmanager.FuzzyScore(query.Name, term); found && (!ok || score/2 > best) {
+			best, ok = score/2, true
+		}
+	}
+
+	if !ok && strings.Contains(strings.ToLower(manifest.Description), strings.ToLower(query.Name)) {
+		best, ok = 0, true
+	}
+
+	return best, ok
+}
+
+// byScore sorts packages by descending score
+type byScore struct {
+	packages []pack.Package
+	scores   []int
+}
+
+func (s byScore) Len() int           { return len(s.packages) }
+func (s byScore) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+func (s byScore) Swap(i, j int) {
+	s.packages[i], s.packages[j] = s.packages[j], s.packages[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
 func (mm ManifestManager) IsCompatible(source string) (packmanager.PackageManager, error) {
 	if _, err := NewProvider(source); err != nil {
 		return nil, fmt.Errorf("incompatible source")
@@ -32,6 +32,7 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -39,6 +40,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"kraftkit.sh/config"
 	"kraftkit.sh/log"
@@ -62,6 +65,14 @@ type Manifest struct {
 	// Description of what this manifest represents
 	Description string `yaml:"description,omitempty"`
 
+	// Tags categorise the manifest, e.g. `network` or `crypto`, and can be
+	// searched for exactly
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Keywords are additional terms, e.g. the features or protocols provided,
+	// by which the manifest can be found when searching
+	Keywords []string `yaml:"keywords,omitempty"`
+
 	// GitRepo represents the code repository by which this manifests is populated
 	GitRepo string `yaml:"git,omitempty"`
 
@@ -80,6 +91,26 @@ type Manifest struct {
 	// resource
 	auths map[string]config.AuthConfig
 
+	// httpClient is an internal property set by a ManifestOption which is used
+	// to retrieve the manifest's remote documents and signatures
+	httpClient *http.Client
+
+	// trustedKeys is an internal property set by a ManifestOption which is used
+	// to verify the signature of the manifest when it is retrieved
+	trustedKeys []string
+
+	// mirrors is an internal property set by a ManifestOption which is used as
+	// fallback locations when retrieving a resource of the manifest
+	mirrors []string
+
+	// sourcesRootDir is an internal property set by a ManifestOption which
+	// holds the root of the sources directory and its content-addressed store
+	sourcesRootDir string
+
+	// gitProtocol is an internal property set by a ManifestOption which selects
+	// the protocol used to access the Git repository of the manifest
+	gitProtocol string
+
 	// log is an internal property used to perform logging within the context of
 	// the manfiest
 	log log.Logger
@@ -103,6 +134,11 @@ func NewManifestProvider(path string, mopts ...ManifestOption) (Provider, error)
 		}, nil
 	}
 
+	var serr *SignatureError
+	if errors.As(err, &serr) {
+		return nil, err
+	}
+
 	manifest, err = NewManifestFromURL(path, mopts...)
 	if err == nil {
 		return ManifestProvider{
@@ -111,6 +147,10 @@ func NewManifestProvider(path string, mopts ...ManifestOption) (Provider, error)
 		}, nil
 	}
 
+	if errors.As(err, &serr) {
+		return nil, err
+	}
+
 	return nil, fmt.Errorf("provided path is not a manifest: %s", path)
 }
 
@@ -165,6 +205,10 @@ func NewManifestFromFile(path string, mopts ...ManifestOption) (*Manifest, error
 		return nil, err
 	}
 
+	if err := verifyDocument(path, contents, mopts); err != nil {
+		return nil, err
+	}
+
 	manifest.SourceOrigin = path
 
 	return manifest, nil
@@ -178,16 +222,12 @@ func NewManifestFromURL(path string, mopts ...ManifestOption) (*Manifest, error)
 		return nil, err
 	}
 
-	resp, err := http.Get(path)
+	resp, err := getDocument(path, mopts)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("received %d error when retreiving: %s", resp.StatusCode, path)
-	}
-
 	// Check if we're directly pointing to a compatible manifest file
 	ext := filepath.Ext(path)
 	if ext != ".yml" && ext != ".yaml" {
@@ -204,6 +244,10 @@ func NewManifestFromURL(path string, mopts ...ManifestOption) (*Manifest, error)
 		return nil, err
 	}
 
+	if err := verifyDocument(path, contents, mopts); err != nil {
+		return nil, err
+	}
+
 	manifest.SourceOrigin = path
 
 	return manifest, nil
@@ -226,7 +270,11 @@ func findManifestsFromSource(lastSource, source string, mopts []ManifestOption)
 
 	// Follow relative paths by using the lastSource
 	if len(lastSource) > 0 {
-		if f, err := os.Stat(lastSource); err == nil && f.IsDir() {
+		if base, err := url.Parse(lastSource); err == nil && (base.Scheme == "http" || base.Scheme == "https") {
+			if ref, err := url.Parse(source); err == nil {
+				source = base.ResolveReference(ref).String()
+			}
+		} else if f, err := os.Stat(lastSource); err == nil && f.IsDir() {
 			source = filepath.Join(lastSource, source)
 		} else {
 			dir, _ := filepath.Split(lastSource)
@@ -308,6 +356,86 @@ func (m Manifest) DefaultChannel() (*ManifestChannel, error) {
 	return nil, fmt.Errorf("manifest does not have a default channel: %s", m.SourceOrigin)
 }
 
+// ResolveVersion returns the channel or version of the Manifest which best
+// satisfies the provided constraint.  Channels, e.g. `stable`, and exact
+// versions are returned as-is, whereas ranges such as `^0.10` or `>=0.9 <0.11`
+// select the newest semantic version which satisfies them, falling back to a
+// channel whose latest version does.  An empty constraint selects the default
+// channel or, without one, the newest version.
+func (m Manifest) ResolveVersion(constraint string) (string, error) {
+	constraint = strings.TrimSpace(constraint)
+	if len(constraint) == 0 {
+		channel, err := m.DefaultChannel()
+		if err == nil {
+			return channel.Name, nil
+		}
+
+		if len(m.Versions) == 0 {
+			return "", err
+		}
+
+		newest := m.Versions[0].Version
+		for _, version := range m.Versions[1:] {
+			if compareVersions(version.Version, newest) > 0 {
+				newest = version.Version
+			}
+		}
+
+		return newest, nil
+	}
+
+	for _, channel := range m.Channels {
+		if channel.Name == constraint {
+			return channel.Name, nil
+		}
+	}
+
+	for _, version := range m.Versions {
+		if version.Version == constraint {
+			return version.Version, nil
+		}
+	}
+
+	cons, err := NewConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	var versions []string
+	for _, version := range m.Versions {
+		if version.Type == ManifestVersionGitSha {
+			continue
+		}
+
+		if _, ok := parseSemver(version.Version); ok {
+			versions = append(versions, version.Version)
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) > 0
+	})
+
+	for _, version := range versions {
+		if cons.Check(version) {
+			return version, nil
+		}
+	}
+
+	for _, channel := range m.Channels {
+		if len(channel.Latest) > 0 && cons.Check(channel.Latest) {
+			return channel.Name, nil
+		}
+	}
+
+	available := availableVersions(&m)
+	if len(available) == 0 {
+		return "", fmt.Errorf("no version of %s/%s satisfies %s: no versions available", m.Type, m.Name, constraint)
+	}
+
+	return "", fmt.Errorf("no version of %s/%s satisfies %s, available versions: %s", m.Type, m.Name, constraint, strings.Join(available, ", "))
+}
+
 // Auths returns the map of provided authentication configuration passed as an
 // option to the Manifest
 func (m Manifest) Auths() map[string]config.AuthConfig {
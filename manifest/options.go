@@ -32,8 +32,10 @@
 package manifest
 
 import (
+	"net/http"
 	"path/filepath"
 
+	"kraftkit.sh/archive"
 	"kraftkit.sh/config"
 	"kraftkit.sh/log"
 	"kraftkit.sh/unikraft"
@@ -48,6 +50,47 @@ func WithAuthConfig(auths map[string]config.AuthConfig) ManifestOption {
 	}
 }
 
+// WithHTTPClient sets the HTTP client which is used to retrieve remote
+// manifests, manifest indexes and their signatures, defaulting to
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) ManifestOption {
+	return func(m *Manifest) error {
+		m.httpClient = client
+		return nil
+	}
+}
+
+// WithTrustedKeys sets the list of public keys which are used to verify the
+// signature of manifest indexes and manifests as they are retrieved.  When no
+// keys are provided, signatures are not verified.
+func WithTrustedKeys(keys []string) ManifestOption {
+	return func(m *Manifest) error {
+		m.trustedKeys = keys
+		return nil
+	}
+}
+
+// WithMirrors sets the list of mirrors which are consulted, in order, when a
+// resource of the manifest cannot be retrieved from its original location.
+// Mirrors are expected to follow the same directory structure as the sources
+// directory, see WithSourcesRootDir.
+func WithMirrors(mirrors []string) ManifestOption {
+	return func(m *Manifest) error {
+		m.mirrors = mirrors
+		return nil
+	}
+}
+
+// WithGitProtocol sets the protocol, either "https" or "ssh", which is used to
+// access the Git repositories of manifests regardless of the protocol of the
+// repository's location.
+func WithGitProtocol(protocol string) ManifestOption {
+	return func(m *Manifest) error {
+		m.gitProtocol = protocol
+		return nil
+	}
+}
+
 func WithLogger(l log.Logger) ManifestOption {
 	return func(m *Manifest) error {
 		m.log = l
@@ -64,15 +107,14 @@ func WithSourcesRootDir(dir string) ManifestOption {
 		// See: https://github.com/golang/go/wiki/CommonMistakes#using-reference-to-loop-iterator-variable
 		dir := dir
 
+		m.sourcesRootDir = dir
+
 		if m.Type != unikraft.ComponentTypeCore {
 			dir = filepath.Join(dir, m.Type.Plural())
 		}
 
 		for i, channel := range m.Channels {
-			ext := filepath.Ext(channel.Resource)
-			if ext == ".gz" {
-				ext = ".tar.gz"
-			}
+			ext := resourceExtension(channel.Resource)
 
 			m.Channels[i].Local = filepath.Join(
 				dir, m.Name+"-"+channel.Name+ext,
@@ -80,10 +122,7 @@ func WithSourcesRootDir(dir string) ManifestOption {
 		}
 
 		for i, version := range m.Versions {
-			ext := filepath.Ext(version.Resource)
-			if ext == ".gz" {
-				ext = ".tar.gz"
-			}
+			ext := resourceExtension(version.Resource)
 
 			m.Versions[i].Local = filepath.Join(
 				dir, m.Name+"-"+version.Version+ext,
@@ -93,3 +132,23 @@ func WithSourcesRootDir(dir string) ManifestOption {
 		return nil
 	}
 }
+
+// resourceExtension returns the file extension of an archive resource
+// including any compression suffix, e.g. ".tar.xz"
+func resourceExtension(resource string) string {
+	if format := archive.FormatFromExtension(resource); format != archive.FormatUnknown {
+		return "." + string(format)
+	}
+
+	ext := filepath.Ext(resource)
+	switch ext {
+	case ".gz":
+		ext = ".tar.gz"
+	case ".xz":
+		ext = ".tar.xz"
+	case ".bz2":
+		ext = ".tar.bz2"
+	}
+
+	return ext
+}
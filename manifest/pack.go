@@ -56,6 +56,8 @@ func NewPackageFromOptions(opts *pack.PackageOptions) (pack.Package, error) {
 // construct based on the input Manifest for a particular version
 func NewPackageWithVersion(manifest *Manifest, version string, popts ...pack.PackageOption) (pack.Package, error) {
 	resource := ""
+	checksum := ""
+	resolved := ""
 
 	var channels []ManifestChannel
 	var versions []ManifestVersion
@@ -65,12 +67,19 @@ func NewPackageWithVersion(manifest *Manifest, version string, popts ...pack.Pac
 		if channel.Name == version {
 			channels = append(channels, channel)
 			resource = channel.Resource
+			checksum = channel.Sha256
+			resolved = channel.Latest
+			if len(resolved) == 0 {
+				resolved = channel.Name
+			}
 		}
 	}
 
 	for _, ver := range manifest.Versions {
 		if ver.Version == version {
 			resource = ver.Resource
+			checksum = ver.Sha256
+			resolved = ver.Version
 			versions = append(versions, ver)
 		}
 	}
@@ -78,6 +87,18 @@ func NewPackageWithVersion(manifest *Manifest, version string, popts ...pack.Pac
 	manifest.Channels = channels
 	manifest.Versions = versions
 
+	// Expose the descriptive properties of the manifest for presentation
+	metadata := make(map[string]interface{})
+	if len(manifest.Description) > 0 {
+		metadata["manifest.description"] = manifest.Description
+	}
+	if len(manifest.Tags) > 0 {
+		metadata["manifest.tags"] = manifest.Tags
+	}
+	if len(manifest.Keywords) > 0 {
+		metadata["manifest.keywords"] = manifest.Keywords
+	}
+
 	// Save the full manifest within the context via the `ContextKey`
 	ctx := context.WithValue(
 		context.TODO(),
@@ -85,10 +106,13 @@ func NewPackageWithVersion(manifest *Manifest, version string, popts ...pack.Pac
 		manifest,
 	)
 
+	popts = append([]pack.PackageOption{pack.WithMetadata(metadata)}, popts...)
 	popts = append(popts,
 		pack.WithContext(ctx),
 		pack.WithName(manifest.Name),
 		pack.WithRemoteLocation(resource),
+		pack.WithSha256(checksum),
+		pack.WithResolvedVersion(resolved),
 		pack.WithType(manifest.Type),
 		pack.WithVersion(version),
 	)
@@ -102,14 +126,15 @@ func NewPackageWithVersion(manifest *Manifest, version string, popts ...pack.Pac
 }
 
 // NewPackageFromManifest generates a manifest implementation of the
-// pack.Package construct based on the input Manifest
+// pack.Package construct based on the input Manifest for its default channel or
+// newest version
 func NewPackageFromManifest(manifest *Manifest, popts ...pack.PackageOption) (pack.Package, error) {
-	channel, err := manifest.DefaultChannel()
+	version, err := manifest.ResolveVersion("")
 	if err != nil {
 		return nil, err
 	}
 
-	return NewPackageWithVersion(manifest, channel.Name, popts...)
+	return NewPackageWithVersion(manifest, version, popts...)
 }
 
 func (mp ManifestPackage) ApplyOptions(opts ...pack.PackageOption) error {
@@ -134,10 +159,6 @@ func (mp ManifestPackage) CanonicalName() string {
 	return "manifest://" + string(mp.PackageOptions.Type) + "-" + mp.PackageOptions.Name + ":" + mp.PackageOptions.Version
 }
 
-func (mp ManifestPackage) Pack() error {
-	return fmt.Errorf("not implemented: pack.manifest.Pack")
-}
-
 func (mp ManifestPackage) Compatible(ref string) bool {
 	return false
 }
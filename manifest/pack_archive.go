@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+)
+
+const (
+	// DefaultPackChannel is the name of the channel which is created when a
+	// package is packed into a manifest which does not have a default channel.
+	DefaultPackChannel = "stable"
+
+	// DefaultPackVersion is the version which is used when packing a package
+	// which has not been given an explicit version.
+	DefaultPackVersion = "latest"
+)
+
+// WithPackOutputDir sets the directory where the resulting tarball and manifest
+// are written to when packing.  When unset, the parent directory of the source
+// is used.
+func WithPackOutputDir(dir string) pack.PackageOption {
+	return func(opts *pack.PackageOptions) error {
+		opts.Metadata["manifest.output"] = dir
+		return nil
+	}
+}
+
+// WithPackResourceURL sets the base URL where the resulting tarball will be
+// published to.  The name of the tarball is appended to this URL and saved as
+// the resource of the new ManifestVersion.  When unset, the path to the tarball
+// on disk is used.
+func WithPackResourceURL(url string) pack.PackageOption {
+	return func(opts *pack.PackageOptions) error {
+		opts.Metadata["manifest.resource"] = url
+		return nil
+	}
+}
+
+// WithPackChannel sets the name of the channel which should point to the newly
+// packed version.  When unset, the default channel of the manifest is used.
+func WithPackChannel(channel string) pack.PackageOption {
+	return func(opts *pack.PackageOptions) error {
+		opts.Metadata["manifest.channel"] = channel
+		return nil
+	}
+}
+
+// Pack generates a reproducible gzip-compressed tarball of the package's source
+// directory (or of its kernel if no source is set) and writes or extends the
+// Manifest which lives next to it with a new ManifestVersion entry.
+func (mp ManifestPackage) Pack() error {
+	source, err := mp.Source()
+	if err != nil {
+		source, err = mp.Kernel()
+		if err != nil {
+			return fmt.Errorf("package has neither a source directory nor a kernel")
+		}
+	}
+
+	name := mp.Name()
+	ctype := mp.Type
+	if ctype == "" {
+		ctype = unikraft.ComponentTypeApp
+	}
+
+	version := mp.Version
+	if version == "" {
+		version = DefaultPackVersion
+	}
+
+	outdir, _ := mp.Metadata["manifest.output"].(string)
+	if outdir == "" {
+		outdir = filepath.Dir(filepath.Clean(source))
+	}
+
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+
+	prefix := name + "-" + version
+	tarball := filepath.Join(outdir, prefix+".tar.gz")
+
+	mp.Log().Infof("packing %s into %s", source, tarball)
+
+	checksum, err := writeArchive(source, prefix, tarball)
+	if err != nil {
+		return fmt.Errorf("could not create archive: %v", err)
+	}
+
+	resource := tarball
+	if base, _ := mp.Metadata["manifest.resource"].(string); base != "" {
+		resource = strings.TrimSuffix(base, "/") + "/" + filepath.Base(tarball)
+	} else if abs, err := filepath.Abs(tarball); err == nil {
+		resource = abs
+	}
+
+	manifestPath := filepath.Join(outdir, name+".yaml")
+	manifest := &Manifest{
+		Name: name,
+		Type: ctype,
+	}
+
+	if _, err := os.Stat(manifestPath); err == nil {
+		manifest, err = NewManifestFromFile(manifestPath)
+		if err != nil {
+			return fmt.Errorf("could not read existing manifest: %v", err)
+		}
+
+		if manifest.Name != name || manifest.Type != ctype {
+			return fmt.Errorf("existing manifest %s describes %s/%s", manifestPath, manifest.Type, manifest.Name)
+		}
+	}
+
+	mv := ManifestVersion{
+		Version:  version,
+		Resource: resource,
+		Sha256:   checksum,
+		Type:     ManifestVersionSemver,
+	}
+
+	// A full-length Git commit SHA as the version of the package determines the
+	// ManifestVersionType
+	if IsGitSha(version) {
+		mv.Type = ManifestVersionGitSha
+	}
+
+	manifest.addVersion(mv)
+
+	// Advance the requested channel, otherwise the default channel, to the newly
+	// packed version
+	channel, _ := mp.Metadata["manifest.channel"].(string)
+	if channel == "" {
+		if dc, err := manifest.DefaultChannel(); err == nil {
+			channel = dc.Name
+		} else {
+			channel = DefaultPackChannel
+		}
+	}
+
+	manifest.pointChannel(channel, mv)
+
+	mp.Log().Infof("writing manifest to %s", manifestPath)
+
+	return manifest.WriteToFile(manifestPath)
+}
+
+// addVersion adds the provided version to the manifest, replacing any existing
+// entry of the same version
+func (m *Manifest) addVersion(mv ManifestVersion) {
+	for i, version := range m.Versions {
+		if version.Version == mv.Version {
+			m.Versions[i] = mv
+			return
+		}
+	}
+
+	m.Versions = append(m.Versions, mv)
+}
+
+// pointChannel updates the channel with the given name such that it points to
+// the provided version, creating the channel if necessary.  A newly created
+// channel is made the default if no other channel is.
+func (m *Manifest) pointChannel(name string, mv ManifestVersion) {
+	for i, channel := range m.Channels {
+		if channel.Name == name {
+			m.Channels[i].Latest = mv.Version
+			m.Channels[i].Resource = mv.Resource
+			m.Channels[i].Sha256 = mv.Sha256
+			return
+		}
+	}
+
+	_, err := m.DefaultChannel()
+
+	m.Channels = append(m.Channels, ManifestChannel{
+		Name:     name,
+		Default:  err != nil,
+		Latest:   mv.Version,
+		Resource: mv.Resource,
+		Sha256:   mv.Sha256,
+	})
+}
+
+// writeArchive creates a gzip-compressed tarball at dst of the file or
+// directory at src with all entries placed under the prefix directory.  The
+// archive is reproducible: entries are written in lexical order and ownership,
+// timestamps and permissions are normalized.  The hex-encoded SHA256 checksum
+// of the resulting archive is returned.
+func writeArchive(src, prefix, dst string) (string, error) {
+	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+	gw := gzip.NewWriter(io.MultiWriter(f, h))
+	tw := tar.NewWriter(gw)
+
+	src = filepath.Clean(src)
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+
+	root := src
+	if !info.IsDir() {
+		root = filepath.Dir(src)
+	}
+
+	if err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip version control metadata which does not belong in a release
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		// Do not archive the archive itself
+		if path == dst {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		name := prefix
+		if rel != "." {
+			name = prefix + "/" + filepath.ToSlash(rel)
+		}
+
+		return writeArchiveEntry(tw, path, name, info)
+	}); err != nil {
+		return "", err
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeArchiveEntry writes a single normalized entry to the tarball
+func writeArchiveEntry(tw *tar.Writer, path, name string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.ModTime = time.Unix(0, 0)
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Format = tar.FormatPAX
+
+	switch {
+	case info.IsDir():
+		header.Name += "/"
+		header.Mode = 0o755
+	case info.Mode()&0o111 != 0:
+		header.Mode = 0o755
+	default:
+		header.Mode = 0o644
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+)
+
+func packTestLibrary(t *testing.T, source, outdir, version string) {
+	t.Helper()
+
+	opts, err := pack.NewPackageOptions(
+		pack.WithName("libfoo"),
+		pack.WithType(unikraft.ComponentTypeLib),
+		pack.WithVersion(version),
+		pack.WithSource(source),
+		pack.WithLogger(logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))),
+		WithPackOutputDir(outdir),
+		WithPackResourceURL("https://example.com/releases/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPackageFromOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Pack(); err != nil {
+		t.Fatalf("could not pack: %v", err)
+	}
+}
+
+func TestPackArchive(t *testing.T) {
+	source := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(source, "Makefile.uk"), []byte("$(eval $(call addlib,libfoo))\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(source, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	packTestLibrary(t, source, outdir, "0.1.0")
+
+	tarball := filepath.Join(outdir, "libfoo-0.1.0.tar.gz")
+	first, err := ioutil.ReadFile(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Touching the source should not change the resulting archive
+	if err := os.Chtimes(filepath.Join(source, "Makefile.uk"), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	packTestLibrary(t, source, outdir, "0.1.0")
+
+	second, err := ioutil.ReadFile(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("expected archive to be reproducible")
+	}
+
+	manifest, err := NewManifestFromFile(filepath.Join(outdir, "libfoo.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Versions) != 1 {
+		t.Fatalf("expected 1 version, got %d", len(manifest.Versions))
+	}
+
+	sum := sha256.Sum256(second)
+	if manifest.Versions[0].Sha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected checksum: %s", manifest.Versions[0].Sha256)
+	}
+
+	if manifest.Versions[0].Resource != "https://example.com/releases/libfoo-0.1.0.tar.gz" {
+		t.Errorf("unexpected resource: %s", manifest.Versions[0].Resource)
+	}
+
+	packTestLibrary(t, source, outdir, "0.2.0")
+
+	manifest, err = NewManifestFromFile(filepath.Join(outdir, "libfoo.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(manifest.Versions))
+	}
+
+	channel, err := manifest.DefaultChannel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if channel.Name != DefaultPackChannel || channel.Latest != "0.2.0" {
+		t.Errorf("unexpected default channel: %+v", channel)
+	}
+}
@@ -33,14 +33,16 @@ package manifest
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"kraftkit.sh/archive"
 	"kraftkit.sh/pack"
+	"kraftkit.sh/store"
 	"kraftkit.sh/unikraft"
 )
 
@@ -73,55 +75,59 @@ func (mp ManifestPackage) pullArchive(popts *pack.PullPackageOptions) error {
 		return err
 	}
 
+	// Without a cache path the partial download would be written to the current
+	// working directory
+	if len(cache) == 0 {
+		return fmt.Errorf("could not pull %s: no cache path for resource", manifest.Name)
+	}
+
+	// The resource and checksum of the package take precedence over those of
+	// the manifest as they may have been pinned, e.g. by a lockfile
+	if len(mp.RemoteLocation) > 0 {
+		resource = mp.RemoteLocation
+	}
+	if len(mp.Sha256) > 0 {
+		checksum = mp.Sha256
+	}
+
 	pp := &pullProgressArchive{
 		onProgress: popts.OnProgress,
 		total:      0,
 		downloaded: 0,
 	}
 
-	if f, err := os.Stat(cache); !popts.UseCache() || err != nil || f.Size() == 0 {
-		// Get the total size of the remote resource.  Note: this fails for GitHub
-		// archives as Content-Length is, for some reason, always set to 0.
-		res, err := http.Head(resource)
-		if err != nil {
-			return fmt.Errorf("could not perform HEAD request on resource: %v", err)
-		} else if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("recieved HTTP error code %d on resource", res.StatusCode)
-		} else if res.ContentLength <= 0 {
-			popts.Log().Warnf("could not determine package size before pulling")
-			pp.total = 0
-		} else {
-			pp.total = int(res.ContentLength)
+	useCache := popts.UseCache()
+	if f, err := os.Stat(cache); err != nil || f.Size() == 0 {
+		useCache = false
+	}
+
+	// Only re-use a cached resource if it matches the expected checksum
+	if useCache && len(checksum) > 0 && popts.CalculateChecksum() {
+		if sum, err := sha256File(cache); err != nil || !checksumMatches(checksum, sum) {
+			popts.Log().Debugf("cached resource does not match checksum, pulling again")
+			useCache = false
 		}
+	}
 
-		// Create a temporary partial of the destination path of the resource
+	if !useCache {
+		// Download into a partial of the destination path of the resource which
+		// is kept between attempts such that interrupted downloads can be resumed
 		tmpCache := cache + ".part"
 		if err := os.MkdirAll(filepath.Dir(tmpCache), 0o755); err != nil {
 			return fmt.Errorf("could not create parent directorires: %v", err)
 		}
 
-		f, err := os.OpenFile(tmpCache, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o755)
-		if err != nil {
-			return fmt.Errorf("could not create cache file: %v", err)
-		}
-
-		defer f.Close()
-
-		// Perform the request to actually retrieve the file
-		res, err = http.Get(resource)
-		if err != nil {
-			return fmt.Errorf("could not initialize GET request to download package: %v", err)
-		} else if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("received non-200 HTTP status code when attemptingn to download package: %v", err)
-		}
-
-		defer res.Body.Close()
-
-		// With io.TeeReader we are able to pass in the implementing io.Writer such
-		// that we are able to call the onProgress method
-		_, err = io.Copy(f, io.TeeReader(res.Body, pp))
-		if err != nil {
-			return err
+		// Resources which have been pushed to a local directory index are read
+		// directly from disk
+		if local := strings.TrimPrefix(resource, "file://"); filepath.IsAbs(local) {
+			if err := copyLocalResource(local, tmpCache, pp); err != nil {
+				return err
+			}
+		} else {
+			locations := append([]string{resource}, mirrorLocations(manifest, resource, cache)...)
+			if err := downloadResource(popts, manifest.Auths(), locations, tmpCache, pp); err != nil {
+				return err
+			}
 		}
 
 		if popts.CalculateChecksum() {
@@ -131,19 +137,15 @@ func (mp ManifestPackage) pullArchive(popts *pack.PullPackageOptions) error {
 				popts.Log().Warnf("manifest does not specify checksum!")
 			} else {
 
-				f, err := os.Open(tmpCache)
+				sum, err := sha256File(tmpCache)
 				if err != nil {
 					return fmt.Errorf("could not perform checksum: %v", err)
 				}
-				defer f.Close()
-
-				h := sha256.New()
-				if _, err := io.Copy(h, f); err != nil {
-					return fmt.Errorf("could not perform checksum: %v", err)
-				}
 
-				if checksum != string(h.Sum(nil)) {
-					return fmt.Errorf("checksum of package does not match")
+				if !checksumMatches(checksum, sum) {
+					// Do not resume from a corrupt partial on the next attempt
+					os.Remove(tmpCache)
+					return fmt.Errorf("checksum of package does not match: expected %s but got %s", checksum, sum)
 				}
 
 				popts.Log().Debugf("checksum OK")
@@ -156,6 +158,12 @@ func (mp ManifestPackage) pullArchive(popts *pack.PullPackageOptions) error {
 		}
 	}
 
+	// Record the checksum of the resource which was actually retrieved
+	mp.PackageOptions.Sha256, err = sha256File(cache)
+	if err != nil {
+		return fmt.Errorf("could not perform checksum: %v", err)
+	}
+
 	local := cache
 	if len(popts.Workdir()) > 0 {
 		local, err = unikraft.PlaceComponent(
@@ -168,8 +176,28 @@ func (mp ManifestPackage) pullArchive(popts *pack.PullPackageOptions) error {
 		}
 	}
 
-	// Unarchive the package to the given workdir
-	if len(local) > 0 {
+	// Place the package in the given workdir via the content-addressed store
+	// which allows the unpacked sources to be shared between projects
+	if len(popts.Workdir()) > 0 && len(manifest.sourcesRootDir) > 0 {
+		st := store.NewStore(manifest.sourcesRootDir)
+
+		if err := st.Add(mp.PackageOptions.Sha256, func(dir string) error {
+			return archive.Unarchive(cache, dir,
+				archive.StripComponents(1),
+			)
+		}); err != nil {
+			return fmt.Errorf("could not unarchive: %v", err)
+		}
+
+		if err := st.Link(mp.PackageOptions.Sha256, local); err != nil {
+			return fmt.Errorf("could not place component package: %v", err)
+		}
+
+		if err := st.Register(popts.Workdir()); err != nil {
+			popts.Log().Warnf("could not register project with source store: %v", err)
+		}
+	} else if len(local) > 0 {
+		// Unarchive the package to the given workdir
 		if err := archive.Unarchive(cache, local,
 			archive.StripComponents(1),
 		); err != nil {
@@ -181,3 +209,31 @@ func (mp ManifestPackage) pullArchive(popts *pack.PullPackageOptions) error {
 
 	return nil
 }
+
+// sha256File returns the hex-encoded SHA256 checksum of the file at the
+// provided path
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumMatches compares an expected hex-encoded SHA256 checksum, as found in
+// a manifest, with a calculated one.  The comparison is case-insensitive and
+// tolerates the optional "sha256:" digest prefix.
+func checksumMatches(expected, actual string) bool {
+	expected = strings.TrimPrefix(strings.TrimSpace(expected), "sha256:")
+	actual = strings.TrimPrefix(strings.TrimSpace(actual), "sha256:")
+
+	return len(expected) > 0 && strings.EqualFold(expected, actual)
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/pack"
+)
+
+func TestPullArchivePinned(t *testing.T) {
+	source := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(source, "Makefile.uk"), []byte("$(eval $(call addlib,libfoo))\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	packTestLibrary(t, source, outdir, "0.1.0")
+
+	log := logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))
+
+	pull := func(opts ...pack.PackageOption) (pack.Package, error) {
+		manifest, err := NewManifestFromFile(filepath.Join(outdir, "libfoo.yaml"),
+			WithSourcesRootDir(t.TempDir()),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		manifest.Versions[0].Resource = filepath.Join(outdir, "libfoo-0.1.0.tar.gz")
+
+		p, err := NewPackageWithVersion(manifest, "0.1.0", pack.WithLogger(log))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Pin the package as is done when honouring a lockfile
+		if err := p.ApplyOptions(opts...); err != nil {
+			t.Fatal(err)
+		}
+
+		return p, p.Pull(
+			pack.WithPullWorkdir(t.TempDir()),
+			pack.WithPullLogger(log),
+			pack.WithPullChecksum(true),
+			pack.WithPullCache(true),
+		)
+	}
+
+	p, err := pull()
+	if err != nil {
+		t.Fatalf("could not pull: %v", err)
+	}
+
+	sum, err := sha256File(filepath.Join(outdir, "libfoo-0.1.0.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Options().Sha256 != sum {
+		t.Errorf("expected checksum %s to be recorded, got %s", sum, p.Options().Sha256)
+	}
+
+	if p.Options().Resolved != "0.1.0" {
+		t.Errorf("unexpected resolved version: %s", p.Options().Resolved)
+	}
+
+	if _, err := pull(pack.WithSha256(sum)); err != nil {
+		t.Errorf("could not pull with matching pinned checksum: %v", err)
+	}
+
+	if _, err := pull(pack.WithSha256("deadbeef")); err == nil {
+		t.Errorf("expected error when pinned checksum does not match")
+	}
+
+	if _, err := pull(pack.WithRemoteLocation(filepath.Join(outdir, "missing.tar.gz"))); err == nil {
+		t.Errorf("expected error when pinned resource does not exist")
+	}
+}
+
+func TestPullArchiveWithoutCache(t *testing.T) {
+	source := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(source, "Makefile.uk"), []byte("\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	packTestLibrary(t, source, outdir, "0.1.0")
+
+	// Without a sources root directory, the resource has no cache path
+	manifest, err := NewManifestFromFile(filepath.Join(outdir, "libfoo.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest.Versions[0].Resource = filepath.Join(outdir, "libfoo-0.1.0.tar.gz")
+
+	log := logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))
+
+	p, err := NewPackageWithVersion(manifest, "0.1.0", pack.WithLogger(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Pull(pack.WithPullLogger(log)); err == nil {
+		t.Errorf("expected error without cache path")
+	}
+
+	if _, err := os.Stat(".part"); !os.IsNotExist(err) {
+		t.Errorf("partial download was written to the working directory")
+	}
+}
@@ -34,8 +34,6 @@ package manifest
 import (
 	"fmt"
 
-	"github.com/libgit2/git2go/v31"
-
 	"kraftkit.sh/pack"
 	"kraftkit.sh/unikraft"
 )
@@ -58,33 +56,18 @@ func (mp ManifestPackage) pullGit(popts *pack.PullPackageOptions) error {
 		return fmt.Errorf("requesting Git with empty repository in manifest")
 	}
 
-	copts := &git.CloneOptions{
-		FetchOptions: &git.FetchOptions{
-			RemoteCallbacks: git.RemoteCallbacks{
-				TransferProgressCallback: func(stats git.TransferProgress) git.ErrorCode {
-					popts.OnProgress(float64(stats.IndexedObjects) / float64(stats.TotalObjects))
-					return 0
-				},
-			},
-		},
-		CheckoutOpts: &git.CheckoutOptions{
-			Strategy: git.CheckoutSafe,
-		},
-		CheckoutBranch: mp.Options().Version,
-		Bare:           false,
-	}
+	repo := manifest.gitRepo()
 
-	// TODO: Authetication.  This needs to be handled via the authentication
-	// callback provided by CloneOptions.
-	// Attribute any supplied authentication, supplied with hostname as key
-	// u, err := url.Parse(manifest.GitRepo)
-	// if err != nil {
-	// 	return fmt.Errorf("could not parse Git repository: %v", err)
-	// }
+	refs, err := listGitRefs(repo, manifest.Auths())
+	if err != nil {
+		return fmt.Errorf("could not list references of %s: %v", repo, err)
+	}
 
-	// if auth, ok := manifest.auths[u.Host]; ok {
-	// 	...
-	// }
+	// Resolve the channel, tag or commit of the package to an exact reference
+	checkout, err := resolveGitCheckout(mp.Options().Version, mp.Options().Resolved, refs)
+	if err != nil {
+		return err
+	}
 
 	local, err := unikraft.PlaceComponent(
 		popts.Workdir(),
@@ -95,14 +78,37 @@ func (mp ManifestPackage) pullGit(popts *pack.PullPackageOptions) error {
 		return fmt.Errorf("could not place component package: %s", err)
 	}
 
-	mp.Log().Infof("cloning %s into %s", manifest.GitRepo, local)
+	// An existing checkout is updated in place rather than cloned anew
+	clone, err := openGitRepository(local, repo, &gitOptions{
+		auths:      manifest.Auths(),
+		onProgress: popts.OnProgress,
+		log:        mp.Log(),
+	})
+	if err != nil {
+		return err
+	}
+
+	defer clone.Close()
 
-	_, err = git.Clone(manifest.GitRepo, local, copts)
+	mp.Log().Infof("fetching %s from %s", mp.Options().Version, repo)
+
+	if err := clone.Fetch(checkout); err != nil {
+		return fmt.Errorf("could not fetch repository: %v", err)
+	}
+
+	commit, err := clone.Checkout(checkout)
 	if err != nil {
-		return fmt.Errorf("could not clone repository: %v", err)
+		return err
 	}
 
-	mp.Log().Infof("successfulyl cloned %s into %s", manifest.GitRepo, local)
+	popts.OnProgress(1)
+
+	// Record the commit which was actually checked out
+	mp.PackageOptions.Resolved = commit
+	mp.PackageOptions.RemoteLocation = manifest.GitRepo
+	mp.PackageOptions.Sha256 = ""
+
+	mp.Log().Infof("successfully checked out %s into %s", commit, local)
 
 	return nil
 }
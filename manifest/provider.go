@@ -31,7 +31,10 @@
 
 package manifest
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type Provider interface {
 	// Manifests returns a slice of Manifests which can be returned by this
@@ -45,14 +48,29 @@ type Provider interface {
 // thus the return of NewProvider a compatible interface Provider able to gather
 // information about the manifest.
 func NewProvider(path string, mopts ...ManifestOption) (Provider, error) {
+	var serr *SignatureError
+
 	provider, err := NewManifestIndexProvider(path, mopts...)
 	if err == nil {
 		return provider, nil
+	} else if errors.As(err, &serr) {
+		return nil, err
 	}
 
 	provider, err = NewManifestProvider(path, mopts...)
 	if err == nil {
 		return provider, nil
+	} else if errors.As(err, &serr) {
+		return nil, err
+	}
+
+	// Sources which are not manifest documents, such as Git repositories, cannot
+	// carry a signature and are therefore rejected when signatures are required.
+	if len(trustedKeysFromOptions(mopts)) > 0 {
+		return nil, &SignatureError{
+			Path: path,
+			Err:  fmt.Errorf("source is not a signed manifest or manifest index"),
+		}
 	}
 
 	provider, err = NewGitHubProvider(path, mopts...)
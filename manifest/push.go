@@ -0,0 +1,427 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+)
+
+// pushTarget represents a destination which a manifest, its resources and the
+// index which references it can be pushed to.  All paths are relative to the
+// root of the destination and use forward slashes.
+type pushTarget interface {
+	// Read returns the contents of the file at the given path or an error
+	// satisfying os.IsNotExist if it does not exist.
+	Read(rel string) ([]byte, error)
+
+	// Write saves the contents of the reader to the given path.
+	Write(rel string, r io.Reader) error
+
+	// URL returns the location of the given path at the destination.
+	URL(rel string) string
+}
+
+// newPushTarget returns the pushTarget which is able to handle the provided
+// destination.  Remote destinations are accessed with the provided client and
+// authentication is looked up by the host of the destination within the
+// provided auths.
+func newPushTarget(destination string, client *http.Client, auths map[string]config.AuthConfig) (pushTarget, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse destination: %v", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		target := &httpPushTarget{
+			base:   strings.TrimSuffix(destination, "/"),
+			client: clientFor(client, auths, u.Host),
+		}
+
+		if auth, ok := auths[u.Host]; ok {
+			target.auth = &auth
+		}
+
+		return target, nil
+
+	case "file":
+		destination = u.Path
+		fallthrough
+
+	case "":
+		if err := os.MkdirAll(destination, 0o755); err != nil {
+			return nil, fmt.Errorf("could not create destination directory: %v", err)
+		}
+
+		abs, err := filepath.Abs(destination)
+		if err != nil {
+			return nil, err
+		}
+
+		return localPushTarget(abs), nil
+	}
+
+	return nil, fmt.Errorf("unsupported push destination: %s", destination)
+}
+
+// localPushTarget pushes to a directory on the host
+type localPushTarget string
+
+func (lpt localPushTarget) Read(rel string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(lpt), filepath.FromSlash(rel)))
+}
+
+func (lpt localPushTarget) Write(rel string, r io.Reader) error {
+	dst := filepath.Join(string(lpt), filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("could not create parent directories: %v", err)
+	}
+
+	// Write to a partial file first such that readers of the destination never
+	// observe an incomplete file
+	tmp := dst + ".part"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+func (lpt localPushTarget) URL(rel string) string {
+	return filepath.Join(string(lpt), filepath.FromSlash(rel))
+}
+
+// httpPushTarget pushes to a remote HTTP server which accepts PUT requests,
+// such as a WebDAV server
+type httpPushTarget struct {
+	base   string
+	auth   *config.AuthConfig
+	client *http.Client
+}
+
+func (hpt *httpPushTarget) do(method, rel string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, hpt.URL(rel), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if hpt.auth != nil {
+		if len(hpt.auth.User) > 0 {
+			req.SetBasicAuth(hpt.auth.User, hpt.auth.Token)
+		} else if len(hpt.auth.Token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+hpt.auth.Token)
+		}
+	}
+
+	return hpt.client.Do(req)
+}
+
+func (hpt *httpPushTarget) Read(rel string) ([]byte, error) {
+	res, err := hpt.do(http.MethodGet, rel, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %d error when retrieving: %s", res.StatusCode, hpt.URL(rel))
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+func (hpt *httpPushTarget) Write(rel string, r io.Reader) error {
+	// WebDAV servers require parent collections to exist before a resource can
+	// be created within them.  Servers which do not speak WebDAV, or on which the
+	// collection already exists, will respond with an error which is ignored.
+	dir := path.Dir(rel)
+	if dir != "." {
+		var parents []string
+		for ; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			parents = append([]string{dir}, parents...)
+		}
+
+		for _, parent := range parents {
+			if res, err := hpt.do("MKCOL", parent+"/", nil); err == nil {
+				res.Body.Close()
+			}
+		}
+	}
+
+	res, err := hpt.do(http.MethodPut, rel, r)
+	if err != nil {
+		return fmt.Errorf("could not upload %s: %v", rel, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("received %d error when uploading: %s", res.StatusCode, hpt.URL(rel))
+	}
+
+	return nil
+}
+
+func (hpt *httpPushTarget) URL(rel string) string {
+	if len(rel) == 0 {
+		return hpt.base
+	}
+
+	return hpt.base + "/" + rel
+}
+
+// Push uploads the manifest at the provided path, alongside any resources it
+// references which reside on the local host, to the destination set in the
+// options.  The index at the root of the destination is created or extended to
+// reference the manifest such that it can be used as a source for `kraft pkg`.
+func (mm ManifestManager) Push(source string, popts *pack.PushPackageOptions) error {
+	manifest, err := NewManifestFromFile(source)
+	if err != nil {
+		return fmt.Errorf("could not read manifest: %v", err)
+	}
+
+	var auths map[string]config.AuthConfig
+	if mm.opts.ConfigManager != nil {
+		auths = mm.opts.ConfigManager.Config.Auth
+	}
+
+	target, err := newPushTarget(popts.Destination(), popts.HTTPClient(), auths)
+	if err != nil {
+		return err
+	}
+
+	log := popts.Log()
+	if log == nil {
+		log = mm.opts.Log
+	}
+
+	dir := ""
+	if manifest.Type != unikraft.ComponentTypeCore {
+		dir = manifest.Type.Plural()
+	}
+
+	base := strings.TrimSuffix(popts.ResourceURL(), "/")
+	if len(base) == 0 {
+		base = target.URL("")
+	}
+
+	// Upload each locally available resource once, even if it is referenced by
+	// both a version and a channel
+	uploaded := map[string]string{}
+	upload := func(resource string) (string, error) {
+		local := strings.TrimPrefix(resource, "file://")
+		if !filepath.IsAbs(local) {
+			local = filepath.Join(filepath.Dir(source), local)
+		}
+
+		if f, err := os.Stat(local); err != nil || f.IsDir() {
+			return resource, nil
+		}
+
+		if remote, ok := uploaded[local]; ok {
+			return remote, nil
+		}
+
+		rel := path.Join(dir, filepath.Base(local))
+
+		f, err := os.Open(local)
+		if err != nil {
+			return "", err
+		}
+
+		defer f.Close()
+
+		log.Infof("pushing %s", rel)
+		if err := target.Write(rel, f); err != nil {
+			return "", err
+		}
+
+		uploaded[local] = base + "/" + rel
+		return uploaded[local], nil
+	}
+
+	for i, version := range manifest.Versions {
+		if manifest.Versions[i].Resource, err = upload(version.Resource); err != nil {
+			return err
+		}
+	}
+
+	for i, channel := range manifest.Channels {
+		if manifest.Channels[i].Resource, err = upload(channel.Resource); err != nil {
+			return err
+		}
+	}
+
+	filename := path.Join(dir, manifest.Name+".yaml")
+
+	// Retain the versions and channels which have previously been pushed
+	if raw, err := target.Read(filename); err == nil {
+		pushed, err := NewManifestFromBytes(raw)
+		if err != nil {
+			return fmt.Errorf("could not parse destination manifest: %v", err)
+		}
+
+		mergePushed(manifest, pushed)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("could not retrieve destination manifest: %v", err)
+	}
+
+	contents, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("pushing %s", filename)
+	if err := target.Write(filename, bytes.NewReader(contents)); err != nil {
+		return err
+	}
+
+	// Create or update the index at the root of the destination
+	index := &ManifestIndex{}
+	if raw, err := target.Read("index.yaml"); err == nil {
+		if index, err = NewManifestIndexFromBytes(raw); err != nil {
+			return fmt.Errorf("could not parse destination index: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("could not retrieve destination index: %v", err)
+	}
+
+	index.LastUpdated = time.Now()
+	entry := &Manifest{
+		Name:     manifest.Name,
+		Type:     manifest.Type,
+		Manifest: "./" + filename,
+	}
+
+	found := false
+	for i, existing := range index.Manifests {
+		if existing.Name == manifest.Name && existing.Type == manifest.Type {
+			index.Manifests[i] = entry
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		index.Manifests = append(index.Manifests, entry)
+	}
+
+	contents, err = yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("updating %s", target.URL("index.yaml"))
+
+	return target.Write("index.yaml", bytes.NewReader(contents))
+}
+
+// mergePushed extends the manifest with the versions and channels of the
+// previously pushed manifest.  Versions and channels of the same name are
+// replaced by those of the manifest being pushed.
+func mergePushed(manifest, pushed *Manifest) {
+	versions := pushed.Versions
+	for _, version := range manifest.Versions {
+		found := false
+		for i, existing := range versions {
+			if existing.Version == version.Version {
+				versions[i] = version
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			versions = append(versions, version)
+		}
+	}
+
+	// Only a single channel can be the default one
+	hasDefault := false
+	for _, channel := range manifest.Channels {
+		hasDefault = hasDefault || channel.Default
+	}
+
+	channels := pushed.Channels
+	for i := range channels {
+		if hasDefault {
+			channels[i].Default = false
+		}
+	}
+
+	for _, channel := range manifest.Channels {
+		found := false
+		for i, existing := range channels {
+			if existing.Name == channel.Name {
+				channels[i] = channel
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			channels = append(channels, channel)
+		}
+	}
+
+	manifest.Versions = versions
+	manifest.Channels = channels
+}
@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+)
+
+func newTestManifestManager(t *testing.T, auths map[string]config.AuthConfig) ManifestManager {
+	t.Helper()
+
+	cfg := &config.ConfigManager{Config: &config.Config{Auth: auths}}
+	opts, err := packmanager.NewPackageManagerOptions(nil,
+		packmanager.WithConfigManager(cfg),
+		packmanager.WithLogger(logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ManifestManager{opts: opts}
+}
+
+func TestPushLocal(t *testing.T) {
+	source := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(source, "Makefile.uk"), []byte("\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := t.TempDir()
+	packTestLibrary(t, source, outdir, "0.1.0")
+
+	dest := t.TempDir()
+	popts, err := pack.NewPushPackageOptions(
+		pack.WithPushDestination(dest),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm := newTestManifestManager(t, nil)
+	if err := mm.Push(filepath.Join(outdir, "libfoo.yaml"), popts); err != nil {
+		t.Fatalf("could not push: %v", err)
+	}
+
+	manifests, err := FindManifestsFromSource(filepath.Join(dest, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifests) != 1 || manifests[0].Name != "libfoo" {
+		t.Fatalf("unexpected manifests in index: %v", manifests)
+	}
+
+	// The remote resource was not found locally and is therefore left untouched
+	if resource := manifests[0].Versions[0].Resource; resource != "https://example.com/releases/libfoo-0.1.0.tar.gz" {
+		t.Errorf("unexpected resource: %s", resource)
+	}
+}
+
+func TestPushMergesVersions(t *testing.T) {
+	source := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(source, "Makefile.uk"), []byte("\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	popts, err := pack.NewPushPackageOptions(
+		pack.WithPushDestination(dest),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm := newTestManifestManager(t, nil)
+
+	for _, version := range []string{"0.1.0", "0.2.0"} {
+		outdir := t.TempDir()
+		packTestLibrary(t, source, outdir, version)
+
+		if err := mm.Push(filepath.Join(outdir, "libfoo.yaml"), popts); err != nil {
+			t.Fatalf("could not push %s: %v", version, err)
+		}
+	}
+
+	manifest, err := NewManifestFromFile(filepath.Join(dest, "libs", "libfoo.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	for _, version := range manifest.Versions {
+		versions = append(versions, version.Version)
+	}
+
+	if strings.Join(versions, ",") != "0.1.0,0.2.0" {
+		t.Errorf("expected both pushed versions, got: %v", versions)
+	}
+}
+
+// headerTransport sets a header on every request to tell apart requests which
+// are performed with a particular client
+type headerTransport struct {
+	name, value string
+}
+
+func (ht headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(ht.name, ht.value)
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPushHTTP(t *testing.T) {
+	var mu sync.Mutex
+	files := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only requests made with the provided client are accepted
+		if r.Header.Get("X-Test-Client") != "push" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if user, token, ok := r.BasicAuth(); !ok || user != "user" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			contents, ok := files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, contents)
+		case http.MethodPut:
+			contents, _ := io.ReadAll(r.Body)
+			files[r.URL.Path] = string(contents)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	manifest := &Manifest{
+		Name: "libfoo",
+		Type: "lib",
+		Versions: []ManifestVersion{{
+			Version:  "0.1.0",
+			Resource: "libfoo-0.1.0.tar.gz",
+		}},
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "libfoo-0.1.0.tar.gz"), []byte("tarball"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manifest.WriteToFile(filepath.Join(dir, "libfoo.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	popts, err := pack.NewPushPackageOptions(
+		pack.WithPushDestination(server.URL+"/registry"),
+		pack.WithPushHTTPClient(&http.Client{
+			Transport: headerTransport{name: "X-Test-Client", value: "push"},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	mm := newTestManifestManager(t, map[string]config.AuthConfig{
+		host: {User: "user", Token: "secret", VerifySSL: true},
+	})
+
+	if err := mm.Push(filepath.Join(dir, "libfoo.yaml"), popts); err != nil {
+		t.Fatalf("could not push: %v", err)
+	}
+
+	if files["/registry/libs/libfoo-0.1.0.tar.gz"] != "tarball" {
+		t.Errorf("resource was not uploaded: %v", files)
+	}
+
+	if !strings.Contains(files["/registry/libs/libfoo.yaml"], server.URL+"/registry/libs/libfoo-0.1.0.tar.gz") {
+		t.Errorf("manifest does not reference uploaded resource: %s", files["/registry/libs/libfoo.yaml"])
+	}
+
+	if !strings.Contains(files["/registry/index.yaml"], "./libs/libfoo.yaml") {
+		t.Errorf("index does not reference manifest: %s", files["/registry/index.yaml"])
+	}
+}
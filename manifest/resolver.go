@@ -0,0 +1,425 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kraftkit.sh/unikraft"
+)
+
+// Requirement is a request for a component whose version must satisfy a
+// constraint.  The constraint is either a channel, an exact version or a range
+// of versions, e.g. `>=0.10.0 <0.12.0`.  An empty constraint accepts any
+// version.
+type Requirement struct {
+	Type       unikraft.ComponentType
+	Name       string
+	Constraint string
+
+	// RequiredBy describes where the requirement originates from and is used to
+	// explain conflicts
+	RequiredBy string
+}
+
+func (r Requirement) String() string {
+	constraint := r.Constraint
+	if len(constraint) == 0 {
+		constraint = "*"
+	}
+
+	if len(r.RequiredBy) == 0 {
+		return constraint
+	}
+
+	return fmt.Sprintf("%s (required by %s)", constraint, r.RequiredBy)
+}
+
+// Resolution is the version of a manifest which was selected by the Resolver
+type Resolution struct {
+	Manifest *Manifest
+
+	// Version is either a version or a channel of the manifest
+	Version string
+}
+
+// ConflictError explains why no version of a component satisfies all the
+// requirements placed upon it
+type ConflictError struct {
+	Type         unikraft.ComponentType
+	Name         string
+	Requirements []Requirement
+	Available    []string
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "cannot resolve %s/%s: no version satisfies all requirements:", e.Type, e.Name)
+
+	for _, req := range e.Requirements {
+		fmt.Fprintf(&b, "\n  %s", req)
+	}
+
+	if len(e.Available) > 0 {
+		fmt.Fprintf(&b, "\navailable versions: %s", strings.Join(e.Available, ", "))
+	} else {
+		fmt.Fprintf(&b, "\nno versions available")
+	}
+
+	return b.String()
+}
+
+// Resolver computes a set of component versions which satisfies the
+// requirements of a project as well as the dependencies and compatible core
+// versions declared by the manifests of its components
+type Resolver struct {
+	manifests []*Manifest
+}
+
+// NewResolver prepares a Resolver for the provided manifests
+func NewResolver(manifests []*Manifest) *Resolver {
+	return &Resolver{
+		manifests: manifests,
+	}
+}
+
+// Resolve returns a consistent version for every required component and their
+// transitive dependencies.  Newer versions are preferred and, on conflict,
+// older versions are attempted before a ConflictError is returned.
+func (r *Resolver) Resolve(reqs ...Requirement) ([]Resolution, error) {
+	selected, err := r.resolve(map[string]candidate{}, map[string][]Requirement{}, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolutions []Resolution
+	for _, c := range selected {
+		resolutions = append(resolutions, Resolution{
+			Manifest: c.manifest,
+			Version:  c.version,
+		})
+	}
+
+	sort.Slice(resolutions, func(i, j int) bool {
+		a, b := resolutions[i].Manifest, resolutions[j].Manifest
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		return a.Name < b.Name
+	})
+
+	return resolutions, nil
+}
+
+// candidate is a version or channel of a manifest which may be selected
+type candidate struct {
+	manifest *Manifest
+	version  string
+
+	// release is the version the candidate represents, which for channels is
+	// their latest version if known
+	release *ManifestVersion
+}
+
+// resolve selects a candidate for the first requirement of the queue and
+// recurses with its dependencies appended to the remaining requirements,
+// backtracking when a conflict arises
+func (r *Resolver) resolve(selected map[string]candidate, constraints map[string][]Requirement, queue []Requirement) (map[string]candidate, error) {
+	if len(queue) == 0 {
+		return selected, nil
+	}
+
+	req, queue := queue[0], queue[1:]
+
+	manifest, err := r.find(req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := string(manifest.Type) + "/" + manifest.Name
+
+	// Copy the constraints such that they remain intact when backtracking
+	constraints = copyConstraints(constraints)
+	constraints[key] = append(constraints[key], req)
+
+	if c, ok := selected[key]; ok {
+		if !c.satisfies(req.Constraint) {
+			return nil, r.conflict(manifest, constraints[key])
+		}
+
+		return r.resolve(selected, constraints, queue)
+	}
+
+	candidates := r.candidates(manifest, constraints[key])
+	if len(candidates) == 0 {
+		return nil, r.conflict(manifest, constraints[key])
+	}
+
+	var first error
+	for _, c := range candidates {
+		next := make(map[string]candidate, len(selected)+1)
+		for k, v := range selected {
+			next[k] = v
+		}
+
+		next[key] = c
+
+		result, err := r.resolve(next, constraints, append(append([]Requirement{}, queue...), c.dependencies()...))
+		if err == nil {
+			return result, nil
+		}
+
+		// The conflict of the preferred candidate is the most relevant one
+		if first == nil {
+			first = err
+		}
+	}
+
+	return nil, first
+}
+
+// find returns the manifest for the requirement.  When the type of the
+// requirement is unknown, the name must be unambiguous.
+func (r *Resolver) find(req Requirement) (*Manifest, error) {
+	var found []*Manifest
+
+	for _, manifest := range r.manifests {
+		if manifest.Name != req.Name {
+			continue
+		}
+
+		if len(req.Type) > 0 && req.Type != unikraft.ComponentTypeUnknown && manifest.Type != req.Type {
+			continue
+		}
+
+		found = append(found, manifest)
+	}
+
+	name := req.Name
+	if len(req.Type) > 0 && req.Type != unikraft.ComponentTypeUnknown {
+		name = string(req.Type) + "/" + req.Name
+	}
+
+	switch {
+	case len(found) == 0 && len(req.RequiredBy) > 0:
+		return nil, fmt.Errorf("could not find %s required by %s", name, req.RequiredBy)
+	case len(found) == 0:
+		return nil, fmt.Errorf("could not find %s", name)
+	case len(found) > 1:
+		return nil, fmt.Errorf("ambiguous component %s, specify its type", name)
+	}
+
+	return found[0], nil
+}
+
+// candidates returns the versions and channels of the manifest which satisfy
+// all the requirements, in order of preference: the default channel when it is
+// acceptable, then versions from newest to oldest and finally other channels
+func (r *Resolver) candidates(manifest *Manifest, reqs []Requirement) []candidate {
+	var all []candidate
+
+	releases := make(map[string]*ManifestVersion)
+	for i := range manifest.Versions {
+		releases[manifest.Versions[i].Version] = &manifest.Versions[i]
+	}
+
+	var channels []candidate
+	for _, channel := range manifest.Channels {
+		c := candidate{
+			manifest: manifest,
+			version:  channel.Name,
+			release:  releases[channel.Latest],
+		}
+
+		if channel.Default {
+			all = append(all, c)
+		} else {
+			channels = append(channels, c)
+		}
+	}
+
+	versions := make([]candidate, 0, len(manifest.Versions))
+	for i := range manifest.Versions {
+		versions = append(versions, candidate{
+			manifest: manifest,
+			version:  manifest.Versions[i].Version,
+			release:  &manifest.Versions[i],
+		})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i].version, versions[j].version) > 0
+	})
+
+	all = append(all, versions...)
+	all = append(all, channels...)
+
+	var candidates []candidate
+	for _, c := range all {
+		ok := true
+		for _, req := range reqs {
+			if !c.satisfies(req.Constraint) {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates
+}
+
+// conflict produces the explanation of why no candidate of the manifest is
+// acceptable
+func (r *Resolver) conflict(manifest *Manifest, reqs []Requirement) error {
+	return &ConflictError{
+		Type:         manifest.Type,
+		Name:         manifest.Name,
+		Requirements: reqs,
+		Available:    availableVersions(manifest),
+	}
+}
+
+// availableVersions lists the versions of the manifest from oldest to newest,
+// followed by its channels and the versions they represent
+func availableVersions(manifest *Manifest) []string {
+	var available []string
+	for _, version := range manifest.Versions {
+		available = append(available, version.Version)
+	}
+
+	sort.SliceStable(available, func(i, j int) bool {
+		return compareVersions(available[i], available[j]) < 0
+	})
+
+	for _, channel := range manifest.Channels {
+		if len(channel.Latest) > 0 {
+			available = append(available, fmt.Sprintf("%s (%s)", channel.Name, channel.Latest))
+		} else {
+			available = append(available, channel.Name)
+		}
+	}
+
+	return available
+}
+
+// satisfies returns whether the candidate is acceptable for the constraint.
+// Channels are compared by name or by their latest version.
+func (c candidate) satisfies(constraint string) bool {
+	constraint = strings.TrimSpace(constraint)
+	if len(constraint) == 0 || constraint == c.version {
+		return true
+	}
+
+	// A channel requested by name is satisfied by its latest version
+	for _, channel := range c.manifest.Channels {
+		if channel.Name == constraint {
+			return len(channel.Latest) > 0 && channel.Latest == c.resolved()
+		}
+	}
+
+	cons, err := NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+
+	return cons.Check(c.resolved())
+}
+
+// resolved returns the version which the candidate represents
+func (c candidate) resolved() string {
+	if c.release != nil {
+		return c.release.Version
+	}
+
+	for _, channel := range c.manifest.Channels {
+		if channel.Name == c.version && len(channel.Latest) > 0 {
+			return channel.Latest
+		}
+	}
+
+	return c.version
+}
+
+// dependencies returns the requirements declared by the candidate
+func (c candidate) dependencies() []Requirement {
+	if c.release == nil {
+		return nil
+	}
+
+	requiredBy := fmt.Sprintf("%s/%s@%s", c.manifest.Type, c.manifest.Name, c.version)
+
+	var reqs []Requirement
+
+	if len(c.release.Unikraft) > 0 {
+		reqs = append(reqs, Requirement{
+			Type:       unikraft.ComponentTypeCore,
+			Name:       "unikraft",
+			Constraint: c.release.Unikraft,
+			RequiredBy: requiredBy,
+		})
+	}
+
+	names := make([]string, 0, len(c.release.Dependencies))
+	for name := range c.release.Dependencies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		reqs = append(reqs, Requirement{
+			Type:       unikraft.ComponentTypeLib,
+			Name:       name,
+			Constraint: c.release.Dependencies[name],
+			RequiredBy: requiredBy,
+		})
+	}
+
+	return reqs
+}
+
+func copyConstraints(constraints map[string][]Requirement) map[string][]Requirement {
+	next := make(map[string][]Requirement, len(constraints))
+	for k, v := range constraints {
+		next[k] = append([]Requirement{}, v...)
+	}
+
+	return next
+}
@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+)
+
+func testResolverManifests() []*Manifest {
+	return []*Manifest{
+		{
+			Name: "unikraft",
+			Type: unikraft.ComponentTypeCore,
+			Channels: []ManifestChannel{
+				{Name: "stable", Default: true, Latest: "0.11.0"},
+			},
+			Versions: []ManifestVersion{
+				{Version: "0.9.0"},
+				{Version: "0.10.0"},
+				{Version: "0.11.0"},
+			},
+		},
+		{
+			Name: "musl",
+			Type: unikraft.ComponentTypeLib,
+			Versions: []ManifestVersion{
+				{Version: "1.0.0", Unikraft: ">=0.9.0"},
+				{Version: "1.1.0", Unikraft: ">=0.11.0"},
+			},
+		},
+		{
+			Name: "lwip",
+			Type: unikraft.ComponentTypeLib,
+			Versions: []ManifestVersion{
+				{Version: "2.1.0", Unikraft: ">=0.9.0 <0.11.0", Dependencies: map[string]string{"musl": ">=1.0.0"}},
+				{Version: "2.2.0", Unikraft: ">=0.11.0", Dependencies: map[string]string{"musl": ">=1.1.0"}},
+			},
+		},
+	}
+}
+
+func resolved(t *testing.T, resolutions []Resolution) map[string]string {
+	t.Helper()
+
+	versions := make(map[string]string)
+	for _, res := range resolutions {
+		versions[res.Manifest.Name] = res.Version
+	}
+
+	return versions
+}
+
+func TestResolverDependencies(t *testing.T) {
+	resolutions, err := NewResolver(testResolverManifests()).Resolve(
+		Requirement{Type: unikraft.ComponentTypeLib, Name: "lwip"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolved(t, resolutions)
+	want := map[string]string{"unikraft": "stable", "lwip": "2.2.0", "musl": "1.1.0"}
+	for name, version := range want {
+		if got[name] != version {
+			t.Errorf("expected %s %s, got %s", name, version, got[name])
+		}
+	}
+}
+
+func TestResolverBacktrack(t *testing.T) {
+	// Pinning an older core requires older versions of the libraries
+	resolutions, err := NewResolver(testResolverManifests()).Resolve(
+		Requirement{Type: unikraft.ComponentTypeCore, Name: "unikraft", Constraint: "0.10.0"},
+		Requirement{Type: unikraft.ComponentTypeLib, Name: "lwip"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolved(t, resolutions)
+	want := map[string]string{"unikraft": "0.10.0", "lwip": "2.1.0", "musl": "1.0.0"}
+	for name, version := range want {
+		if got[name] != version {
+			t.Errorf("expected %s %s, got %s", name, version, got[name])
+		}
+	}
+}
+
+func TestResolverConflict(t *testing.T) {
+	_, err := NewResolver(testResolverManifests()).Resolve(
+		Requirement{Type: unikraft.ComponentTypeCore, Name: "unikraft", Constraint: "stable", RequiredBy: "Kraftfile"},
+		Requirement{Type: unikraft.ComponentTypeLib, Name: "lwip", Constraint: "2.1.0", RequiredBy: "Kraftfile"},
+	)
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	if conflict.Name != "unikraft" {
+		t.Errorf("expected conflict on unikraft, got %s", conflict.Name)
+	}
+
+	for _, want := range []string{
+		"stable (required by Kraftfile)",
+		">=0.9.0 <0.11.0 (required by lib/lwip@2.1.0)",
+		"available versions: 0.9.0, 0.10.0, 0.11.0, stable (0.11.0)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in explanation:\n%s", want, err)
+		}
+	}
+
+	if _, err := NewResolver(testResolverManifests()).Resolve(
+		Requirement{Name: "missing", RequiredBy: "Kraftfile"},
+	); err == nil {
+		t.Errorf("expected error for missing component")
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		ok         bool
+	}{
+		{">=0.10.0 <0.12.0", "0.10.0", true},
+		{">=0.10.0 <0.12.0", "0.11.3", true},
+		{">=0.10.0 <0.12.0", "0.12.0", false},
+		{">= 0.10, < 0.12", "v0.11.0", true},
+		{"0.10.0", "0.10", true},
+		{"!=0.10.0", "0.10.0", false},
+		{">=1.0.0", "1.0.0-rc1", false},
+		{"abcdef", "abcdef", true},
+		{">=1.0.0", "abcdef", false},
+		{"^0.10", "0.10.4", true},
+		{"^0.10", "0.11.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"^0.10", "0.11.0-rc1", false},
+		{"~0.10.1", "0.11.0-rc1", false},
+		{">=0.11.0-rc1", "0.11.0-rc2", true},
+		{">=0.11.0-rc1", "0.12.0-rc1", false},
+		{"0.11.0-rc1", "0.11.0-rc1", true},
+	}
+
+	for _, test := range tests {
+		c, err := NewConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.constraint, err)
+		}
+
+		if c.Check(test.version) != test.ok {
+			t.Errorf("expected %q satisfies %q to be %v", test.version, test.constraint, test.ok)
+		}
+	}
+
+	if _, err := NewConstraint(">=abc"); err == nil {
+		t.Errorf("expected error for non-semantic range")
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	manifest := testResolverManifests()[0]
+	manifest.Channels = append(manifest.Channels, ManifestChannel{Name: "staging", Latest: "0.12.0"})
+	manifest.Versions = append(manifest.Versions, ManifestVersion{Version: "0.10.3", Type: ManifestVersionSemver})
+
+	for constraint, want := range map[string]string{
+		"":            "stable",
+		"staging":     "staging",
+		"0.10.0":      "0.10.0",
+		"^0.10":       "0.10.3",
+		"~0.9":        "0.9.0",
+		">=0.9 <0.11": "0.10.3",
+		">=0.12":      "staging",
+	} {
+		got, err := manifest.ResolveVersion(constraint)
+		if err != nil {
+			t.Errorf("%q: %v", constraint, err)
+		} else if got != want {
+			t.Errorf("%q: expected %s, got %s", constraint, want, got)
+		}
+	}
+
+	// Pre-releases of the next version do not satisfy a range of patch releases
+	prerelease := &Manifest{
+		Name: "unikraft",
+		Type: unikraft.ComponentTypeCore,
+		Versions: []ManifestVersion{
+			{Version: "0.10.0", Type: ManifestVersionSemver},
+			{Version: "0.10.2", Type: ManifestVersionSemver},
+			{Version: "0.11.0-rc1", Type: ManifestVersionSemver},
+		},
+	}
+
+	for constraint, want := range map[string]string{
+		"^0.10":       "0.10.2",
+		"~0.10.1":     "0.10.2",
+		"^0.11.0-rc1": "0.11.0-rc1",
+	} {
+		if got, err := prerelease.ResolveVersion(constraint); err != nil || got != want {
+			t.Errorf("%q: expected %s, got %q (%v)", constraint, want, got, err)
+		}
+	}
+
+	// Without channels the newest version is selected by default
+	if got, err := testResolverManifests()[1].ResolveVersion(""); err != nil || got != "1.1.0" {
+		t.Errorf("expected default version 1.1.0, got %q (%v)", got, err)
+	}
+
+	_, err := manifest.ResolveVersion("^1.0")
+	if err == nil || !strings.Contains(err.Error(), "available versions: 0.9.0, 0.10.0, 0.10.3, 0.11.0, stable (0.11.0), staging (0.12.0)") {
+		t.Errorf("expected available versions in error, got %v", err)
+	}
+}
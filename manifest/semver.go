@@ -0,0 +1,311 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version, e.g. `0.10.0` or `v1.2.3-rc1`
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseSemver parses the provided version.  Versions may be prefixed with `v`
+// and omit their minor and patch components.  It returns false if the version
+// is not a semantic version, e.g. a Git commit SHA.
+func parseSemver(version string) (semver, bool) {
+	v := semver{}
+
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+
+	if i := strings.Index(version, "-"); i >= 0 {
+		v.pre = version[i+1:]
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+
+		*nums[i] = n
+	}
+
+	return v, true
+}
+
+// compare returns -1, 0 or 1 if the version is respectively lower than, equal
+// to or greater than the provided version.  Pre-releases precede the release.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	case v.pre < o.pre:
+		return -1
+	}
+
+	return 1
+}
+
+// compareVersions orders two versions, placing semantic versions before any
+// other kind of version
+func compareVersions(a, b string) int {
+	va, aok := parseSemver(a)
+	vb, bok := parseSemver(b)
+
+	switch {
+	case aok && bok:
+		return va.compare(vb)
+	case aok:
+		return 1
+	case bok:
+		return -1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// comparison is a single operator and operand of a constraint
+type comparison struct {
+	op      string
+	version string
+}
+
+// Constraint is a set of comparisons which a version must all satisfy, e.g.
+// `>=0.10.0 <0.12.0`.  Comparisons are separated by spaces or commas and a
+// version without an operator must match exactly.  Caret and tilde ranges are
+// expanded to a pair of comparisons: `^0.10` permits `>=0.10.0 <0.11.0`,
+// `^1.2` permits `>=1.2.0 <2.0.0` and `~1.2` permits `>=1.2.0 <1.3.0`.
+type Constraint struct {
+	raw         string
+	comparisons []comparison
+}
+
+// NewConstraint parses the provided constraint
+func NewConstraint(constraint string) (*Constraint, error) {
+	c := &Constraint{
+		raw: strings.TrimSpace(constraint),
+	}
+
+	fields := strings.FieldsFunc(c.raw, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		op := ""
+		for _, o := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(field, o) {
+				op = o
+				break
+			}
+		}
+
+		version := strings.TrimPrefix(field, op)
+
+		// Allow a space between the operator and the version, e.g. `>= 0.10`
+		if len(version) == 0 && i+1 < len(fields) {
+			i++
+			version = fields[i]
+		}
+
+		if len(version) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: missing version after %s", constraint, op)
+		}
+
+		if op == "" {
+			op = "="
+		}
+
+		if op == "^" || op == "~" {
+			lower, upper, err := expandRange(op, version)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %v", constraint, err)
+			}
+
+			c.comparisons = append(c.comparisons,
+				comparison{">=", lower},
+				comparison{"<", upper},
+			)
+
+			continue
+		}
+
+		if op != "=" && op != "!=" {
+			if _, ok := parseSemver(version); !ok {
+				return nil, fmt.Errorf("invalid constraint %q: %s is not a semantic version", constraint, version)
+			}
+		}
+
+		c.comparisons = append(c.comparisons, comparison{op, version})
+	}
+
+	return c, nil
+}
+
+// Check returns whether the version satisfies all comparisons of the
+// constraint.  Versions which are not semantic versions only satisfy exact
+// comparisons.  Pre-releases are only considered when the constraint names a
+// pre-release of the same version, such that e.g. `^0.10` does not permit
+// `0.11.0-rc1`.
+func (c *Constraint) Check(version string) bool {
+	if v, ok := parseSemver(version); ok && len(v.pre) > 0 && !c.allowsPre(v) {
+		return false
+	}
+
+	for _, cmp := range c.comparisons {
+		if !cmp.check(version) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// allowsPre returns whether a comparison of the constraint names a pre-release
+// of the same major, minor and patch version as the provided version
+func (c *Constraint) allowsPre(v semver) bool {
+	for _, cmp := range c.comparisons {
+		o, ok := parseSemver(cmp.version)
+		if ok && len(o.pre) > 0 && o.major == v.major && o.minor == v.minor && o.patch == v.patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandRange returns the inclusive lower and exclusive upper bound of a caret
+// or tilde range.  A caret permits changes which do not modify the left-most
+// non-zero component of the version, whereas a tilde permits patch changes
+// only, or minor changes if the minor component is omitted.
+func expandRange(op, version string) (string, string, error) {
+	v, ok := parseSemver(version)
+	if !ok {
+		return "", "", fmt.Errorf("%s is not a semantic version", version)
+	}
+
+	core := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := len(strings.Split(core, "."))
+	lower := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	if len(v.pre) > 0 {
+		lower += "-" + v.pre
+	}
+
+	var upper string
+
+	switch {
+	case op == "~" && parts == 1,
+		op == "^" && v.major > 0,
+		op == "^" && parts == 1:
+		upper = fmt.Sprintf("%d.0.0", v.major+1)
+	case op == "~",
+		op == "^" && v.minor > 0,
+		op == "^" && parts == 2:
+		upper = fmt.Sprintf("%d.%d.0", v.major, v.minor+1)
+	default:
+		upper = fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch+1)
+	}
+
+	return lower, upper, nil
+}
+
+func (cmp comparison) check(version string) bool {
+	v, vok := parseSemver(version)
+	o, ook := parseSemver(cmp.version)
+
+	if !vok || !ook {
+		switch cmp.op {
+		case "=":
+			return version == cmp.version
+		case "!=":
+			return version != cmp.version
+		}
+
+		return false
+	}
+
+	d := v.compare(o)
+
+	switch cmp.op {
+	case "=":
+		return d == 0
+	case "!=":
+		return d != 0
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	}
+
+	return false
+}
+
+// String returns the constraint as it was provided
+func (c *Constraint) String() string {
+	return c.raw
+}
@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// SignatureExtension is the file extension of a detached, base64 encoded
+	// ed25519 signature which is stored next to a manifest or manifest index.
+	SignatureExtension = ".sig"
+
+	// MinisignExtension is the file extension of a detached minisign signature
+	// which is stored next to a manifest or manifest index.
+	MinisignExtension = ".minisig"
+)
+
+var (
+	minisignAlgorithm         = []byte("Ed")
+	minisignPrehashAlgorithm  = []byte("ED")
+	minisignUntrustedComment  = "untrusted comment:"
+	minisignTrustedComment    = "trusted comment: "
+	minisignKeyIDSize         = 8
+	minisignPublicKeySize     = 2 + minisignKeyIDSize + ed25519.PublicKeySize
+	minisignSignatureBlobSize = 2 + minisignKeyIDSize + ed25519.SignatureSize
+)
+
+// SignatureError is returned when a manifest or manifest index could not be
+// verified against the configured trusted keys.
+type SignatureError struct {
+	Path string
+	Err  error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("could not verify signature of %s: %v", e.Path, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// trustedKey is a parsed public key.  Keys in the minisign format carry a key
+// ID which is matched against the key ID of a minisign signature.
+type trustedKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+// parseTrustedKey accepts either a base64 encoded raw ed25519 public key or a
+// minisign public key, optionally including its untrusted comment line.
+func parseTrustedKey(value string) (*trustedKey, error) {
+	var encoded string
+	for _, line := range strings.Split(strings.TrimSpace(value), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, minisignUntrustedComment) {
+			continue
+		}
+
+		encoded = line
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode public key: %v", err)
+	}
+
+	switch {
+	case len(raw) == ed25519.PublicKeySize:
+		return &trustedKey{
+			key: ed25519.PublicKey(raw),
+		}, nil
+
+	case len(raw) == minisignPublicKeySize && bytes.Equal(raw[:2], minisignAlgorithm):
+		return &trustedKey{
+			id:  raw[2 : 2+minisignKeyIDSize],
+			key: ed25519.PublicKey(raw[2+minisignKeyIDSize:]),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported public key format")
+}
+
+// parseTrustedKeys parses all provided public keys.
+func parseTrustedKeys(values []string) ([]*trustedKey, error) {
+	var keys []*trustedKey
+
+	for _, value := range values {
+		key, err := parseTrustedKey(value)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// verifySignature checks a detached, base64 encoded ed25519 signature of the
+// contents against any of the trusted keys.
+func verifySignature(contents, signature []byte, keys []*trustedKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %v", err)
+	}
+
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature size: %d", len(sig))
+	}
+
+	for _, key := range keys {
+		if ed25519.Verify(key.key, contents, sig) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature does not match any trusted key")
+}
+
+// verifyMinisign checks a minisign signature of the contents against the
+// trusted key with the matching key ID.  Both the signature of the contents and
+// the global signature covering the trusted comment must be valid.
+func verifyMinisign(contents, signature []byte, keys []*trustedKey) error {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	if !strings.HasPrefix(lines[0], minisignUntrustedComment) ||
+		!strings.HasPrefix(lines[2], minisignTrustedComment) {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %v", err)
+	}
+
+	if len(blob) != minisignSignatureBlobSize {
+		return fmt.Errorf("invalid signature size: %d", len(blob))
+	}
+
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return fmt.Errorf("could not decode global signature: %v", err)
+	}
+
+	if len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid global signature size: %d", len(global))
+	}
+
+	algorithm := blob[:2]
+	id := blob[2 : 2+minisignKeyIDSize]
+	sig := blob[2+minisignKeyIDSize:]
+
+	message := contents
+	switch {
+	case bytes.Equal(algorithm, minisignAlgorithm):
+	case bytes.Equal(algorithm, minisignPrehashAlgorithm):
+		sum := blake2b.Sum512(contents)
+		message = sum[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm: %q", algorithm)
+	}
+
+	comment := []byte(strings.TrimPrefix(lines[2], minisignTrustedComment))
+
+	for _, key := range keys {
+		if key.id == nil || !bytes.Equal(key.id, id) {
+			continue
+		}
+
+		if !ed25519.Verify(key.key, message, sig) {
+			return fmt.Errorf("signature does not match trusted key")
+		}
+
+		if !ed25519.Verify(key.key, append(append([]byte{}, sig...), comment...), global) {
+			return fmt.Errorf("global signature does not match trusted key")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("signature key ID %X does not match any trusted key", id)
+}
+
+// readSignature retrieves the detached signature stored next to the provided
+// path, which is either a local file or a remote URL.  Remote signatures are
+// retrieved with the client and credentials set by the provided
+// ManifestOptions.
+func readSignature(path string, mopts []ManifestOption) ([]byte, error) {
+	if u, err := url.Parse(path); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		resp, err := getDocument(path, mopts)
+		if err != nil {
+			return nil, err
+		}
+
+		defer resp.Body.Close()
+
+		return io.ReadAll(resp.Body)
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// probeOptions returns a Manifest with only the provided ManifestOptions
+// applied, such that the options can be inspected before a manifest is read
+func probeOptions(mopts []ManifestOption) *Manifest {
+	probe := &Manifest{}
+	for _, o := range mopts {
+		_ = o(probe)
+	}
+
+	return probe
+}
+
+// trustedKeysFromOptions returns the trusted keys set by the provided
+// ManifestOptions.
+func trustedKeysFromOptions(mopts []ManifestOption) []string {
+	return probeOptions(mopts).trustedKeys
+}
+
+// verifyDocument verifies the raw contents of the manifest or manifest index
+// retrieved from the provided path against the signature stored next to it.
+// A minisign signature takes precedence over a raw ed25519 signature.  When no
+// trusted keys are set by the provided ManifestOptions, nothing is verified.
+func verifyDocument(path string, contents []byte, mopts []ManifestOption) error {
+	values := trustedKeysFromOptions(mopts)
+	if len(values) == 0 {
+		return nil
+	}
+
+	keys, err := parseTrustedKeys(values)
+	if err != nil {
+		return &SignatureError{Path: path, Err: err}
+	}
+
+	if signature, err := readSignature(path+MinisignExtension, mopts); err == nil {
+		if err := verifyMinisign(contents, signature, keys); err != nil {
+			return &SignatureError{Path: path, Err: err}
+		}
+
+		return nil
+	}
+
+	signature, err := readSignature(path+SignatureExtension, mopts)
+	if err != nil {
+		return &SignatureError{Path: path, Err: fmt.Errorf("no signature found")}
+	}
+
+	if err := verifySignature(contents, signature, keys); err != nil {
+		return &SignatureError{Path: path, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+
+	"kraftkit.sh/config"
+)
+
+const testSignedIndex = `manifests:
+  - name: unikraft
+    type: core
+    manifest: unikraft.yaml
+`
+
+func TestVerifySignedIndex(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.yaml")
+	trusted := WithTrustedKeys([]string{base64.StdEncoding.EncodeToString(pub)})
+
+	if err := ioutil.WriteFile(path, []byte(testSignedIndex), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var serr *SignatureError
+	if _, err := NewManifestIndexFromFile(path, trusted); !errors.As(err, &serr) {
+		t.Fatalf("expected unsigned index to be rejected, got: %v", err)
+	}
+
+	sig := ed25519.Sign(priv, []byte(testSignedIndex))
+	if err := ioutil.WriteFile(path+SignatureExtension, []byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManifestIndexFromFile(path, trusted); err != nil {
+		t.Fatalf("expected signed index to be accepted, got: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(testSignedIndex+"  - name: evil\n    type: lib\n    manifest: evil.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManifestIndexFromFile(path, trusted); !errors.As(err, &serr) {
+		t.Fatalf("expected tampered index to be rejected, got: %v", err)
+	}
+
+	// Without trusted keys, signatures are not checked
+	if _, err := NewManifestIndexFromFile(path); err != nil {
+		t.Fatalf("expected index to be accepted without trusted keys, got: %v", err)
+	}
+}
+
+func TestVerifySignedPrivateIndex(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(testSignedIndex)))
+
+	// The index and its signature are only served to authenticated requests
+	// over TLS with a certificate which is not trusted by the host
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "user" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/index.yaml":
+			io.WriteString(w, testSignedIndex)
+		case "/index.yaml" + SignatureExtension:
+			io.WriteString(w, sig+"\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	// Rejected handshakes are expected without credentials
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	trusted := WithTrustedKeys([]string{base64.StdEncoding.EncodeToString(pub)})
+	auths := WithAuthConfig(map[string]config.AuthConfig{
+		strings.TrimPrefix(server.URL, "https://"): {User: "user", Token: "secret", VerifySSL: false},
+	})
+
+	if _, err := NewManifestIndexFromURL(server.URL+"/index.yaml", trusted, auths); err != nil {
+		t.Fatalf("expected signed private index to be accepted, got: %v", err)
+	}
+
+	if _, err := NewManifestIndexFromURL(server.URL+"/index.yaml", trusted); err == nil {
+		t.Fatal("expected private index to be rejected without credentials")
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	key, err := parseTrustedKey("untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pub...)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := []byte(testSignedIndex)
+	hash := blake2b.Sum512(contents)
+	sig := ed25519.Sign(priv, hash[:])
+	comment := "timestamp:1666000000\tfile:index.yaml"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+
+	minisig := func(comment string) []byte {
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("ED"), id...), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+
+	if err := verifyMinisign(contents, minisig(comment), []*trustedKey{key}); err != nil {
+		t.Fatalf("expected valid minisign signature, got: %v", err)
+	}
+
+	if err := verifyMinisign(contents, minisig("tampered"), []*trustedKey{key}); err == nil {
+		t.Fatal("expected tampered trusted comment to be rejected")
+	}
+
+	if err := verifyMinisign([]byte("tampered"), minisig(comment), []*trustedKey{key}); err == nil {
+		t.Fatal("expected tampered contents to be rejected")
+	}
+}
+
+func TestChecksumMatches(t *testing.T) {
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	for _, expected := range []string{
+		sum,
+		"9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08",
+		"sha256:" + sum,
+	} {
+		if !checksumMatches(expected, sum) {
+			t.Errorf("expected %s to match", expected)
+		}
+	}
+
+	for _, expected := range []string{"", sum[1:], string([]byte(sum)[:32])} {
+		if checksumMatches(expected, sum) {
+			t.Errorf("expected %q not to match", expected)
+		}
+	}
+}
@@ -33,6 +33,7 @@ package manifest
 
 import (
 	"fmt"
+	"regexp"
 )
 
 type ManifestVersionType string
@@ -47,8 +48,16 @@ type ManifestVersion struct {
 	Resource string              `yaml:"resource"`
 	Sha256   string              `yaml:"sha256,omitempty"`
 	Type     ManifestVersionType `yaml:"type,omitempty"`
-	Unikraft string              `yaml:"unikraft,omitempty"`
-	Local    string              `yaml:"-"`
+
+	// Unikraft is the constraint on the versions of the Unikraft core which this
+	// version is compatible with, e.g. `>=0.10.0 <0.12.0`
+	Unikraft string `yaml:"unikraft,omitempty"`
+
+	// Dependencies maps the names of the libraries which this version depends on
+	// to a constraint on their versions
+	Dependencies map[string]string `yaml:"dependencies,omitempty"`
+
+	Local string `yaml:"-"`
 }
 
 func (mv *ManifestVersion) ShortGitSha() (string, error) {
@@ -58,3 +67,10 @@ func (mv *ManifestVersion) ShortGitSha() (string, error) {
 
 	return mv.Version[:7], nil
 }
+
+var gitShaRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsGitSha returns whether the provided version is a full Git commit SHA
+func IsGitSha(version string) bool {
+	return gitShaRegexp.MatchString(version)
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// BridgeDriver attaches each machine to a tap device which is enslaved to a
+// bridge on the host.  The bridge and the tap device are created when they do
+// not exist and only those which have been created are removed afterwards.
+type BridgeDriver struct {
+	// linkExists determines whether a link with the given name exists on the
+	// host and can be overridden for testing
+	linkExists func(string) bool
+}
+
+func init() {
+	if err := RegisterDriver(DefaultDriver, BridgeDriver{}); err != nil {
+		panic(err)
+	}
+}
+
+func hostLinkExists(name string) bool {
+	_, err := net.InterfaceByName(name)
+	return err == nil
+}
+
+// tapName returns the name of the host tap device of the network
+func tapName(cfg NetworkConfig, index int) string {
+	if len(cfg.Interface) > 0 {
+		return cfg.Interface
+	}
+
+	return "kraft-tap" + strconv.Itoa(index)
+}
+
+// bridgeName returns the name of the host bridge of the network
+func bridgeName(cfg NetworkConfig) string {
+	if len(cfg.BridgeName) > 0 {
+		return cfg.BridgeName
+	}
+
+	return DefaultBridgeName
+}
+
+func (bd BridgeDriver) Up(cfg NetworkConfig, index int) ([][]string, State, error) {
+	var state State
+
+	if err := cfg.Validate(); err != nil {
+		return nil, state, err
+	}
+
+	exists := bd.linkExists
+	if exists == nil {
+		exists = hostLinkExists
+	}
+
+	bridge := bridgeName(cfg)
+	tap := tapName(cfg, index)
+
+	var cmds [][]string
+
+	if !exists(bridge) {
+		state.Links = append(state.Links, bridge)
+		cmds = append(cmds, []string{"ip", "link", "add", "name", bridge, "type", "bridge"})
+
+		if len(cfg.Gateway) > 0 {
+			cmds = append(cmds, []string{
+				"ip", "address", "add",
+				fmt.Sprintf("%s/%d", cfg.Gateway, cfg.PrefixLength()),
+				"dev", bridge,
+			})
+		}
+
+		cmds = append(cmds, []string{"ip", "link", "set", bridge, "up"})
+	}
+
+	if !exists(tap) {
+		state.Links = append(state.Links, tap)
+		cmds = append(cmds, []string{"ip", "tuntap", "add", "dev", tap, "mode", "tap"})
+	}
+
+	cmds = append(cmds,
+		[]string{"ip", "link", "set", tap, "master", bridge},
+		[]string{"ip", "link", "set", tap, "up"},
+	)
+
+	return cmds, state, nil
+}
+
+func (bd BridgeDriver) Down(cfg NetworkConfig, index int, state State) ([][]string, error) {
+	var cmds [][]string
+
+	// Remove the links in reverse order of their creation, i.e. the tap device
+	// before the bridge it is enslaved to
+	for i := len(state.Links) - 1; i >= 0; i-- {
+		cmds = append(cmds, []string{"ip", "link", "delete", state.Links[i]})
+	}
+
+	return cmds, nil
+}
+
+func (bd BridgeDriver) Args(hypervisor string, cfg NetworkConfig, index int) ([]string, error) {
+	switch hypervisor {
+	case "qemu":
+		model := cfg.Type
+		if len(model) == 0 || model == "virtio" {
+			model = "virtio-net-pci"
+		}
+
+		id := "net" + strconv.Itoa(index)
+
+		return []string{
+			"-netdev", fmt.Sprintf("tap,id=%s,ifname=%s,script=no,downscript=no", id, tapName(cfg, index)),
+			"-device", fmt.Sprintf("%s,netdev=%s", model, id),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("hypervisor is not supported by the %s network driver: %s", bd.String(), hypervisor)
+}
+
+func (bd BridgeDriver) String() string {
+	return DefaultDriver
+}
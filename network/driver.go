@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package network
+
+import (
+	"fmt"
+	"sort"
+)
+
+// State records what a driver creates on the host when setting up a network,
+// such that only that is removed again when tearing it down
+type State struct {
+	// Links are the names of the links which are created on the host
+	Links []string
+}
+
+// Driver turns a NetworkConfig into the commands which set up and tear down
+// the network on the host and the arguments which attach a hypervisor to it
+type Driver interface {
+	// Up returns the commands which create the network on the host and the
+	// state which these commands result in
+	Up(cfg NetworkConfig, index int) ([][]string, State, error)
+
+	// Down returns the commands which remove what has been created by the
+	// commands of Up from the host
+	Down(cfg NetworkConfig, index int, state State) ([][]string, error)
+
+	// Args returns the arguments for the given hypervisor which attach the
+	// machine to the network
+	Args(hypervisor string, cfg NetworkConfig, index int) ([]string, error)
+
+	// String returns the name of the driver
+	String() string
+}
+
+var drivers = make(map[string]Driver)
+
+// RegisterDriver makes a Driver available by its name
+func RegisterDriver(name string, driver Driver) error {
+	if _, ok := drivers[name]; ok {
+		return fmt.Errorf("network driver already registered: %s", name)
+	}
+
+	drivers[name] = driver
+
+	return nil
+}
+
+// Drivers returns the sorted list of names of the registered drivers
+func Drivers() []string {
+	var names []string
+	for name := range drivers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewDriver returns the Driver which handles the provided network
+func NewDriver(cfg NetworkConfig) (Driver, error) {
+	name := cfg.Driver
+	if len(name) == 0 {
+		name = DefaultDriver
+	}
+
+	driver, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network driver: %s", name)
+	}
+
+	return driver, nil
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package network
+
+import (
+	"fmt"
+	"net"
+)
+
+const (
+	// DefaultDriver is the network driver used when none is specified
+	DefaultDriver = "bridge"
+
+	// DefaultBridgeName is the name of the host bridge used when none is
+	// specified
+	DefaultBridgeName = "kraft0"
+)
+
+// NetworkConfig represents a network which a unikernel is attached to
+type NetworkConfig struct {
+	// Name of the network
+	Name string `yaml:"-" json:"-"`
+
+	// PreUp is executed on the host after the network has been set up and
+	// before the unikernel is started
+	PreUp []string `yaml:"pre_up,omitempty" json:"pre_up,omitempty"`
+
+	// PostDown is executed on the host after the unikernel has exited and the
+	// network has been torn down
+	PostDown []string `yaml:"post_down,omitempty" json:"post_down,omitempty"`
+
+	// IP is the IPv4 address of the unikernel on this network
+	IP string `yaml:",omitempty" json:"ip,omitempty"`
+
+	// Gateway is the IPv4 address of the host on this network
+	Gateway string `yaml:",omitempty" json:"gateway,omitempty"`
+
+	// Netmask of the network in dotted decimal notation
+	Netmask string `yaml:",omitempty" json:"netmask,omitempty"`
+
+	// Interface is the name of the host interface which is created for the
+	// unikernel
+	Interface string `yaml:",omitempty" json:"interface,omitempty"`
+
+	// Driver is the name of the Driver which sets up the network on the host
+	Driver string `yaml:",omitempty" json:"driver,omitempty"`
+
+	// Type is the model of network device presented to the unikernel
+	Type string `yaml:",omitempty" json:"type,omitempty"`
+
+	// BridgeName is the name of the host bridge the interface is attached to
+	BridgeName string `yaml:"bridge_name,omitempty" json:"bridge_name,omitempty"`
+}
+
+type Networks map[string]NetworkConfig
+
+// Validate checks that the addresses of the network are well-formed
+func (nc NetworkConfig) Validate() error {
+	for field, addr := range map[string]string{
+		"ip":      nc.IP,
+		"gateway": nc.Gateway,
+		"netmask": nc.Netmask,
+	} {
+		if len(addr) > 0 && net.ParseIP(addr).To4() == nil {
+			return fmt.Errorf("network %s: invalid IPv4 address for %s: %s", nc.Name, field, addr)
+		}
+	}
+
+	if len(nc.Netmask) > 0 {
+		if _, bits := net.IPMask(net.ParseIP(nc.Netmask).To4()).Size(); bits == 0 {
+			return fmt.Errorf("network %s: non-canonical netmask: %s", nc.Name, nc.Netmask)
+		}
+	}
+
+	return nil
+}
+
+// PrefixLength returns the number of leading ones in the netmask, or 24 if
+// the netmask is not set
+func (nc NetworkConfig) PrefixLength() int {
+	if len(nc.Netmask) == 0 {
+		return 24
+	}
+
+	ones, _ := net.IPMask(net.ParseIP(nc.Netmask).To4()).Size()
+	return ones
+}
+
+// KernelArgs returns the command-line arguments which configure the network
+// interface within Unikraft
+func (nc NetworkConfig) KernelArgs() []string {
+	var args []string
+
+	if len(nc.IP) > 0 {
+		args = append(args, "netdev.ipv4_addr="+nc.IP)
+	}
+
+	if len(nc.Gateway) > 0 {
+		args = append(args, "netdev.ipv4_gw_addr="+nc.Gateway)
+	}
+
+	if len(nc.Netmask) > 0 {
+		args = append(args, "netdev.ipv4_subnet_mask="+nc.Netmask)
+	}
+
+	return args
+}
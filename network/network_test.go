@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBridgeUp(t *testing.T) {
+	cfg := NetworkConfig{
+		Name:       "default",
+		IP:         "172.44.0.2",
+		Gateway:    "172.44.0.1",
+		Netmask:    "255.255.0.0",
+		BridgeName: "kraftbr0",
+	}
+
+	driver := BridgeDriver{linkExists: func(string) bool { return false }}
+	cmds, _, err := driver.Up(cfg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"ip", "link", "add", "name", "kraftbr0", "type", "bridge"},
+		{"ip", "address", "add", "172.44.0.1/16", "dev", "kraftbr0"},
+		{"ip", "link", "set", "kraftbr0", "up"},
+		{"ip", "tuntap", "add", "dev", "kraft-tap0", "mode", "tap"},
+		{"ip", "link", "set", "kraft-tap0", "master", "kraftbr0"},
+		{"ip", "link", "set", "kraft-tap0", "up"},
+	}
+
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("unexpected setup commands:\n%v\nexpected:\n%v", cmds, expected)
+	}
+
+	// An existing bridge should be re-used
+	driver = BridgeDriver{linkExists: func(name string) bool { return name == "kraftbr0" }}
+	cmds, _, err = driver.Up(cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cmds) != 3 || cmds[0][1] != "tuntap" || cmds[0][4] != "kraft-tap1" {
+		t.Errorf("unexpected setup commands with existing bridge: %v", cmds)
+	}
+}
+
+func TestBridgeDown(t *testing.T) {
+	cfg := NetworkConfig{
+		Name:       "default",
+		BridgeName: "kraftbr0",
+		Interface:  "tap42",
+	}
+
+	driver := BridgeDriver{linkExists: func(string) bool { return false }}
+	_, state, err := driver.Up(cfg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmds, err := driver.Down(cfg, 0, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"ip", "link", "delete", "tap42"},
+		{"ip", "link", "delete", "kraftbr0"},
+	}
+
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("unexpected teardown commands:\n%v\nexpected:\n%v", cmds, expected)
+	}
+
+	// Links which already existed are left in place
+	driver = BridgeDriver{linkExists: func(string) bool { return true }}
+	if _, state, err = driver.Up(cfg, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmds, err = driver.Down(cfg, 0, state); err != nil || len(cmds) != 0 {
+		t.Errorf("expected no teardown commands for existing links, got: %v (%v)", cmds, err)
+	}
+}
+
+func TestBridgeArgs(t *testing.T) {
+	cfg := NetworkConfig{Interface: "tap42"}
+
+	args, err := BridgeDriver{}.Args("qemu", cfg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"-netdev", "tap,id=net2,ifname=tap42,script=no,downscript=no",
+		"-device", "virtio-net-pci,netdev=net2",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments: %v", args)
+	}
+
+	if _, err := (BridgeDriver{}).Args("xen", cfg, 0); err == nil {
+		t.Errorf("expected error for unsupported hypervisor")
+	}
+}
+
+func TestNetworkConfigValidate(t *testing.T) {
+	for _, cfg := range []NetworkConfig{
+		{IP: "172.44.0"},
+		{Gateway: "fe80::1"},
+		{Netmask: "255.0.255.0"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for invalid network: %+v", cfg)
+		}
+	}
+
+	cfg := NetworkConfig{IP: "10.0.0.2", Gateway: "10.0.0.1", Netmask: "255.255.255.0"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"netdev.ipv4_addr=10.0.0.2",
+		"netdev.ipv4_gw_addr=10.0.0.1",
+		"netdev.ipv4_subnet_mask=255.255.255.0",
+	}
+
+	if args := cfg.KernelArgs(); !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected kernel arguments: %v", args)
+	}
+}
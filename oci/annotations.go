@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package oci
+
+const (
+	// MediaTypeKernel is the media type of the layer holding the unikernel
+	// image.  The layer itself is a regular tarball so that it can be handled by
+	// existing OCI-compatible tooling.
+	MediaTypeKernel = "application/vnd.unikraft.kernel.v1.tar"
+
+	// MediaTypeInitrd is the media type of the layer holding the initramfs.
+	MediaTypeInitrd = "application/vnd.unikraft.initrd.v1.tar"
+
+	// MediaTypeVolume9pfs is the media type of a layer holding the contents of
+	// a directory which is shared with the unikernel via 9pfs.
+	MediaTypeVolume9pfs = "application/vnd.unikraft.volume.9pfs.v1.tar"
+
+	// MediaTypeVolumeRaw is the media type of a layer holding a raw disk image.
+	MediaTypeVolumeRaw = "application/vnd.unikraft.volume.raw.v1.tar"
+
+	// Well-known locations of the artifacts within the layers of the image.
+	WellKnownKernelPath  = "unikraft/bin/kernel"
+	WellKnownInitrdPath  = "unikraft/bin/initrd"
+	WellKnownVolumesPath = "unikraft/volumes"
+
+	// Annotations which are attached to both the image manifest and its entry
+	// within the image layout's index.
+	AnnotationName         = "org.unikraft.image.name"
+	AnnotationVersion      = "org.unikraft.image.version"
+	AnnotationType         = "org.unikraft.image.type"
+	AnnotationArchitecture = "org.unikraft.image.architecture"
+	AnnotationPlatform     = "org.unikraft.image.platform"
+	AnnotationKernelPath   = "org.unikraft.image.kernel"
+	AnnotationInitrdPath   = "org.unikraft.image.initrd"
+
+	// Annotations which are attached to the descriptor of a volume layer.
+	AnnotationVolumeName = "org.unikraft.volume.name"
+	AnnotationVolumeType = "org.unikraft.volume.type"
+)
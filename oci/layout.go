@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// ImageIndexFile is the name of the file containing the top-most index of
+	// the image layout.
+	ImageIndexFile = "index.json"
+
+	// ImageBlobsDir is the name of the directory containing all blobs of the
+	// image layout.
+	ImageBlobsDir = "blobs"
+)
+
+// Layout represents an OCI image layout directory on disk.  See:
+// https://github.com/opencontainers/image-spec/blob/main/image-layout.md
+type Layout struct {
+	root string
+}
+
+// NewLayout prepares the OCI image layout at the provided path.  If the
+// directory does not exist or has not yet been initialized as an image layout,
+// it is initialized.
+func NewLayout(root string) (*Layout, error) {
+	if len(root) == 0 {
+		return nil, fmt.Errorf("cannot use empty path as image layout")
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, ImageBlobsDir, string(digest.SHA256)), 0o755); err != nil {
+		return nil, fmt.Errorf("could not create image layout: %v", err)
+	}
+
+	layout := &Layout{root: root}
+
+	if !IsLayout(root) {
+		raw, err := json.Marshal(ocispec.ImageLayout{
+			Version: ocispec.ImageLayoutVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(root, ocispec.ImageLayoutFile), raw, 0o644); err != nil {
+			return nil, fmt.Errorf("could not write image layout file: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, ImageIndexFile)); os.IsNotExist(err) {
+		if err := layout.writeIndex(&ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Manifests: []ocispec.Descriptor{},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return layout, nil
+}
+
+// IsLayout checks whether the provided path is an initialized OCI image layout
+func IsLayout(root string) bool {
+	raw, err := ioutil.ReadFile(filepath.Join(root, ocispec.ImageLayoutFile))
+	if err != nil {
+		return false
+	}
+
+	layout := ocispec.ImageLayout{}
+	if err := json.Unmarshal(raw, &layout); err != nil {
+		return false
+	}
+
+	return layout.Version == ocispec.ImageLayoutVersion
+}
+
+// Root returns the path to the image layout on disk
+func (l *Layout) Root() string {
+	return l.root
+}
+
+// BlobPath returns the location of a blob within the image layout given its
+// digest
+func (l *Layout) BlobPath(dgst digest.Digest) string {
+	return filepath.Join(l.root, ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// WriteBlob consumes the provided reader and stores its contents as a
+// content-addressable blob within the image layout.  The descriptor of the
+// resulting blob is returned.
+func (l *Layout) WriteBlob(mediaType string, r io.Reader) (ocispec.Descriptor, error) {
+	tmp, err := ioutil.TempFile(filepath.Join(l.root, ImageBlobsDir), "blob-*.part")
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("could not create temporary blob: %v", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(tmp, io.TeeReader(r, digester.Hash()))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("could not write blob: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}
+
+	if err := os.Rename(tmp.Name(), l.BlobPath(desc.Digest)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("could not move blob to destination: %v", err)
+	}
+
+	return desc, nil
+}
+
+// WriteBlobBytes is a convenience method of WriteBlob for in-memory content
+func (l *Layout) WriteBlobBytes(mediaType string, content []byte) (ocispec.Descriptor, error) {
+	return l.WriteBlob(mediaType, bytes.NewReader(content))
+}
+
+// ReadBlob returns the contents of a blob after verifying its digest
+func (l *Layout) ReadBlob(desc ocispec.Descriptor) ([]byte, error) {
+	raw, err := ioutil.ReadFile(l.BlobPath(desc.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("could not read blob: %v", err)
+	}
+
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+
+	if actual := desc.Digest.Algorithm().FromBytes(raw); actual != desc.Digest {
+		return nil, fmt.Errorf("blob digest mismatch: expected %s got %s", desc.Digest, actual)
+	}
+
+	return raw, nil
+}
+
+// Index returns the top-most index of the image layout
+func (l *Layout) Index() (*ocispec.Index, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(l.root, ImageIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not read image index: %v", err)
+	}
+
+	index := &ocispec.Index{}
+	if err := json.Unmarshal(raw, index); err != nil {
+		return nil, fmt.Errorf("could not parse image index: %v", err)
+	}
+
+	return index, nil
+}
+
+// Manifest returns the image manifest referenced by the provided descriptor
+func (l *Layout) Manifest(desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	raw, err := l.ReadBlob(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ocispec.Manifest{}
+	if err := json.Unmarshal(raw, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse image manifest: %v", err)
+	}
+
+	return manifest, nil
+}
+
+// AddManifest saves the descriptor of an image manifest within the index of
+// the image layout.  Existing entries with the same reference name and
+// platform are replaced.
+func (l *Layout) AddManifest(desc ocispec.Descriptor) error {
+	index, err := l.Index()
+	if err != nil {
+		return err
+	}
+
+	var manifests []ocispec.Descriptor
+	for _, existing := range index.Manifests {
+		if sameReference(existing, desc) {
+			continue
+		}
+
+		manifests = append(manifests, existing)
+	}
+
+	index.Manifests = append(manifests, desc)
+
+	return l.writeIndex(index)
+}
+
+// sameReference checks whether two descriptors point to the same reference
+// name for the same platform
+func sameReference(a, b ocispec.Descriptor) bool {
+	if a.Annotations[ocispec.AnnotationRefName] != b.Annotations[ocispec.AnnotationRefName] {
+		return false
+	}
+
+	if a.Platform == nil || b.Platform == nil {
+		return a.Platform == b.Platform
+	}
+
+	return a.Platform.Architecture == b.Platform.Architecture &&
+		a.Platform.OS == b.Platform.OS
+}
+
+// writeIndex is an internal method which serializes the top-most index of the
+// image layout to disk
+func (l *Layout) writeIndex(index *ocispec.Index) error {
+	raw, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(l.root, ImageIndexFile), raw, 0o644)
+}
@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gobwas/glob"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+)
+
+type OCIManager struct {
+	opts *packmanager.PackageManagerOptions
+}
+
+func init() {
+	options, err := packmanager.NewPackageManagerOptions(
+		context.TODO(),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("could not register package manager options: %s", err))
+	}
+
+	manager, err := NewOCIPackageManagerFromOptions(options)
+	if err != nil {
+		panic(fmt.Sprintf("could not register package manager: %s", err))
+	}
+
+	// Register a new pack.Package type
+	packmanager.RegisterPackageManager(OCIContext, manager)
+}
+
+func NewOCIPackageManagerFromOptions(opts *packmanager.PackageManagerOptions) (packmanager.PackageManager, error) {
+	return OCIManager{
+		opts: opts,
+	}, nil
+}
+
+// NewPackage initializes a new package
+func (om OCIManager) NewPackageFromOptions(opts *pack.PackageOptions) ([]pack.Package, error) {
+	om.opts.Log.Infof("initializing new oci package...")
+	p, err := NewPackageFromOptions(opts, om.LocalLayoutDir())
+	return []pack.Package{p}, err
+}
+
+// Options allows you to view the current options.
+func (om OCIManager) Options() *packmanager.PackageManagerOptions {
+	return om.opts
+}
+
+func (om OCIManager) ApplyOptions(pmopts ...packmanager.PackageManagerOption) error {
+	for _, opt := range pmopts {
+		if err := opt(om.opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update is a no-op since the OCI image layout is always local.
+func (om OCIManager) Update() error {
+	return nil
+}
+
+// AddSource is not applicable to the OCI image layout.
+func (om OCIManager) AddSource(source string) error {
+	return nil
+}
+
+// RemoveSource is not applicable to the OCI image layout.
+func (om OCIManager) RemoveSource(source string) error {
+	return nil
+}
+
+// Push the resulting package to the supported registry of the implementation.
+func (om OCIManager) Push(path string, opts *pack.PushPackageOptions) error {
+	return fmt.Errorf("not implemented: pack.OCIManager.Push")
+}
+
+// Pull all images saved within the OCI image layout at the provided path into
+// the working directory.
+func (om OCIManager) Pull(path string, opts *pack.PullPackageOptions) ([]pack.Package, error) {
+	if _, err := om.IsCompatible(path); err != nil {
+		return nil, err
+	}
+
+	layout, err := NewLayout(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := layout.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []pack.Package
+
+	for _, desc := range index.Manifests {
+		name, ok := desc.Annotations[AnnotationName]
+		if !ok {
+			continue
+		}
+
+		ctype := unikraft.ComponentType(desc.Annotations[AnnotationType])
+		if len(ctype) == 0 {
+			ctype = unikraft.ComponentTypeApp
+		}
+
+		p, err := newPackageFromDescriptor(layout, desc,
+			pack.WithName(name),
+			pack.WithType(ctype),
+			pack.WithVersion(desc.Annotations[AnnotationVersion]),
+			pack.WithLogger(om.opts.Log),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.(OCIPackage).pull(opts); err != nil {
+			return nil, fmt.Errorf("could not pull %s: %v", p.Name(), err)
+		}
+
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+func (om OCIManager) From(sub string) (packmanager.PackageManager, error) {
+	return nil, fmt.Errorf("method not applicable to oci manager")
+}
+
+// Catalog returns the images saved within the local OCI image layout which
+// match the provided query
+func (om OCIManager) Catalog(query packmanager.CatalogQuery, popts ...pack.PackageOption) ([]pack.Package, error) {
+	// Nothing has been packaged yet
+	if !IsLayout(om.LocalLayoutDir()) {
+		return nil, nil
+	}
+
+	layout, err := NewLayout(om.LocalLayoutDir())
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := layout.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	var g glob.Glob
+	if len(query.Name) > 0 {
+		g = glob.MustCompile(query.Name)
+	}
+
+	var packages []pack.Package
+
+	for _, desc := range index.Manifests {
+		name, ok := desc.Annotations[AnnotationName]
+		if !ok {
+			continue
+		}
+
+		ctype := unikraft.ComponentType(desc.Annotations[AnnotationType])
+		if len(ctype) == 0 {
+			ctype = unikraft.ComponentTypeApp
+		}
+
+		if len(query.Types) > 0 {
+			found := false
+			for _, t := range query.Types {
+				if ctype == t {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+
+		if len(query.Name) > 0 && !g.Match(name) {
+			continue
+		}
+
+		version := desc.Annotations[AnnotationVersion]
+		if len(query.Version) > 0 && query.Version != version {
+			continue
+		}
+
+		p, err := newPackageFromDescriptor(layout, desc, append(popts,
+			pack.WithName(name),
+			pack.WithType(ctype),
+			pack.WithVersion(version),
+			pack.WithLogger(om.opts.Log),
+		)...)
+		if err != nil {
+			om.opts.Log.Warnf("%v", err)
+			continue
+		}
+
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+// newPackageFromDescriptor is an internal method which instantiates an
+// OCIPackage from an entry of the image layout's index
+func newPackageFromDescriptor(layout *Layout, desc ocispec.Descriptor, popts ...pack.PackageOption) (pack.Package, error) {
+	if arch, ok := desc.Annotations[AnnotationArchitecture]; ok {
+		popts = append(popts, pack.WithArchitecture(arch))
+	}
+
+	if plat, ok := desc.Annotations[AnnotationPlatform]; ok {
+		popts = append(popts, pack.WithPlatform(plat))
+	}
+
+	popts = append(popts,
+		pack.WithRemoteLocation(layout.Root()+"@"+desc.Digest.String()),
+	)
+
+	opts, err := pack.NewPackageOptions(popts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not prepare package from image: %v", err)
+	}
+
+	opts.Sha256 = desc.Digest.Encoded()
+
+	return NewPackageFromOptions(opts, layout.Root())
+}
+
+// IsCompatible checks whether the provided source is an OCI image layout
+func (om OCIManager) IsCompatible(source string) (packmanager.PackageManager, error) {
+	if !IsLayout(source) {
+		return nil, fmt.Errorf("incompatible source")
+	}
+
+	return om, nil
+}
+
+// LocalLayoutDir returns the user configured path to the OCI image layout
+func (om OCIManager) LocalLayoutDir() string {
+	if om.opts.ConfigManager != nil && len(om.opts.ConfigManager.Config.Paths.OCI) > 0 {
+		return om.opts.ConfigManager.Config.Paths.OCI
+	}
+
+	return filepath.Join(config.DataDir(), "oci")
+}
+
+// String returns the name of the implementation.
+func (om OCIManager) String() string {
+	return "oci"
+}
@@ -0,0 +1,489 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package oci
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/archive"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/volume"
+)
+
+type OCIPackage struct {
+	*pack.PackageOptions
+
+	// layout is the path to the OCI image layout where the package is stored
+	layout string
+}
+
+const (
+	OCIContext pack.ContextKey = "oci"
+
+	// DefaultVersion is used as the image tag when the package has no version
+	DefaultVersion = "latest"
+)
+
+// NewPackageFromOptions generates an OCI implementation of the pack.Package
+// construct based on the input options which will be stored within the OCI
+// image layout at the provided path
+func NewPackageFromOptions(opts *pack.PackageOptions, layout string) (pack.Package, error) {
+	if len(opts.Type) == 0 {
+		opts.Type = unikraft.ComponentTypeApp
+	}
+
+	if len(opts.Version) == 0 {
+		opts.Version = DefaultVersion
+	}
+
+	return OCIPackage{
+		PackageOptions: opts,
+		layout:         layout,
+	}, nil
+}
+
+func (op OCIPackage) ApplyOptions(opts ...pack.PackageOption) error {
+	for _, o := range opts {
+		if err := o(op.PackageOptions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op OCIPackage) Options() *pack.PackageOptions {
+	return op.PackageOptions
+}
+
+func (op OCIPackage) Name() string {
+	return op.PackageOptions.Name
+}
+
+func (op OCIPackage) CanonicalName() string {
+	return "oci://" + op.PackageOptions.NameVersion()
+}
+
+// Pack serializes the kernel and, if set, the initramfs of the package into an
+// OCI image which is saved within the OCI image layout
+func (op OCIPackage) Pack() error {
+	kernel, err := op.Kernel()
+	if err != nil {
+		return err
+	}
+
+	layout, err := NewLayout(op.layout)
+	if err != nil {
+		return err
+	}
+
+	annotations := op.annotations()
+	annotations[AnnotationKernelPath] = WellKnownKernelPath
+
+	op.Log().Infof("adding kernel %s", kernel)
+
+	kernelDesc, kernelDiffID, err := writeFileLayer(layout, MediaTypeKernel, kernel, WellKnownKernelPath)
+	if err != nil {
+		return fmt.Errorf("could not add kernel to image: %v", err)
+	}
+
+	layers := []ocispec.Descriptor{kernelDesc}
+	diffIDs := []digest.Digest{kernelDiffID}
+
+	if initrdConfig, err := op.InitrdConfig(); err == nil && initrdConfig != nil {
+		initrd, err := initrdConfig.Build()
+		if err != nil {
+			return fmt.Errorf("could not build initramfs: %v", err)
+		}
+
+		// Remove the archive if it was only temporarily generated
+		if initrd != initrdConfig.Output {
+			defer os.Remove(initrd)
+		}
+
+		op.Log().Infof("adding initramfs %s", initrd)
+
+		initrdDesc, initrdDiffID, err := writeFileLayer(layout, MediaTypeInitrd, initrd, WellKnownInitrdPath)
+		if err != nil {
+			return fmt.Errorf("could not add initramfs to image: %v", err)
+		}
+
+		layers = append(layers, initrdDesc)
+		diffIDs = append(diffIDs, initrdDiffID)
+		annotations[AnnotationInitrdPath] = WellKnownInitrdPath
+	}
+
+	for _, vol := range op.Volumes() {
+		op.Log().Infof("adding volume %s (%s) from %s", vol.Name, vol.Type, vol.Source)
+
+		volumeDesc, volumeDiffID, err := writeVolumeLayer(layout, vol)
+		if err != nil {
+			return fmt.Errorf("could not add volume %s to image: %v", vol.Name, err)
+		}
+
+		layers = append(layers, volumeDesc)
+		diffIDs = append(diffIDs, volumeDiffID)
+	}
+
+	platform := op.platform()
+	created := time.Now().UTC()
+
+	config, err := json.Marshal(ocispec.Image{
+		Created:      &created,
+		Architecture: platform.Architecture,
+		OS:           platform.OS,
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	configDesc, err := layout.WriteBlobBytes(ocispec.MediaTypeImageConfig, config)
+	if err != nil {
+		return fmt.Errorf("could not write image config: %v", err)
+	}
+
+	annotations[ocispec.AnnotationCreated] = created.Format(time.RFC3339)
+
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		Config:      configDesc,
+		Layers:      layers,
+		Annotations: annotations,
+	})
+	if err != nil {
+		return err
+	}
+
+	manifestDesc, err := layout.WriteBlobBytes(ocispec.MediaTypeImageManifest, manifest)
+	if err != nil {
+		return fmt.Errorf("could not write image manifest: %v", err)
+	}
+
+	manifestDesc.Platform = platform
+	manifestDesc.Annotations = annotations
+	manifestDesc.Annotations[ocispec.AnnotationRefName] = op.NameVersion()
+
+	if err := layout.AddManifest(manifestDesc); err != nil {
+		return fmt.Errorf("could not save image to layout: %v", err)
+	}
+
+	op.Log().Infof("saved %s as %s in %s", op.NameVersion(), manifestDesc.Digest, layout.Root())
+
+	return nil
+}
+
+// annotations returns the annotations describing the package which are common
+// to the image manifest and its descriptor
+func (op OCIPackage) annotations() map[string]string {
+	annotations := map[string]string{
+		ocispec.AnnotationTitle:   op.PackageOptions.Name,
+		ocispec.AnnotationVersion: op.PackageOptions.Version,
+		AnnotationName:            op.PackageOptions.Name,
+		AnnotationVersion:         op.PackageOptions.Version,
+		AnnotationType:            string(op.PackageOptions.Type),
+	}
+
+	if op.PackageOptions.Architecture != nil {
+		annotations[AnnotationArchitecture] = *op.PackageOptions.Architecture
+	}
+
+	if op.PackageOptions.Platform != nil {
+		annotations[AnnotationPlatform] = *op.PackageOptions.Platform
+	}
+
+	return annotations
+}
+
+// platform returns the OCI platform of the package.  Unikraft unikernels are
+// not bound to a host operating system, instead the "OS" of the image is the
+// Unikraft platform (e.g. kvm or xen) it was built for.
+func (op OCIPackage) platform() *ocispec.Platform {
+	platform := &ocispec.Platform{}
+
+	if op.PackageOptions.Architecture != nil {
+		platform.Architecture = *op.PackageOptions.Architecture
+	}
+
+	if op.PackageOptions.Platform != nil {
+		platform.OS = *op.PackageOptions.Platform
+	}
+
+	return platform
+}
+
+// Compatible checks whether the provided path is an OCI image layout
+func (op OCIPackage) Compatible(ref string) bool {
+	return IsLayout(ref)
+}
+
+// Pull extracts the layers of the package's image from the OCI image layout
+// into the location of the component within the working directory
+func (op OCIPackage) Pull(opts ...pack.PullPackageOption) error {
+	popts, err := pack.NewPullPackageOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	return op.pull(popts)
+}
+
+// pull is an internal method which performs the pull of the package with
+// already instantiated options
+func (op OCIPackage) pull(popts *pack.PullPackageOptions) error {
+	if len(popts.Workdir()) == 0 {
+		return fmt.Errorf("cannot pull %s without a working directory", op.NameVersion())
+	}
+
+	if !IsLayout(op.layout) {
+		return fmt.Errorf("%s is not an OCI image layout", op.layout)
+	}
+
+	layout, err := NewLayout(op.layout)
+	if err != nil {
+		return err
+	}
+
+	desc, err := op.descriptor(layout)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := layout.Manifest(desc)
+	if err != nil {
+		return fmt.Errorf("could not read image manifest: %v", err)
+	}
+
+	dest, err := unikraft.PlaceComponent(popts.Workdir(), op.Type, op.Name())
+	if err != nil {
+		return fmt.Errorf("could not place component: %v", err)
+	}
+
+	op.Log().Infof("pulling %s from %s into %s", op.NameVersion(), layout.Root(), dest)
+
+	for i, layer := range manifest.Layers {
+		if popts.CalculateChecksum() {
+			if _, err := layout.ReadBlob(layer); err != nil {
+				return fmt.Errorf("could not verify layer %s: %v", layer.Digest, err)
+			}
+		}
+
+		if err := archive.Untar(layout.BlobPath(layer.Digest), dest); err != nil {
+			return fmt.Errorf("could not extract layer %s: %v", layer.Digest, err)
+		}
+
+		popts.OnProgress(float64(i+1) / float64(len(manifest.Layers)))
+	}
+
+	return nil
+}
+
+// descriptor returns the entry of the image layout's index which represents
+// the package, either by its digest or by its reference name and platform
+func (op OCIPackage) descriptor(layout *Layout) (ocispec.Descriptor, error) {
+	index, err := layout.Index()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	ref := ocispec.Descriptor{
+		Platform: op.platform(),
+		Annotations: map[string]string{
+			ocispec.AnnotationRefName: op.NameVersion(),
+		},
+	}
+
+	for _, desc := range index.Manifests {
+		if len(op.Sha256) > 0 {
+			if desc.Digest.Encoded() == op.Sha256 {
+				return desc, nil
+			}
+		} else if sameReference(desc, ref) {
+			return desc, nil
+		}
+	}
+
+	return ocispec.Descriptor{}, fmt.Errorf("could not find %s in %s", op.NameVersion(), layout.Root())
+}
+
+func (op OCIPackage) String() string {
+	return "oci"
+}
+
+// writeFileLayer wraps the file at the provided path into a single-entry
+// tarball at the given destination path and saves it as a layer within the
+// image layout.  The descriptor of the layer and its diff ID are returned.
+func writeFileLayer(layout *Layout, mediaType, path, dest string) (ocispec.Descriptor, digest.Digest, error) {
+	return writeLayer(layout, mediaType, dest, func(tw *tar.Writer) error {
+		return writeTarEntry(tw, path, dest)
+	})
+}
+
+// writeVolumeLayer saves the contents of the volume as a layer within the image
+// layout.  The contents are placed in a directory named after the volume.
+func writeVolumeLayer(layout *Layout, vol volume.VolumeConfig) (ocispec.Descriptor, digest.Digest, error) {
+	if err := vol.Validate(); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	dest := WellKnownVolumesPath + "/" + vol.Name
+
+	var desc ocispec.Descriptor
+	var diffID digest.Digest
+	var err error
+
+	switch vol.Type {
+	case volume.VolumeType9pfs:
+		desc, diffID, err = writeLayer(layout, MediaTypeVolume9pfs, dest, func(tw *tar.Writer) error {
+			return filepath.Walk(vol.Source, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				rel, err := filepath.Rel(vol.Source, path)
+				if err != nil {
+					return err
+				}
+
+				return writeTarEntry(tw, path, dest+"/"+filepath.ToSlash(rel))
+			})
+		})
+	case volume.VolumeTypeRaw:
+		desc, diffID, err = writeLayer(layout, MediaTypeVolumeRaw, dest, func(tw *tar.Writer) error {
+			return writeTarEntry(tw, vol.Source, dest)
+		})
+	}
+	if err != nil {
+		return desc, diffID, err
+	}
+
+	desc.Annotations[AnnotationVolumeName] = vol.Name
+	desc.Annotations[AnnotationVolumeType] = string(vol.Type)
+
+	return desc, diffID, nil
+}
+
+// writeLayer streams the tarball produced by the provided function into a
+// layer within the image layout.  The descriptor of the layer and its diff ID
+// are returned.
+func writeLayer(layout *Layout, mediaType, title string, write func(*tar.Writer) error) (ocispec.Descriptor, digest.Digest, error) {
+	reader, writer := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(writer)
+
+		if err := write(tw); err != nil {
+			writer.CloseWithError(err)
+			return
+		}
+
+		writer.CloseWithError(tw.Close())
+	}()
+
+	desc, err := layout.WriteBlob(mediaType, reader)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+
+	desc.Annotations = map[string]string{
+		ocispec.AnnotationTitle: title,
+	}
+
+	// Layers are not compressed and so the diff ID is the digest of the layer
+	return desc, desc.Digest, nil
+}
+
+// writeTarEntry adds the file or directory at the provided path to the tarball
+// at the given destination path
+func writeTarEntry(tw *tar.Writer, path, dest string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	// Use a fixed modification time and ownership such that layers are
+	// reproducible
+	header.Name = dest
+	header.ModTime = time.Unix(0, 0)
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/internal/logger"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/pack"
+)
+
+func newTestPackage(t *testing.T, layout string, popts ...pack.PackageOption) pack.Package {
+	t.Helper()
+
+	kernel := filepath.Join(t.TempDir(), "helloworld_kvm-x86_64")
+	if err := ioutil.WriteFile(kernel, []byte("not really a kernel"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := pack.NewPackageOptions(append([]pack.PackageOption{
+		pack.WithName("helloworld"),
+		pack.WithVersion("0.10.0"),
+		pack.WithArchitecture("x86_64"),
+		pack.WithPlatform("kvm"),
+		pack.WithKernel(kernel),
+		pack.WithLogger(logger.NewLogger(ioutil.Discard, iostreams.NewColorScheme(false, false, false))),
+	}, popts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPackageFromOptions(opts, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+// layerFile returns the name and contents of the single file within a layer
+func layerFile(t *testing.T, layout *Layout, desc ocispec.Descriptor) (string, []byte) {
+	t.Helper()
+
+	raw, err := layout.ReadBlob(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(bytes.NewReader(raw))
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return header.Name, contents
+}
+
+func TestPackKernel(t *testing.T) {
+	root := t.TempDir()
+	p := newTestPackage(t, root)
+
+	if err := p.Pack(); err != nil {
+		t.Fatalf("could not pack: %v", err)
+	}
+
+	if !IsLayout(root) {
+		t.Fatalf("expected %s to be an image layout", root)
+	}
+
+	layout, err := NewLayout(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := layout.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(index.Manifests) != 1 {
+		t.Fatalf("expected 1 manifest in index, got %d", len(index.Manifests))
+	}
+
+	desc := index.Manifests[0]
+	if ref := desc.Annotations[ocispec.AnnotationRefName]; ref != "helloworld:0.10.0" {
+		t.Errorf("unexpected reference name: %s", ref)
+	}
+
+	if desc.Platform == nil || desc.Platform.Architecture != "x86_64" || desc.Platform.OS != "kvm" {
+		t.Errorf("unexpected platform: %+v", desc.Platform)
+	}
+
+	manifest, err := layout.Manifest(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Layers) != 1 {
+		t.Fatalf("expected 1 layer, got %d", len(manifest.Layers))
+	}
+
+	if manifest.Layers[0].MediaType != MediaTypeKernel {
+		t.Errorf("unexpected kernel media type: %s", manifest.Layers[0].MediaType)
+	}
+
+	name, contents := layerFile(t, layout, manifest.Layers[0])
+	if name != WellKnownKernelPath || string(contents) != "not really a kernel" {
+		t.Errorf("unexpected kernel layer contents: %s: %q", name, contents)
+	}
+
+	// Packing the same package again should replace the entry in the index
+	if err := p.Pack(); err != nil {
+		t.Fatalf("could not re-pack: %v", err)
+	}
+
+	index, err = layout.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(index.Manifests) != 1 {
+		t.Fatalf("expected 1 manifest in index after re-pack, got %d", len(index.Manifests))
+	}
+}
+
+func TestPackInitrd(t *testing.T) {
+	rootfs := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(rootfs, "hello.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(rootfs, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ird, err := initrd.ParseInitrdConfig(rootfs, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	p := newTestPackage(t, root, pack.WithInitrdConfig(ird))
+
+	if err := p.Pack(); err != nil {
+		t.Fatalf("could not pack: %v", err)
+	}
+
+	layout, err := NewLayout(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := layout.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := layout.Manifest(index.Manifests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.Layers) != 2 {
+		t.Fatalf("expected 2 layers, got %d", len(manifest.Layers))
+	}
+
+	if manifest.Annotations[AnnotationInitrdPath] != WellKnownInitrdPath {
+		t.Errorf("missing initrd annotation")
+	}
+
+	name, contents := layerFile(t, layout, manifest.Layers[1])
+	if name != WellKnownInitrdPath {
+		t.Errorf("unexpected initrd path: %s", name)
+	}
+
+	reader, err := ird.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		found[header.Name] = true
+	}
+
+	for _, want := range []string{"hello.txt", "etc"} {
+		if !found[want] {
+			t.Errorf("expected %s in initramfs, got %v", want, found)
+		}
+	}
+}
+
+func TestPull(t *testing.T) {
+	root := t.TempDir()
+
+	if err := newTestPackage(t, root).Pack(); err != nil {
+		t.Fatalf("could not pack: %v", err)
+	}
+
+	// Pull the package as it would be found within the layout
+	p := newTestPackage(t, root)
+
+	workdir := t.TempDir()
+	if err := p.Pull(
+		pack.WithPullWorkdir(workdir),
+		pack.WithPullChecksum(true),
+	); err != nil {
+		t.Fatalf("could not pull: %v", err)
+	}
+
+	kernel := filepath.Join(workdir, ".unikraft", "apps", "helloworld", WellKnownKernelPath)
+	contents, err := ioutil.ReadFile(kernel)
+	if err != nil {
+		t.Fatalf("expected kernel to be pulled: %v", err)
+	}
+
+	if string(contents) != "not really a kernel" {
+		t.Errorf("unexpected kernel contents: %q", contents)
+	}
+
+	// Packages which are not saved in the layout cannot be pulled
+	missing := newTestPackage(t, root, pack.WithVersion("0.11.0"))
+	if err := missing.Pull(pack.WithPullWorkdir(t.TempDir())); err == nil {
+		t.Error("expected pulling an unknown package to fail")
+	}
+}
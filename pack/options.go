@@ -35,39 +35,45 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 
 	"kraftkit.sh/initrd"
 	"kraftkit.sh/log"
 	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/volume"
 	"kraftkit.sh/utils"
 )
 
 // PackageOptions contains configuration for the Package
 type PackageOptions struct {
 	// Name of the package
-	Name string
+	Name string `json:"name" yaml:"name"`
 
 	// Type of package
-	Type unikraft.ComponentType
+	Type unikraft.ComponentType `json:"type" yaml:"type"`
 
 	// Version of the package
-	Version string
+	Version string `json:"version" yaml:"version"`
 
 	// Architecture of the package if applicable
-	Architecture *string
+	Architecture *string `json:"architecture,omitempty" yaml:"architecture,omitempty"`
 
 	// Platform of the package if applicable
-	Platform *string
+	Platform *string `json:"platform,omitempty" yaml:"platform,omitempty"`
 
 	// Metadata represents other items that did not have appropriate annotations
-	Metadata map[string]interface{}
+	Metadata map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 
 	// RemoteLocation contains the remote location of the package.
-	RemoteLocation string
+	RemoteLocation string `json:"remote_location,omitempty" yaml:"remote_location,omitempty"`
 
-	// Sha256
-	Sha256 string
+	// Sha256 is the hex-encoded SHA256 checksum of the package's resource
+	Sha256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+
+	// Resolved is the exact version which the version of the package resolves
+	// to, e.g. the latest release of a channel or a Git commit SHA
+	Resolved string `json:"resolved,omitempty" yaml:"resolved,omitempty"`
 
 	// Access to a logger
 	log log.Logger
@@ -202,6 +208,34 @@ func (po *PackageOptions) Kernel() (string, error) {
 	return kernel, nil
 }
 
+// WithSource sets the metadata attribute path to the source directory of the
+// component which is to be packaged
+func WithSource(source string) PackageOption {
+	return func(opts *PackageOptions) error {
+		if len(source) == 0 {
+			return fmt.Errorf("path to source cannot be empty")
+		}
+		if f, err := os.Stat(source); err != nil || !f.IsDir() {
+			return fmt.Errorf("path to source is not a directory: %s", source)
+		}
+
+		opts.Metadata["source"] = source
+
+		return nil
+	}
+}
+
+// Source returns the metadata attribute path to the source directory of the
+// component
+func (po *PackageOptions) Source() (string, error) {
+	source, ok := po.Metadata["source"].(string)
+	if !ok {
+		return "", fmt.Errorf("source not set")
+	}
+
+	return source, nil
+}
+
 // WithInitrdConfig sets the metadata attribute with the interface representing
 // initrd configuration
 func WithInitrdConfig(initrdConfig *initrd.InitrdConfig) PackageOption {
@@ -227,6 +261,34 @@ func (po *PackageOptions) InitrdConfig() (*initrd.InitrdConfig, error) {
 	return ird, nil
 }
 
+// WithVolumes sets the metadata attribute with the volumes which are bundled
+// within the package
+func WithVolumes(volumes ...volume.VolumeConfig) PackageOption {
+	return func(opts *PackageOptions) error {
+		for _, vol := range volumes {
+			if err := vol.Validate(); err != nil {
+				return err
+			}
+		}
+
+		if len(volumes) == 0 {
+			return nil
+		}
+
+		existing, _ := opts.Metadata["volumes"].([]volume.VolumeConfig)
+		opts.Metadata["volumes"] = append(existing, volumes...)
+
+		return nil
+	}
+}
+
+// Volumes returns the metadata attribute with the volumes which are bundled
+// within the package
+func (po *PackageOptions) Volumes() []volume.VolumeConfig {
+	volumes, _ := po.Metadata["volumes"].([]volume.VolumeConfig)
+	return volumes
+}
+
 // WithRemoteLocation sets the location of the package at its remote registry
 func WithRemoteLocation(location string) PackageOption {
 	return func(opts *PackageOptions) error {
@@ -235,6 +297,24 @@ func WithRemoteLocation(location string) PackageOption {
 	}
 }
 
+// WithSha256 sets the expected hex-encoded SHA256 checksum of the package's
+// resource
+func WithSha256(sum string) PackageOption {
+	return func(opts *PackageOptions) error {
+		opts.Sha256 = sum
+		return nil
+	}
+}
+
+// WithResolvedVersion sets the exact version which the version of the package
+// resolves to
+func WithResolvedVersion(resolved string) PackageOption {
+	return func(opts *PackageOptions) error {
+		opts.Resolved = resolved
+		return nil
+	}
+}
+
 func WithLogger(l log.Logger) PackageOption {
 	return func(opts *PackageOptions) error {
 		opts.log = l
@@ -265,6 +345,7 @@ type PullPackageOptions struct {
 	workdir           string
 	log               log.Logger
 	useCache          bool
+	httpClient        *http.Client
 }
 
 // OnProgress calls (if set) an embedded progress function which can be used to
@@ -297,6 +378,16 @@ func (ppo *PullPackageOptions) UseCache() bool {
 	return ppo.useCache
 }
 
+// HTTPClient returns the HTTP client which should be used to retrieve remote
+// resources, defaulting to http.DefaultClient.
+func (ppo *PullPackageOptions) HTTPClient() *http.Client {
+	if ppo.httpClient == nil {
+		return http.DefaultClient
+	}
+
+	return ppo.httpClient
+}
+
 type PullPackageOption func(opts *PullPackageOptions) error
 
 // NewPullPackageOptions creates PullPackageOptions
@@ -393,3 +484,100 @@ func WithPullCache(cache bool) PullPackageOption {
 		return nil
 	}
 }
+
+// WithPullHTTPClient sets the HTTP client used to retrieve remote resources
+// such that configured headers, proxies and transports apply
+func WithPullHTTPClient(client *http.Client) PullPackageOption {
+	return func(opts *PullPackageOptions) error {
+		opts.httpClient = client
+		return nil
+	}
+}
+
+type PushPackageOptions struct {
+	destination string
+	resourceURL string
+	log         log.Logger
+	httpClient  *http.Client
+}
+
+// Destination returns the location which the package should be pushed to
+func (ppo *PushPackageOptions) Destination() string {
+	return ppo.destination
+}
+
+// ResourceURL returns the base URL under which pushed resources are to be
+// retrieved from, if different from the destination
+func (ppo *PushPackageOptions) ResourceURL() string {
+	return ppo.resourceURL
+}
+
+// Log returns the available logger
+func (ppo *PushPackageOptions) Log() log.Logger {
+	return ppo.log
+}
+
+// HTTPClient returns the HTTP client which should be used to upload to remote
+// destinations, defaulting to http.DefaultClient.
+func (ppo *PushPackageOptions) HTTPClient() *http.Client {
+	if ppo.httpClient == nil {
+		return http.DefaultClient
+	}
+
+	return ppo.httpClient
+}
+
+type PushPackageOption func(opts *PushPackageOptions) error
+
+// NewPushPackageOptions creates PushPackageOptions
+func NewPushPackageOptions(opts ...PushPackageOption) (*PushPackageOptions, error) {
+	options := &PushPackageOptions{}
+
+	for _, o := range opts {
+		err := o(options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.destination) == 0 {
+		return nil, fmt.Errorf("push destination cannot be empty")
+	}
+
+	return options, nil
+}
+
+// WithPushDestination sets the location where the package should be pushed to
+func WithPushDestination(destination string) PushPackageOption {
+	return func(opts *PushPackageOptions) error {
+		opts.destination = destination
+		return nil
+	}
+}
+
+// WithPushResourceURL sets the base URL under which the pushed resources are
+// publicly accessible.  This is useful when the destination is, for example, a
+// local directory which is served by a web server.
+func WithPushResourceURL(url string) PushPackageOption {
+	return func(opts *PushPackageOptions) error {
+		opts.resourceURL = url
+		return nil
+	}
+}
+
+// WithPushLogger set the use of a logger
+func WithPushLogger(l log.Logger) PushPackageOption {
+	return func(opts *PushPackageOptions) error {
+		opts.log = l
+		return nil
+	}
+}
+
+// WithPushHTTPClient sets the HTTP client used to upload to remote destinations
+// such that configured headers, proxies and transports apply
+func WithPushHTTPClient(client *http.Client) PushPackageOption {
+	return func(opts *PushPackageOptions) error {
+		opts.httpClient = client
+		return nil
+	}
+}
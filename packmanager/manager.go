@@ -47,7 +47,7 @@ type PackageManager interface {
 	Update() error
 
 	// Push a package to the supported registry of the implementation.
-	Push(string) error
+	Push(string, *pack.PushPackageOptions) error
 
 	// Pull package(s) from the supported registry of the implementation.
 	Pull(string, *pack.PullPackageOptions) ([]pack.Package, error)
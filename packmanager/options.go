@@ -35,10 +35,8 @@ import (
 	"context"
 
 	"kraftkit.sh/config"
-	"kraftkit.sh/utils"
 
 	"kraftkit.sh/log"
-	"kraftkit.sh/unikraft"
 )
 
 // PackageManagerOptions contains configuration for the Package
@@ -89,40 +87,3 @@ func WithConfigManager(cm *config.ConfigManager) PackageManagerOption {
 		return nil
 	}
 }
-
-type CatalogQuery struct {
-	Types   []unikraft.ComponentType
-	Name    string
-	Version string
-}
-
-func NewCatalogQuery(s string) CatalogQuery {
-	query := CatalogQuery{}
-	return query
-}
-
-func (cq CatalogQuery) String() string {
-	s := ""
-	if len(cq.Types) == 1 {
-		s += string(cq.Types[0]) + "-"
-	} else if len(cq.Types) > 1 {
-		var types []string
-		for _, t := range cq.Types {
-			types = append(types, string(t))
-		}
-
-		s += "{" + utils.ListJoinStr(types, ", ") + "}-"
-	}
-
-	if len(cq.Name) > 0 {
-		s += cq.Name
-	} else {
-		s += "*"
-	}
-
-	if len(cq.Version) > 0 {
-		s += ":" + cq.Version
-	}
-
-	return s
-}
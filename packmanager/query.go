@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package packmanager
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/utils"
+)
+
+// CatalogQuery selects packages from the catalog of a package manager
+type CatalogQuery struct {
+	// Types of the packages, any type is accepted when empty
+	Types []unikraft.ComponentType
+
+	// Name of the packages, which is either exact or a glob pattern, e.g.
+	// `*ssl*`.  When Fuzzy is set, the name is matched approximately instead.
+	Name string
+
+	// Version, channel or version constraint of the packages
+	Version string
+
+	// Tags which the packages must all carry
+	Tags []string
+
+	// Fuzzy matches the name approximately against the names, tags and keywords
+	// of packages and ranks the results from best to worst match
+	Fuzzy bool
+}
+
+// NewCatalogQuery parses a query consisting of whitespace-separated terms.  A
+// term is either a qualifier, i.e. `type:app`, `tag:network`, `name:nginx` or
+// `version:>=1.0`, or a package in the form `[TYPE/]NAME[:VERSION]` where the
+// name may be a glob pattern, e.g. `lib/*ssl*:>=1.0`.  Version constraints
+// must not contain spaces and separate their comparisons with commas instead.
+func NewCatalogQuery(s string) (CatalogQuery, error) {
+	query := CatalogQuery{}
+
+	for _, term := range strings.Fields(s) {
+		key, value := "", term
+		if i := strings.Index(term, ":"); i > 0 {
+			switch term[:i] {
+			case "type", "tag", "name", "version":
+				key, value = term[:i], term[i+1:]
+			}
+		}
+
+		if len(key) > 0 && len(value) == 0 {
+			return query, fmt.Errorf("missing value for %s in query", key)
+		}
+
+		switch key {
+		case "type":
+			t, ok := unikraft.ComponentTypes()[strings.ToLower(value)]
+			if !ok {
+				return query, fmt.Errorf("unknown component type: %s", value)
+			}
+
+			query.Types = append(query.Types, t)
+
+		case "tag":
+			query.Tags = append(query.Tags, value)
+
+		case "name":
+			if len(query.Name) > 0 {
+				return query, fmt.Errorf("query contains more than one name: %s and %s", query.Name, value)
+			}
+
+			query.Name = value
+
+		case "version":
+			query.Version = value
+
+		default:
+			t, n, v, err := unikraft.GuessTypeNameVersion(term)
+			if err != nil {
+				return query, err
+			}
+
+			if t != unikraft.ComponentTypeUnknown {
+				query.Types = append(query.Types, t)
+			}
+
+			if len(n) > 0 {
+				if len(query.Name) > 0 {
+					return query, fmt.Errorf("query contains more than one name: %s and %s", query.Name, n)
+				}
+
+				query.Name = n
+			}
+
+			if len(v) > 0 {
+				query.Version = v
+			}
+		}
+	}
+
+	return query, nil
+}
+
+func (cq CatalogQuery) String() string {
+	s := ""
+	if len(cq.Types) == 1 {
+		s += string(cq.Types[0]) + "-"
+	} else if len(cq.Types) > 1 {
+		var types []string
+		for _, t := range cq.Types {
+			types = append(types, string(t))
+		}
+
+		s += "{" + utils.ListJoinStr(types, ", ") + "}-"
+	}
+
+	if len(cq.Name) > 0 {
+		s += cq.Name
+	} else {
+		s += "*"
+	}
+
+	if len(cq.Version) > 0 {
+		s += ":" + cq.Version
+	}
+
+	for _, tag := range cq.Tags {
+		s += " tag:" + tag
+	}
+
+	return s
+}
+
+// IsGlob returns whether the name is a glob pattern rather than an exact name
+func IsGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[{")
+}
+
+// FuzzyScore scores how well the pattern approximately matches the text,
+// ignoring case.  All characters of the pattern must appear in order within
+// the text.  Consecutive characters and characters at the start of a word are
+// rewarded, whereas characters of the text which are not matched are
+// penalised such that closer matches rank higher.  It returns false if the
+// pattern does not match.
+func FuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	matched := 0
+	last := -2
+
+	for i := 0; i < len(t) && matched < len(p); i++ {
+		if t[i] != p[matched] {
+			continue
+		}
+
+		score += 10
+
+		if i == last+1 {
+			score += 15
+		}
+
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 20
+		}
+
+		last = i
+		matched++
+	}
+
+	if matched < len(p) {
+		return 0, false
+	}
+
+	score -= len(t) - len(p)
+
+	if len(t) == len(p) {
+		score += 100
+	}
+
+	return score, true
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package packmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+)
+
+func TestNewCatalogQuery(t *testing.T) {
+	for input, expected := range map[string]CatalogQuery{
+		"lib/*ssl*:>=1.0": {
+			Types:   []unikraft.ComponentType{unikraft.ComponentTypeLib},
+			Name:    "*ssl*",
+			Version: ">=1.0",
+		},
+		"type:app tag:network  tag:http": {
+			Types: []unikraft.ComponentType{unikraft.ComponentTypeApp},
+			Tags:  []string{"network", "http"},
+		},
+		"unikraft:stable": {
+			Types:   []unikraft.ComponentType{unikraft.ComponentTypeCore},
+			Name:    "unikraft",
+			Version: "stable",
+		},
+		"name:nginx version:^1.2": {
+			Name:    "nginx",
+			Version: "^1.2",
+		},
+	} {
+		query, err := NewCatalogQuery(input)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(query, expected) {
+			t.Errorf("%q: expected %+v, got %+v", input, expected, query)
+		}
+	}
+
+	for _, input := range []string{"type:unknown", "tag:", "musl nginx"} {
+		if _, err := NewCatalogQuery(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := FuzzyScore("ssl", "lwip"); ok {
+		t.Errorf("expected no match")
+	}
+
+	exact, _ := FuzzyScore("musl", "musl")
+	prefix, _ := FuzzyScore("mus", "musl")
+	scattered, _ := FuzzyScore("msl", "musl")
+	word, _ := FuzzyScore("ssl", "lib-ssl")
+	inner, _ := FuzzyScore("ssl", "openssl")
+
+	if !(exact > prefix && prefix > scattered) {
+		t.Errorf("expected exact > prefix > scattered, got %d, %d, %d", exact, prefix, scattered)
+	}
+
+	if word <= inner {
+		t.Errorf("expected match at start of word to rank higher, got %d <= %d", word, inner)
+	}
+}
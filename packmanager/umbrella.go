@@ -153,8 +153,14 @@ func (um UmbrellaManager) RemoveSource(source string) error {
 }
 
 // Push the resulting package to the supported registry of the implementation.
-func (um UmbrellaManager) Push(path string) error {
-	return fmt.Errorf("not implemented: pack.UmbrellaManager.Push")
+func (um UmbrellaManager) Push(path string, opts *pack.PushPackageOptions) error {
+	manager, err := um.IsCompatible(path)
+	if err != nil {
+		return err
+	}
+
+	um.opts.Log.Tracef("pushing %s via %s...", path, manager.String())
+	return manager.Push(path, opts)
 }
 
 // Pull a package from the support registry of the implementation.
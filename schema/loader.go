@@ -29,6 +29,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"kraftkit.sh/log"
+	"kraftkit.sh/network"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
@@ -37,6 +38,7 @@ import (
 	"kraftkit.sh/unikraft/core"
 	"kraftkit.sh/unikraft/lib"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 )
 
 const (
@@ -197,6 +199,8 @@ func Load(details config.ConfigDetails, options ...func(*LoaderOptions)) (*app.A
 		Unikraft:      model.Unikraft,
 		Libraries:     model.Libraries,
 		Targets:       model.Targets,
+		Networks:      model.Networks,
+		Volumes:       model.Volumes,
 		Configuration: details.Configuration,
 		Extensions:    model.Extensions,
 	}
@@ -257,6 +261,16 @@ func loadSections(filename string, cfgIface map[string]interface{}, configDetail
 		return nil, err
 	}
 
+	cfg.Networks, err = LoadNetworks(getSectionMap(cfgIface, "networks"), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Volumes, err = LoadVolumes(getSectionMap(cfgIface, "volumes"), configDetails, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	extensions := getSectionMap(cfgIface, "extensions")
 	if len(extensions) > 0 {
 		cfg.Extensions = extensions
@@ -268,6 +282,15 @@ func loadSections(filename string, cfgIface map[string]interface{}, configDetail
 // LoadUnikraft produces a UnikraftConfig from a kraft file Dict the source Dict
 // is not validated if directly used. Use Load() to enable validation
 func LoadUnikraft(source interface{}, opts *LoaderOptions) (core.UnikraftConfig, error) {
+	// The short syntax only specifies the version of the core, e.g.
+	// `unikraft: stable`
+	switch value := source.(type) {
+	case string, int, float64:
+		source = map[string]interface{}{
+			"version": fmt.Sprint(value),
+		}
+	}
+
 	// Populate the unikraft component with shared `ComponentConfig` attributes
 	base := component.ComponentConfig{}
 	err := Transform(source, &base)
@@ -379,8 +402,20 @@ func LoadTargets(source []interface{}, configDetails config.ConfigDetails, outdi
 			target.ComponentConfig.Name = projectName
 		}
 
+		// Each target is built in its own directory such that targets do not share
+		// their configuration or object files
+		if target.OutDir == "" {
+			if target.ComponentConfig.Name != projectName {
+				target.OutDir = filepath.Join(outdir, target.ComponentConfig.Name)
+			} else {
+				target.OutDir = filepath.Join(outdir, target.ArchPlatString())
+			}
+		} else if opts.ResolvePaths {
+			target.OutDir = configDetails.RelativePath(target.OutDir)
+		}
+
 		if target.Kernel == "" {
-			target.Kernel = filepath.Join(outdir, fmt.Sprintf(
+			target.Kernel = filepath.Join(target.OutDir, fmt.Sprintf(
 				// The filename pattern below is a baked in assumption within Unikraft's
 				// build system, see for example `KVM_IMAGE`.  TODO: This format should
 				// likely be upstreamed into the core as a generic for all platforms.
@@ -408,6 +443,52 @@ func LoadTargets(source []interface{}, configDetails config.ConfigDetails, outdi
 	return targets, nil
 }
 
+// LoadNetworks produces a NetworkConfig map from a kraft file Dict the source
+// Dict is not validated if directly used. Use Load() to enable validation
+func LoadNetworks(source map[string]interface{}, opts *LoaderOptions) (map[string]network.NetworkConfig, error) {
+	networks := make(map[string]network.NetworkConfig)
+	if err := Transform(source, &networks); err != nil {
+		return networks, err
+	}
+
+	for name, net := range networks {
+		net.Name = name
+
+		if err := net.Validate(); err != nil {
+			return networks, err
+		}
+
+		networks[name] = net
+	}
+
+	return networks, nil
+}
+
+// LoadVolumes produces a VolumeConfig map from a kraft file Dict the source
+// Dict is not validated if directly used. Use Load() to enable validation
+func LoadVolumes(source map[string]interface{}, configDetails config.ConfigDetails, opts *LoaderOptions) (map[string]volume.VolumeConfig, error) {
+	volumes := make(map[string]volume.VolumeConfig)
+	if err := Transform(source, &volumes); err != nil {
+		return volumes, err
+	}
+
+	for name, vol := range volumes {
+		vol.Name = name
+
+		if opts.ResolvePaths && len(vol.Source) > 0 {
+			vol.Source = configDetails.RelativePath(vol.Source)
+		}
+
+		if len(vol.Type) == 0 {
+			vol.Type = volume.DetectType(vol.Source)
+		}
+
+		volumes[name] = vol
+	}
+
+	return volumes, nil
+}
+
 func getSection(config map[string]interface{}, key string) interface{} {
 	section, ok := config[key]
 	if !ok {
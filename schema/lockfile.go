@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/component"
+)
+
+// LockfileName is the name of the file, next to the Kraftfile, which records
+// the exact versions of the components of a project
+const LockfileName = "Kraftfile.lock"
+
+// lockfileHeader is written at the top of every lockfile
+const lockfileHeader = "# This file is generated by kraft, do not edit it manually.\n"
+
+// Lockfile records the exact version which each component of a project
+// resolved to such that subsequent pulls retrieve identical sources
+type Lockfile struct {
+	Components []LockedComponent `yaml:"components"`
+}
+
+// LockedComponent is the resolved state of a single component
+type LockedComponent struct {
+	// Type of the component
+	Type unikraft.ComponentType `yaml:"type"`
+
+	// Name of the component
+	Name string `yaml:"name"`
+
+	// Version of the component as requested in the Kraftfile
+	Version string `yaml:"version"`
+
+	// Resolved is the exact version the requested version resolved to, or the
+	// Git commit SHA when the component was pulled via Git
+	Resolved string `yaml:"resolved,omitempty"`
+
+	// Source is the URL of the tarball or Git repository the component was
+	// pulled from
+	Source string `yaml:"source,omitempty"`
+
+	// Sha256 is the hex-encoded checksum of the tarball
+	Sha256 string `yaml:"sha256,omitempty"`
+}
+
+// LockfilePath returns the path to the lockfile of the project in the provided
+// working directory
+func LockfilePath(workdir string) string {
+	return filepath.Join(workdir, LockfileName)
+}
+
+// NewLockfileFromFile reads the lockfile at the provided path.  A missing
+// lockfile results in an empty Lockfile.
+func NewLockfileFromFile(path string) (*Lockfile, error) {
+	lockfile := &Lockfile{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lockfile, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read lockfile: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, lockfile); err != nil {
+		return nil, fmt.Errorf("could not parse lockfile %s: %v", path, err)
+	}
+
+	return lockfile, nil
+}
+
+// Find returns the locked component with the provided type and name
+func (l *Lockfile) Find(t unikraft.ComponentType, name string) (LockedComponent, bool) {
+	for _, c := range l.Components {
+		if c.Type == t && c.Name == name {
+			return c, true
+		}
+	}
+
+	return LockedComponent{}, false
+}
+
+// Set adds the locked component or replaces the existing entry with the same
+// type and name
+func (l *Lockfile) Set(component LockedComponent) {
+	for i, c := range l.Components {
+		if c.Type == component.Type && c.Name == component.Name {
+			l.Components[i] = component
+			return
+		}
+	}
+
+	l.Components = append(l.Components, component)
+}
+
+// SetPackage records the resolved state of a package which was pulled for the
+// version requested by the project
+func (l *Lockfile) SetPackage(version string, p pack.Package) {
+	popts := p.Options()
+
+	l.Set(LockedComponent{
+		Type:     popts.Type,
+		Name:     popts.Name,
+		Version:  version,
+		Resolved: popts.Resolved,
+		Source:   popts.RemoteLocation,
+		Sha256:   popts.Sha256,
+	})
+}
+
+// Retain removes all locked components which are not part of the provided
+// components, e.g. libraries which have been removed from the project
+func (l *Lockfile) Retain(components []component.Component) {
+	var retained []LockedComponent
+	for _, lc := range l.Components {
+		for _, c := range components {
+			if c.Type() == lc.Type && c.Name() == lc.Name {
+				retained = append(retained, lc)
+				break
+			}
+		}
+	}
+
+	l.Components = retained
+}
+
+// Save writes the lockfile to the provided path.  Components are sorted by
+// their type and name such that the file is stable across pulls.
+func (l *Lockfile) Save(path string) error {
+	sort.SliceStable(l.Components, func(i, j int) bool {
+		if l.Components[i].Type != l.Components[j].Type {
+			return l.Components[i].Type < l.Components[j].Type
+		}
+
+		return l.Components[i].Name < l.Components[j].Name
+	})
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("could not serialize lockfile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(lockfileHeader)
+	buf.Write(data)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("could not write lockfile: %v", err)
+	}
+
+	return nil
+}
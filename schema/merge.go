@@ -20,10 +20,12 @@ package schema
 import (
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
+	"kraftkit.sh/network"
 	"kraftkit.sh/unikraft/config"
 	"kraftkit.sh/unikraft/core"
 	"kraftkit.sh/unikraft/lib"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 )
 
 func merge(configs []*config.Config) (*config.Config, error) {
@@ -47,6 +49,16 @@ func merge(configs []*config.Config) (*config.Config, error) {
 			return base, errors.Wrapf(err, "cannot merge networks from %s", override.Filename)
 		}
 
+		base.Networks, err = mergeNetworks(base.Networks, override.Networks)
+		if err != nil {
+			return base, errors.Wrapf(err, "cannot merge networks from %s", override.Filename)
+		}
+
+		base.Volumes, err = mergeVolumes(base.Volumes, override.Volumes)
+		if err != nil {
+			return base, errors.Wrapf(err, "cannot merge volumes from %s", override.Filename)
+		}
+
 		base.Extensions, err = mergeExtensions(base.Extensions, override.Extensions)
 		if err != nil {
 			return base, errors.Wrapf(err, "cannot merge extensions from %s", override.Filename)
@@ -77,6 +89,22 @@ func mergeTargets(base, override []target.TargetConfig) ([]target.TargetConfig,
 	return base, err
 }
 
+func mergeNetworks(base, override map[string]network.NetworkConfig) (map[string]network.NetworkConfig, error) {
+	if base == nil {
+		base = map[string]network.NetworkConfig{}
+	}
+	err := mergo.Map(&base, &override, mergo.WithOverride)
+	return base, err
+}
+
+func mergeVolumes(base, override map[string]volume.VolumeConfig) (map[string]volume.VolumeConfig, error) {
+	if base == nil {
+		base = map[string]volume.VolumeConfig{}
+	}
+	err := mergo.Map(&base, &override, mergo.WithOverride)
+	return base, err
+}
+
 func mergeExtensions(base, override map[string]interface{}) (map[string]interface{}, error) {
 	if base == nil {
 		base = map[string]interface{}{}
@@ -120,7 +120,11 @@ func WithDotConfig(withDotConfig bool) ProjectOptionsFn {
 			return nil
 		}
 
-		if o.DotConfigFile == "" {
+		// Only a missing configuration file which has been explicitly requested is
+		// an error
+		explicit := o.DotConfigFile != ""
+
+		if !explicit {
 			wd, err := o.GetWorkingDir()
 			if err != nil {
 				return err
@@ -140,7 +144,7 @@ func WithDotConfig(withDotConfig bool) ProjectOptionsFn {
 
 		s, err := os.Stat(dotConfigFile)
 		if os.IsNotExist(err) {
-			if o.DotConfigFile != "" {
+			if explicit {
 				return errors.Errorf("couldn't find config file: %s", o.DotConfigFile)
 			}
 			return nil
@@ -151,7 +155,7 @@ func WithDotConfig(withDotConfig bool) ProjectOptionsFn {
 		}
 
 		if s.IsDir() {
-			if o.DotConfigFile == "" {
+			if !explicit {
 				return nil
 			}
 			return errors.Errorf("%s is a directory", dotConfigFile)
@@ -289,6 +293,17 @@ func findFiles(names []string, pwd string) []string {
 	return candidates
 }
 
+// FindConfigFile returns the path to the preferred project file (Kraftfile)
+// within the provided directory and whether one was found
+func FindConfigFile(dir string) (string, bool) {
+	candidates := findFiles(DefaultFileNames, dir)
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	return candidates[0], true
+}
+
 // IsWorkdirInitialized provides a quick check to determine if whether one of
 // the supported project files (Kraftfiles) is present within a provided working
 // directory.
@@ -20,6 +20,7 @@ package schema
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/mattn/go-shellwords"
@@ -27,11 +28,13 @@ import (
 	"github.com/pkg/errors"
 
 	"kraftkit.sh/initrd"
+	"kraftkit.sh/network"
 	"kraftkit.sh/unikraft/arch"
 	"kraftkit.sh/unikraft/component"
 	"kraftkit.sh/unikraft/lib"
 	"kraftkit.sh/unikraft/plat"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 )
 
 // TransformerFunc defines a function to perform the actual transformation
@@ -57,7 +60,10 @@ func Transform(source interface{}, target interface{}, additionalTransformers ..
 		ZeroFields: false,
 		MatchName: func(mapKey, fieldName string) bool {
 			maps := map[string]string{
-				"kconfig": "Configuration",
+				"kconfig":     "Configuration",
+				"pre_up":      "PreUp",
+				"post_down":   "PostDown",
+				"bridge_name": "BridgeName",
 			}
 
 			if f, ok := maps[mapKey]; ok && f == fieldName {
@@ -87,6 +93,8 @@ func createTransformHook(additionalTransformers ...Transformer) mapstructure.Dec
 		reflect.TypeOf(arch.ArchitectureConfig{}): transformArchitecture,
 		reflect.TypeOf(plat.PlatformConfig{}):     transformPlatform,
 		reflect.TypeOf(initrd.InitrdConfig{}):     transformInitrd,
+		reflect.TypeOf(network.NetworkConfig{}):   transformNetwork,
+		reflect.TypeOf(volume.VolumeConfig{}):     transformVolume,
 		reflect.TypeOf(lib.LibraryConfig{}):       transformLibrary,
 		// Use a map as we need to access the name (which is the key)
 		reflect.TypeOf(map[string]component.ComponentConfig{}): transformComponents,
@@ -138,7 +146,17 @@ var transformMapStringString TransformerFunc = func(data interface{}) (interface
 var transformArchitecture TransformerFunc = func(data interface{}) (interface{}, error) {
 	switch value := data.(type) {
 	case map[string]interface{}:
-		return toMapStringString(value, false), nil
+		architecture := make(map[string]interface{}, len(value))
+		for key, prop := range value {
+			switch key {
+			case "kconfig":
+				architecture[key] = prop
+			default:
+				architecture[key] = toString(prop, false)
+			}
+		}
+
+		return architecture, nil
 	case map[string]string:
 		return value, nil
 	case string:
@@ -151,7 +169,29 @@ var transformArchitecture TransformerFunc = func(data interface{}) (interface{},
 var transformPlatform TransformerFunc = func(data interface{}) (interface{}, error) {
 	switch value := data.(type) {
 	case map[string]interface{}:
-		return toMapStringString(value, false), nil
+		platform := make(map[string]interface{}, len(value))
+		for key, prop := range value {
+			switch key {
+			case "pre_up", "post_down":
+				cmd, err := transformCommand(prop)
+				if err != nil {
+					return data, err
+				}
+				platform[key] = cmd
+			case "cpus":
+				cpus, err := strconv.Atoi(fmt.Sprint(prop))
+				if err != nil {
+					return data, errors.Errorf("invalid number of cpus for platform: %v", prop)
+				}
+				platform[key] = cpus
+			case "kconfig":
+				platform[key] = prop
+			default:
+				platform[key] = toString(prop, false)
+			}
+		}
+
+		return platform, nil
 	case map[string]string:
 		return value, nil
 	case string:
@@ -161,6 +201,43 @@ var transformPlatform TransformerFunc = func(data interface{}) (interface{}, err
 	}
 }
 
+var transformNetwork TransformerFunc = func(data interface{}) (interface{}, error) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		net := make(map[string]interface{}, len(value))
+		for key, prop := range value {
+			switch key {
+			case "pre_up", "post_down":
+				cmd, err := transformCommand(prop)
+				if err != nil {
+					return data, err
+				}
+				net[key] = cmd
+			default:
+				net[key] = toString(prop, false)
+			}
+		}
+
+		return net, nil
+	case bool, nil:
+		// A network which is simply enabled uses the defaults
+		return map[string]interface{}{}, nil
+	default:
+		return data, errors.Errorf("invalid type %T for network", value)
+	}
+}
+
+var transformVolume TransformerFunc = func(data interface{}) (interface{}, error) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		return toMapStringString(value, false), nil
+	case string:
+		return map[string]interface{}{"source": value}, nil
+	default:
+		return data, errors.Errorf("invalid type %T for volume", value)
+	}
+}
+
 var transformInitrd TransformerFunc = func(data interface{}) (interface{}, error) {
 	switch value := data.(type) {
 	case map[string]interface{}:
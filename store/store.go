@@ -0,0 +1,359 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package store provides a content-addressed store of unpacked component
+// sources which are shared between projects.  Each entry is keyed by the
+// SHA256 checksum of the archive it was unpacked from and is placed into a
+// project by reflinking, hardlinking or, as a last resort, copying its files.
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// EntriesDir is the directory within the root of the store which holds the
+	// unpacked entries
+	EntriesDir = "sha256"
+
+	// ProjectsFile is the file within the root of the store which records the
+	// projects into which entries have been placed
+	ProjectsFile = "projects"
+)
+
+var sumRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// Store is a content-addressed store residing at a root directory
+type Store struct {
+	root string
+}
+
+// NewStore returns a Store residing at the provided root directory, which is
+// typically the configured sources directory.
+func NewStore(root string) *Store {
+	return &Store{root: root}
+}
+
+// Root returns the root directory of the store
+func (s *Store) Root() string {
+	return s.root
+}
+
+// normalize returns the lower-case hex representation of the checksum
+func normalize(sum string) (string, error) {
+	sum = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(sum), "sha256:"))
+	if !sumRegexp.MatchString(sum) {
+		return "", fmt.Errorf("invalid sha256 checksum: %s", sum)
+	}
+
+	return sum, nil
+}
+
+// Path returns the location of the entry with the provided checksum
+func (s *Store) Path(sum string) string {
+	if n, err := normalize(sum); err == nil {
+		sum = n
+	}
+
+	return filepath.Join(s.root, EntriesDir, sum)
+}
+
+// Has returns whether an entry with the provided checksum exists
+func (s *Store) Has(sum string) bool {
+	if _, err := normalize(sum); err != nil {
+		return false
+	}
+
+	f, err := os.Stat(s.Path(sum))
+	return err == nil && f.IsDir()
+}
+
+// Add populates a new entry with the provided checksum using the populate
+// callback which receives a temporary directory.  The entry only becomes
+// visible once it has been fully populated.  Adding an existing entry is a
+// no-op.
+func (s *Store) Add(sum string, populate func(dir string) error) error {
+	sum, err := normalize(sum)
+	if err != nil {
+		return err
+	}
+
+	if s.Has(sum) {
+		return nil
+	}
+
+	entries := filepath.Join(s.root, EntriesDir)
+	if err := os.MkdirAll(entries, 0o755); err != nil {
+		return fmt.Errorf("could not create store: %v", err)
+	}
+
+	tmp, err := ioutil.TempDir(entries, "."+sum+"-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary entry: %v", err)
+	}
+
+	// Temporary directories are only accessible by the owner
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := populate(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, s.Path(sum)); err != nil {
+		os.RemoveAll(tmp)
+
+		// The entry may have been added concurrently
+		if s.Has(sum) {
+			return nil
+		}
+
+		return fmt.Errorf("could not add entry to store: %v", err)
+	}
+
+	return nil
+}
+
+// Link places the files of the entry with the provided checksum at the
+// destination directory.  Files are reflinked where the filesystem supports it
+// and hardlinked or copied otherwise.  Note that hardlinked files are shared
+// with the store and should not be modified in place.  Existing files at the
+// destination are replaced.
+func (s *Store) Link(sum, dst string) error {
+	if !s.Has(sum) {
+		return fmt.Errorf("entry does not exist in store: %s", sum)
+	}
+
+	src := s.Path(sum)
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()|0o700); err != nil {
+				return fmt.Errorf("could not create directory: %v", err)
+			}
+
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("could not create symbolic link: %v", err)
+			}
+
+		case info.Mode().IsRegular():
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+
+			if err := linkFile(path, target, info.Mode()); err != nil {
+				return fmt.Errorf("could not place file: %v", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// linkFile places the file at the source to the destination by first trying
+// to reflink, then to hardlink and finally to copy the file.
+func linkFile(src, dst string, mode os.FileMode) error {
+	if err := reflink(src, dst, mode); err == nil {
+		return nil
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	return copyFile(src, dst, mode)
+}
+
+// copyFile copies the contents of the source to a new destination file
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// Entries returns the checksums of all entries in the store
+func (s *Store) Entries() ([]string, error) {
+	dirents, err := os.ReadDir(filepath.Join(s.root, EntriesDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var sums []string
+	for _, dirent := range dirents {
+		if dirent.IsDir() && sumRegexp.MatchString(dirent.Name()) {
+			sums = append(sums, dirent.Name())
+		}
+	}
+
+	return sums, nil
+}
+
+// Remove deletes the entry with the provided checksum from the store.
+// Projects which hardlinked files of the entry retain their own links.
+func (s *Store) Remove(sum string) error {
+	sum, err := normalize(sum)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(s.Path(sum))
+}
+
+// Register records the project directory as a user of the store such that its
+// references are considered when pruning.
+func (s *Store) Register(project string) error {
+	project, err := filepath.Abs(project)
+	if err != nil {
+		return err
+	}
+
+	projects, err := s.Projects()
+	if err != nil {
+		return err
+	}
+
+	for _, known := range projects {
+		if known == project {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(s.root, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.root, ProjectsFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not register project: %v", err)
+	}
+
+	defer f.Close()
+
+	_, err = f.WriteString(project + "\n")
+	return err
+}
+
+// Projects returns the project directories which have been registered
+func (s *Store) Projects() ([]string, error) {
+	f, err := os.Open(filepath.Join(s.root, ProjectsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var projects []string
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		project := strings.TrimSpace(scanner.Text())
+		if len(project) == 0 || seen[project] {
+			continue
+		}
+
+		seen[project] = true
+		projects = append(projects, project)
+	}
+
+	return projects, scanner.Err()
+}
+
+// SetProjects replaces the list of registered project directories
+func (s *Store) SetProjects(projects []string) error {
+	if err := os.MkdirAll(s.root, 0o755); err != nil {
+		return err
+	}
+
+	var contents string
+	for _, project := range projects {
+		contents += project + "\n"
+	}
+
+	return ioutil.WriteFile(filepath.Join(s.root, ProjectsFile), []byte(contents), 0o644)
+}
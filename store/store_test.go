@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestStoreAddLink(t *testing.T) {
+	st := NewStore(t.TempDir())
+
+	if st.Has(testSum) {
+		t.Fatal("expected empty store")
+	}
+
+	populated := 0
+	populate := func(dir string) error {
+		populated++
+
+		if err := os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dir, "lib", "Makefile.uk"), []byte("$(eval $(call addlib,libfoo))\n"), 0o644)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := st.Add(testSum, populate); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if populated != 1 {
+		t.Errorf("expected entry to be populated once, got %d", populated)
+	}
+
+	project := t.TempDir()
+	for _, dst := range []string{filepath.Join(project, "a"), filepath.Join(project, "b")} {
+		if err := st.Link(testSum, dst); err != nil {
+			t.Fatal(err)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(dst, "lib", "Makefile.uk"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(contents) != "$(eval $(call addlib,libfoo))\n" {
+			t.Errorf("unexpected contents: %s", contents)
+		}
+	}
+
+	entries, err := st.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0] != testSum {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+
+	if err := st.Remove(testSum); err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Has(testSum) {
+		t.Error("expected entry to be removed")
+	}
+
+	// Placed sources are not affected by removing the entry
+	if _, err := os.Stat(filepath.Join(project, "a", "lib", "Makefile.uk")); err != nil {
+		t.Errorf("expected placed file to remain: %v", err)
+	}
+}
+
+func TestStoreRegister(t *testing.T) {
+	st := NewStore(t.TempDir())
+	project := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		if err := st.Register(project); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	projects, err := st.Projects()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 1 || projects[0] != project {
+		t.Errorf("unexpected projects: %v", projects)
+	}
+}
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+// Package menuconfig implements an editor of the KConfig options of a project,
+// which is used either interactively as a TUI or headless through its methods.
+package menuconfig
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"kraftkit.sh/kconfig"
+)
+
+type MenuConfig struct {
+	tree      *kconfig.Tree
+	dc        *kconfig.DotConfig
+	title     string
+	requested map[string]bool
+	changed   map[string]bool
+
+	// menu is the entry whose children are listed and parents holds the
+	// enclosing menus
+	menu    *kconfig.Node
+	parents []frame
+	cursor  int
+	offset  int
+
+	search    textinput.Model
+	searching bool
+	input     textinput.Model
+	editing   *kconfig.Symbol
+
+	message  string
+	width    int
+	height   int
+	saved    bool
+	quitting bool
+}
+
+// frame is a menu which has been descended from along with the position of the
+// cursor in it
+type frame struct {
+	menu   *kconfig.Node
+	cursor int
+}
+
+// NewMenuConfig returns an editor of the `.config` file against the KConfig
+// menu tree.  Changes are applied to the provided `.config` file.
+func NewMenuConfig(tree *kconfig.Tree, dc *kconfig.DotConfig, opts ...MenuConfigOption) (*MenuConfig, error) {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search options"
+
+	mc := &MenuConfig{
+		tree:      tree,
+		dc:        dc,
+		requested: map[string]bool{},
+		changed:   map[string]bool{},
+		menu:      tree.Root,
+		search:    search,
+		input:     textinput.New(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(mc); err != nil {
+			return nil, err
+		}
+	}
+
+	return mc, nil
+}
+
+// Set assigns the value of the named configuration option, see
+// `kconfig.Tree.Set`.  The requested options whose values change as a
+// consequence, e.g. when selecting another member of a choice, are considered
+// changed as well.
+func (mc *MenuConfig) Set(name, value string) error {
+	previous := map[string]string{}
+	for requested := range mc.requested {
+		previous[requested], _ = mc.Value(requested)
+	}
+
+	if err := mc.tree.Set(mc.dc, name, value); err != nil {
+		return err
+	}
+
+	sym, _ := mc.tree.Symbol(name)
+	mc.changed[sym.ConfigName()] = true
+
+	for requested, before := range previous {
+		if after, _ := mc.Value(requested); after != before {
+			mc.changed[requested] = true
+		}
+	}
+
+	return nil
+}
+
+// Value returns the current value of the named configuration option
+func (mc *MenuConfig) Value(name string) (string, bool) {
+	sym, ok := mc.tree.Symbol(name)
+	if !ok {
+		return "", false
+	}
+
+	return mc.tree.Lookup(mc.dc)(sym.Name)
+}
+
+// Changes returns the configuration options which have been set as well as
+// the requested options whose values have changed as a consequence
+func (mc *MenuConfig) Changes() map[string]string {
+	changes := map[string]string{}
+
+	for name := range mc.changed {
+		changes[name], _ = mc.Value(name)
+	}
+
+	return changes
+}
+
+// Dump writes the resulting `.config` file
+func (mc *MenuConfig) Dump(w io.Writer) error {
+	_, err := mc.dc.WriteTo(w)
+	return err
+}
+
+// Start runs the interactive editor and returns whether the changes were
+// saved by the user
+func (mc *MenuConfig) Start() (bool, error) {
+	model, err := tea.NewProgram(*mc, tea.WithAltScreen()).StartReturningModel()
+	if err != nil {
+		return false, err
+	}
+
+	*mc = model.(MenuConfig)
+
+	return mc.saved, nil
+}
+
+func (mc MenuConfig) Init() tea.Cmd {
+	return nil
+}
+
+// entries returns the entries which are listed, i.e. the visible children of
+// the current menu or the symbols matching the search query
+func (mc MenuConfig) entries() []*kconfig.Node {
+	lookup := mc.tree.Lookup(mc.dc)
+
+	query := strings.ToLower(mc.search.Value())
+	if len(query) == 0 {
+		return visible(children(mc.menu), lookup)
+	}
+
+	var nodes []*kconfig.Node
+	for _, sym := range mc.tree.Symbols() {
+		for _, node := range sym.Nodes {
+			if len(node.Prompt) == 0 {
+				continue
+			}
+
+			if strings.Contains(strings.ToLower(sym.Name), query) ||
+				strings.Contains(strings.ToLower(node.Prompt), query) {
+				nodes = append(nodes, node)
+				break
+			}
+		}
+	}
+
+	return visible(nodes, lookup)
+}
+
+// children returns the entries of a menu.  As in Unikraft's menuconfig, the
+// entries which follow a `menuconfig` symbol and depend on it are listed in
+// a submenu of that symbol.
+func children(menu *kconfig.Node) []*kconfig.Node {
+	if menu.Kind == kconfig.NodeConfig {
+		if !menu.MenuConfig || menu.Parent == nil {
+			return nil
+		}
+
+		var nodes []*kconfig.Node
+		following := false
+
+		for _, node := range menu.Parent.Children {
+			if node == menu {
+				following = true
+				continue
+			}
+
+			if !following {
+				continue
+			}
+
+			if !dependsOn(node, menu.Symbol.Name) {
+				break
+			}
+
+			nodes = append(nodes, node)
+		}
+
+		return nodes
+	}
+
+	var nodes []*kconfig.Node
+	var menuconfig *kconfig.Node
+
+	for _, node := range menu.Children {
+		if menuconfig != nil && dependsOn(node, menuconfig.Symbol.Name) {
+			continue
+		}
+
+		menuconfig = nil
+		if node.Kind == kconfig.NodeConfig && node.MenuConfig && node.Symbol != nil {
+			menuconfig = node
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// dependsOn returns whether the entry depends on the named symbol
+func dependsOn(node *kconfig.Node, name string) bool {
+	if node.Dependencies == nil {
+		return false
+	}
+
+	for _, sym := range node.Dependencies.Symbols() {
+		if sym == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// visible returns the entries which have a prompt and whose dependencies are
+// satisfied
+func visible(nodes []*kconfig.Node, lookup kconfig.Lookup) []*kconfig.Node {
+	var shown []*kconfig.Node
+
+	for _, node := range nodes {
+		if len(node.Prompt) == 0 {
+			continue
+		}
+
+		hidden := false
+		for _, cond := range []kconfig.Expr{node.Dependencies, node.PromptIf, node.Visible} {
+			if cond != nil && cond.Eval(lookup) == kconfig.No {
+				hidden = true
+			}
+		}
+
+		if !hidden {
+			shown = append(shown, node)
+		}
+	}
+
+	return shown
+}
+
+// selected returns the entry under the cursor
+func (mc MenuConfig) selected() *kconfig.Node {
+	entries := mc.entries()
+	if mc.cursor < 0 || mc.cursor >= len(entries) {
+		return nil
+	}
+
+	return entries[mc.cursor]
+}
+
+// toggle cycles the value of a bool or tristate symbol
+func (mc *MenuConfig) toggle(sym *kconfig.Symbol) error {
+	value, _ := mc.Value(sym.Name)
+
+	next := "y"
+	switch {
+	case value == "y" && sym.Choice != nil:
+		return nil
+	case value == "y":
+		next = "n"
+	case value == "n" && sym.Type == kconfig.TypeTristate:
+		next = "m"
+	}
+
+	return mc.Set(sym.Name, next)
+}
+
+// describe returns a summary of a symbol, i.e. its type, value, dependencies
+// and the symbols which select it
+func (mc MenuConfig) describe(sym *kconfig.Symbol) []string {
+	value, _ := mc.Value(sym.Name)
+
+	lines := []string{fmt.Sprintf("%s (%s) = %s", sym.ConfigName(), sym.Type, value)}
+
+	if deps := sym.DependsOn(); deps != nil {
+		lines = append(lines, "depends on: "+deps.String())
+	}
+
+	if by := mc.tree.SelectedBy(sym.Name); len(by) > 0 {
+		names := make([]string, len(by))
+		for i, s := range by {
+			names[i] = s.ConfigName()
+		}
+
+		lines = append(lines, "selected by: "+strings.Join(names, ", "))
+	}
+
+	if len(sym.Nodes) > 0 {
+		lines = append(lines, fmt.Sprintf("defined at: %s:%d", sym.Nodes[0].File, sym.Nodes[0].Line))
+	}
+
+	return lines
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package menuconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kraftkit.sh/kconfig"
+)
+
+const testConfigUk = `config LIBFOO
+	bool "foo"
+	select LIBBAR
+
+config LIBBAR
+	bool "bar"
+
+config LIBBAZ
+	bool "baz"
+	default y
+
+choice
+	prompt "Optimization"
+
+config OPTIMIZE_PERF
+	bool "performance"
+
+config OPTIMIZE_SIZE
+	bool "size"
+
+endchoice
+`
+
+func TestChanges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "Config.uk")
+	if err := os.WriteFile(file, []byte(testConfigUk), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := kconfig.Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LIBBAZ is requested by the Kraftfile but missing from the configuration,
+	// e.g. after its synchronization, which is not a change of the user
+	dc, err := kconfig.ParseDotConfig(strings.NewReader("CONFIG_OPTIMIZE_PERF=y\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mc, err := NewMenuConfig(tree, dc,
+		WithRequested("CONFIG_LIBBAZ", "CONFIG_OPTIMIZE_PERF"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes := mc.Changes(); len(changes) != 0 {
+		t.Errorf("expected no changes before editing, got %v", changes)
+	}
+
+	if err := mc.Set("OPTIMIZE_SIZE", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mc.Set("LIBFOO", "y"); err != nil {
+		t.Fatal(err)
+	}
+
+	// LIBBAR is selected by LIBFOO but not requested, such that it is left to
+	// the build system
+	expected := map[string]string{
+		"CONFIG_OPTIMIZE_SIZE": "y",
+		"CONFIG_OPTIMIZE_PERF": "n",
+		"CONFIG_LIBFOO":        "y",
+	}
+
+	changes := mc.Changes()
+	if len(changes) != len(expected) {
+		t.Errorf("expected %d changes, got %v", len(expected), changes)
+	}
+
+	for name, value := range expected {
+		if changes[name] != value {
+			t.Errorf("expected %s=%s, got %q", name, value, changes[name])
+		}
+	}
+}
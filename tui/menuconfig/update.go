@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package menuconfig
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"kraftkit.sh/kconfig"
+)
+
+func (mc MenuConfig) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		mc.width = msg.Width
+		mc.height = msg.Height
+		return mc, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			mc.quitting = true
+			return mc, tea.Quit
+		}
+
+		if mc.editing != nil {
+			return mc.updateInput(msg)
+		} else if mc.search.Focused() {
+			return mc.updateSearch(msg)
+		}
+
+		return mc.updateMenu(msg)
+	}
+
+	// Forward other messages, e.g. the blinking of the cursor, to the input
+	// which has the focus
+	if mc.editing != nil {
+		mc.input, cmd = mc.input.Update(msg)
+	} else if mc.search.Focused() {
+		mc.search, cmd = mc.search.Update(msg)
+	}
+
+	return mc, cmd
+}
+
+// updateMenu handles the keys which navigate and change the listed entries
+func (mc MenuConfig) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	mc.message = ""
+	entries := mc.entries()
+
+	switch msg.String() {
+	case "q":
+		mc.quitting = true
+		return mc, tea.Quit
+
+	case "s":
+		mc.saved = true
+		mc.quitting = true
+		return mc, tea.Quit
+
+	case "up", "k":
+		mc.cursor--
+
+	case "down", "j":
+		mc.cursor++
+
+	case "pgup":
+		mc.cursor -= mc.listHeight()
+
+	case "pgdown":
+		mc.cursor += mc.listHeight()
+
+	case "home", "g":
+		mc.cursor = 0
+
+	case "end", "G":
+		mc.cursor = len(entries) - 1
+
+	case "/":
+		mc.search.CursorEnd()
+		mc.search.Focus()
+		return mc, textinput.Blink
+
+	case "esc", "left", "h", "backspace":
+		if len(mc.search.Value()) > 0 {
+			mc.search.Reset()
+			mc.cursor = 0
+		} else {
+			mc.up()
+		}
+
+	case "enter", "right", "l":
+		if node := mc.selected(); node != nil {
+			return mc.enter(node)
+		}
+
+	case " ":
+		if node := mc.selected(); node != nil && node.Symbol != nil && node.Kind == kconfig.NodeConfig && node.Symbol.IsTristate() {
+			mc.report(mc.toggle(node.Symbol))
+		}
+
+	case "y", "n", "m":
+		if node := mc.selected(); node != nil && node.Symbol != nil && node.Kind == kconfig.NodeConfig && node.Symbol.IsTristate() {
+			mc.report(mc.Set(node.Symbol.Name, msg.String()))
+		}
+	}
+
+	mc.clamp()
+
+	return mc, nil
+}
+
+// updateInput handles the keys whilst the value of a symbol is being edited
+func (mc MenuConfig) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "enter":
+		mc.report(mc.Set(mc.editing.Name, mc.input.Value()))
+		fallthrough
+
+	case "esc":
+		mc.editing = nil
+		mc.input.Blur()
+		mc.clamp()
+		return mc, nil
+	}
+
+	mc.input, cmd = mc.input.Update(msg)
+
+	return mc, cmd
+}
+
+// updateSearch handles the keys whilst the search query is being edited
+func (mc MenuConfig) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "esc":
+		mc.search.Reset()
+		fallthrough
+
+	case "enter", "up", "down":
+		mc.search.Blur()
+		mc.clamp()
+		return mc, nil
+	}
+
+	mc.search, cmd = mc.search.Update(msg)
+	mc.cursor = 0
+	mc.offset = 0
+
+	return mc, cmd
+}
+
+// enter descends into menus and choices, edits the value of symbols and, for
+// members of a choice, selects them
+func (mc MenuConfig) enter(node *kconfig.Node) (tea.Model, tea.Cmd) {
+	sym := node.Symbol
+
+	switch {
+	case node.Kind == kconfig.NodeMenu,
+		node.Kind == kconfig.NodeChoice,
+		node.Kind == kconfig.NodeConfig && len(children(node)) > 0:
+		mc.parents = append(mc.parents, frame{mc.menu, mc.cursor})
+		mc.menu = node
+		mc.cursor = 0
+		mc.offset = 0
+		mc.search.Reset()
+
+	case sym == nil || node.Kind != kconfig.NodeConfig:
+
+	case sym.Choice != nil:
+		if err := mc.Set(sym.Name, "y"); err != nil {
+			mc.report(err)
+		} else {
+			mc.up()
+		}
+
+	case sym.IsTristate():
+		mc.report(mc.toggle(sym))
+
+	default:
+		value, _ := mc.Value(sym.Name)
+		mc.editing = sym
+		mc.input.Prompt = sym.ConfigName() + "="
+		mc.input.SetValue(value)
+		mc.input.CursorEnd()
+		mc.clamp()
+		return mc, tea.Batch(mc.input.Focus(), textinput.Blink)
+	}
+
+	mc.clamp()
+
+	return mc, nil
+}
+
+// up returns to the enclosing menu
+func (mc *MenuConfig) up() {
+	if len(mc.parents) == 0 {
+		return
+	}
+
+	parent := mc.parents[len(mc.parents)-1]
+	mc.parents = mc.parents[:len(mc.parents)-1]
+	mc.menu = parent.menu
+	mc.cursor = parent.cursor
+	mc.offset = 0
+}
+
+// report displays the error of a change, if any
+func (mc *MenuConfig) report(err error) {
+	if err != nil {
+		mc.message = err.Error()
+	}
+}
+
+// clamp keeps the cursor on the listed entries and scrolls the list such that
+// the cursor is visible
+func (mc *MenuConfig) clamp() {
+	total := len(mc.entries())
+
+	if mc.cursor >= total {
+		mc.cursor = total - 1
+	}
+
+	if mc.cursor < 0 {
+		mc.cursor = 0
+	}
+
+	height := mc.listHeight()
+
+	if mc.cursor < mc.offset {
+		mc.offset = mc.cursor
+	} else if mc.cursor >= mc.offset+height {
+		mc.offset = mc.cursor - height + 1
+	}
+}
@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package menuconfig
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+
+	"kraftkit.sh/kconfig"
+)
+
+const (
+	// DETAILS is the number of lines of the pane which describes the selected
+	// entry
+	DETAILS = 8
+
+	// CHROME is the number of lines around the list of entries, excluding the
+	// details pane
+	CHROME = 6
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Render
+	red           = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render
+	yellow        = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render
+	lightGrey     = lipgloss.NewStyle().Foreground(lipgloss.Color("250")).Render
+	selectedStyle = lipgloss.NewStyle().Reverse(true).Render
+)
+
+func (mc MenuConfig) View() string {
+	if mc.quitting {
+		return ""
+	}
+
+	lookup := mc.tree.Lookup(mc.dc)
+	entries := mc.entries()
+
+	title := mc.title
+	if len(title) == 0 {
+		title = mc.tree.MainMenu
+	}
+
+	s := titleStyle(title) + "\n" + lightGrey(mc.path()) + "\n\n"
+
+	height := mc.listHeight()
+	lines := 0
+
+	for i := mc.offset; i < len(entries) && lines < height; i++ {
+		row := mc.row(entries[i], lookup)
+		if i == mc.cursor {
+			row = selectedStyle(row)
+		}
+
+		s += mc.truncate(row) + "\n"
+		lines++
+	}
+
+	if len(entries) == 0 {
+		s += lightGrey("    no options") + "\n"
+		lines++
+	}
+
+	// Keep the details pane at the bottom of the screen
+	if mc.height > 0 {
+		s += strings.Repeat("\n", height-lines)
+	}
+
+	s += lightGrey(strings.Repeat("─", mc.ruleWidth())) + "\n"
+
+	details := mc.details()
+	for _, line := range details {
+		s += mc.truncate(line) + "\n"
+	}
+
+	if mc.height > 0 {
+		s += strings.Repeat("\n", DETAILS-len(details))
+	}
+
+	switch {
+	case mc.editing != nil:
+		s += mc.input.View() + "\n"
+	case mc.search.Focused() || len(mc.search.Value()) > 0:
+		s += mc.search.View() + "\n"
+	case len(mc.message) > 0:
+		s += red(mc.message) + "\n"
+	default:
+		s += "\n"
+	}
+
+	s += lightGrey("↑/↓ move • enter select • space toggle • y/n/m set • esc back • / search • s save • q quit")
+
+	return s
+}
+
+// row formats an entry of the menu
+func (mc MenuConfig) row(node *kconfig.Node, lookup kconfig.Lookup) string {
+	switch node.Kind {
+	case kconfig.NodeMenu:
+		return "    " + node.Prompt + "  --->"
+
+	case kconfig.NodeComment:
+		return "    *** " + node.Prompt + " ***"
+
+	case kconfig.NodeChoice:
+		chosen := ""
+		for _, child := range node.Children {
+			if child.Symbol == nil {
+				continue
+			}
+
+			if value, _ := lookup(child.Symbol.Name); value == "y" {
+				chosen = child.Prompt
+				break
+			}
+		}
+
+		return "    " + node.Prompt + " (" + chosen + ")  --->"
+	}
+
+	sym := node.Symbol
+	value, _ := lookup(sym.Name)
+
+	s := ""
+	switch {
+	case sym.Choice != nil && value == "y":
+		s = "(X)"
+	case sym.Choice != nil:
+		s = "( )"
+	case sym.Type == kconfig.TypeBool && value == "y":
+		s = "[*]"
+	case sym.Type == kconfig.TypeBool:
+		s = "[ ]"
+	case sym.Type == kconfig.TypeTristate && value == "y":
+		s = "<*>"
+	case sym.Type == kconfig.TypeTristate && value == "m":
+		s = "<M>"
+	case sym.Type == kconfig.TypeTristate:
+		s = "< >"
+	default:
+		s = "(" + value + ")"
+	}
+
+	s += " " + node.Prompt
+
+	if len(children(node)) > 0 {
+		s += "  --->"
+	}
+
+	if mc.changed[sym.ConfigName()] {
+		s = yellow(s)
+	}
+
+	return s
+}
+
+// details describes the selected entry, i.e. its symbol and help text
+func (mc MenuConfig) details() []string {
+	node := mc.selected()
+	if node == nil {
+		return nil
+	}
+
+	var lines []string
+	if node.Symbol != nil && node.Kind == kconfig.NodeConfig {
+		lines = mc.describe(node.Symbol)
+	} else if node.Dependencies != nil {
+		lines = []string{"depends on: " + node.Dependencies.String()}
+	}
+
+	help := node.Help
+	if len(help) == 0 && node.Symbol != nil {
+		help = node.Symbol.Help()
+	}
+
+	if len(help) > 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		width := mc.ruleWidth()
+		lines = append(lines, strings.Split(wordwrap.String(strings.TrimSpace(help), width), "\n")...)
+	}
+
+	if len(lines) > DETAILS {
+		lines = append(lines[:DETAILS-1], lightGrey("…"))
+	}
+
+	return lines
+}
+
+// path returns the prompts of the menus which have been descended into
+func (mc MenuConfig) path() string {
+	if len(mc.search.Value()) > 0 {
+		return "search: " + mc.search.Value()
+	}
+
+	var prompts []string
+	for _, parent := range mc.parents[min(1, len(mc.parents)):] {
+		prompts = append(prompts, parent.menu.Prompt)
+	}
+
+	if mc.menu != mc.tree.Root {
+		prompts = append(prompts, mc.menu.Prompt)
+	}
+
+	if len(prompts) == 0 {
+		return ""
+	}
+
+	return "> " + strings.Join(prompts, " > ")
+}
+
+// listHeight returns the number of entries which fit on the screen
+func (mc MenuConfig) listHeight() int {
+	// Without the size of the terminal, all entries are listed
+	if mc.height == 0 {
+		return len(mc.entries())
+	}
+
+	if height := mc.height - CHROME - DETAILS; height > 1 {
+		return height
+	}
+
+	return 1
+}
+
+// ruleWidth returns the width of the separator of the details pane
+func (mc MenuConfig) ruleWidth() int {
+	if mc.width > 0 {
+		return mc.width
+	}
+
+	return 80
+}
+
+// truncate cuts a line at the width of the terminal
+func (mc MenuConfig) truncate(line string) string {
+	if mc.width == 0 {
+		return line
+	}
+
+	return lipgloss.NewStyle().MaxWidth(mc.width).Render(line)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
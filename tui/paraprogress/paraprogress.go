@@ -79,7 +79,8 @@ func NewParaProgress(processes []*Process, opts ...ParaProgressOption) (*ParaPro
 	for i := range processes {
 		processes[i].NameWidth = maxNameLen
 
-		if md.parallel {
+		// Clone the logger for this process if we are in fancy render mode
+		if md.parallel && !md.norender {
 			md.processes[i].log = md.log.Clone()
 			md.processes[i].log.SetOutput(md.processes[i])
 		} else {
@@ -147,6 +148,15 @@ func (md ParaProgress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		md.processes[i], cmd = md.processes[i].Update(msg)
 		cmds = append(cmds, cmd)
 
+		// Start the next process once the previous one has exited if not in
+		// parallel mode
+		if status, ok := msg.(StatusMsg); ok && !md.parallel &&
+			status.ID == md.processes[i].id &&
+			(status.status == StatusFailed || status.status == StatusSuccess) &&
+			i+1 < len(md.processes) {
+			cmds = append(cmds, md.processes[i+1].Start())
+		}
+
 		if md.processes[i].Status == StatusFailed ||
 			md.processes[i].Status == StatusSuccess {
 			complete += 1
@@ -33,23 +33,24 @@ package app
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/xlab/treeprint"
 
 	"kraftkit.sh/exec"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/kconfig"
 	"kraftkit.sh/make"
+	"kraftkit.sh/network"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/component"
 	"kraftkit.sh/unikraft/core"
 	"kraftkit.sh/unikraft/lib"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 )
 
 const DefaultKConfigFile = ".config"
@@ -67,6 +68,8 @@ type ApplicationConfig struct {
 	Unikraft      core.UnikraftConfig  `yaml:",omitempty" json:"unikraft,omitempty"`
 	Libraries     lib.Libraries        `yaml:",omitempty" json:"libraries,omitempty"`
 	Targets       target.Targets       `yaml:",omitempty" json:"targets,omitempty"`
+	Networks      network.Networks     `yaml:",omitempty" json:"networks,omitempty"`
+	Volumes       volume.Volumes       `yaml:",omitempty" json:"volumes,omitempty"`
 	Extensions    component.Extensions `yaml:",inline" json:"-"` // https://github.com/golang/go/issues/6213
 	KraftFiles    []string             `yaml:"-" json:"-"`
 	Configuration map[string]string    `yaml:"-" json:"-"`
@@ -117,8 +120,6 @@ func (a *ApplicationConfig) MakeArgs() (*core.MakeArgs, error) {
 		libraries = append(libraries, src)
 	}
 
-	// TODO: Platforms & architectures
-
 	return &core.MakeArgs{
 		OutputDir:      a.OutDir,
 		ApplicationDir: a.WorkingDir,
@@ -126,25 +127,131 @@ func (a *ApplicationConfig) MakeArgs() (*core.MakeArgs, error) {
 	}, nil
 }
 
+// TargetMakeArgs returns the populated `core.MakeArgs` for building the
+// provided target, which is built within its own output directory using its own
+// configuration file and includes the target's platform if it is external to
+// the Unikraft core.
+func (a *ApplicationConfig) TargetMakeArgs(targ *target.TargetConfig) (*core.MakeArgs, error) {
+	args, err := a.MakeArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targ.OutDir) > 0 {
+		args.OutputDir = targ.OutDir
+		args.ConfigPath = targ.KConfigFile()
+	}
+
+	// Built-in platforms are part of the Unikraft core, whereas external
+	// platforms are placed within the project like libraries
+	if targ.Platform.IsUnpackedInProject(a.WorkingDir) {
+		src, err := targ.Platform.SourceDir()
+		if err != nil {
+			return nil, err
+		}
+
+		args.PlatformDirs = src
+	}
+
+	return args, nil
+}
+
+// kconfigLayer is the configuration of a component of the application
+type kconfigLayer struct {
+	origin  string
+	kconfig component.KConfig
+}
+
+// targetKConfigLayers returns the configuration of the components of the
+// application for the provided target in order of precedence
+func (a *ApplicationConfig) targetKConfigLayers(targ *target.TargetConfig) []kconfigLayer {
+	layers := []kconfigLayer{}
+
+	if name, ok := a.Configuration[unikraft.UK_NAME]; ok {
+		layers = append(layers, kconfigLayer{"app", component.KConfig{
+			unikraft.UK_NAME: &name,
+		}})
+	}
+
+	layers = append(layers, kconfigLayer{"core", a.Unikraft.Configuration})
+
+	for _, name := range a.LibraryNames() {
+		layers = append(layers, kconfigLayer{"lib/" + name, a.Libraries[name].Configuration})
+	}
+
+	yes := "y"
+	arch := "arch/" + targ.Architecture.Name()
+	plat := "plat/" + targ.Platform.Name()
+
+	return append(layers,
+		kconfigLayer{"app", a.ComponentConfig.Configuration},
+		kconfigLayer{arch, targ.Architecture.Configuration},
+		kconfigLayer{plat, targ.Platform.Configuration},
+		kconfigLayer{"target/" + targ.Name(), targ.ComponentConfig.Configuration},
+		kconfigLayer{arch, component.KConfig{targ.Architecture.KConfigSymbol(): &yes}},
+		kconfigLayer{plat, component.KConfig{targ.Platform.KConfigSymbol(): &yes}},
+	)
+}
+
+// TargetKConfig returns the configuration of the provided target.  The
+// configuration options of the components of the application are merged such
+// that later ones take precedence: the Unikraft core, libraries (in order of
+// their name), the application itself, the architecture, the platform and
+// finally the target.  The options which select the target's architecture and
+// platform are always enabled.
+func (a *ApplicationConfig) TargetKConfig(targ *target.TargetConfig) component.KConfig {
+	values := component.KConfig{}
+
+	for _, layer := range a.targetKConfigLayers(targ) {
+		values.OverrideBy(layer.kconfig)
+	}
+
+	return values.RemoveEmpty()
+}
+
+// TargetKConfigOrigins returns the component which each configuration option of
+// the provided target's configuration originates from, e.g. "core",
+// "lib/musl", "app", "arch/x86_64", "plat/kvm" or "target/NAME".
+func (a *ApplicationConfig) TargetKConfigOrigins(targ *target.TargetConfig) map[string]string {
+	origins := map[string]string{}
+
+	for _, layer := range a.targetKConfigLayers(targ) {
+		for k, v := range layer.kconfig {
+			if v == nil {
+				delete(origins, k)
+			} else {
+				origins[k] = layer.origin
+			}
+		}
+	}
+
+	return origins
+}
+
 // Make is a method which invokes Unikraft's build system.  You can pass in make
 // options based on the `make` package.  Ultimately, this is an abstract method
 // which will be used by a number of well-known make command goals by Unikraft's
 // build system.
 func (a *ApplicationConfig) Make(mopts ...make.MakeOption) error {
-	coreSrc, err := a.Unikraft.SourceDir()
+	args, err := a.MakeArgs()
 	if err != nil {
 		return err
 	}
 
-	mopts = append(mopts,
-		make.WithDirectory(coreSrc),
-	)
+	return a.make(args, mopts...)
+}
 
-	args, err := a.MakeArgs()
+// make invokes Unikraft's build system with the provided arguments
+func (a *ApplicationConfig) make(args *core.MakeArgs, mopts ...make.MakeOption) error {
+	coreSrc, err := a.Unikraft.SourceDir()
 	if err != nil {
 		return err
 	}
 
+	mopts = append(mopts,
+		make.WithDirectory(coreSrc),
+	)
+
 	m, err := make.NewFromInterface(*args, mopts...)
 	if err != nil {
 		return err
@@ -223,97 +330,357 @@ func (a *ApplicationConfig) Fetch(mopts ...make.MakeOption) error {
 	)...)
 }
 
-func (a *ApplicationConfig) Set(mopts ...make.MakeOption) error {
-	// Write the configuration to a temporary file
-	tmpfile, err := ioutil.TempFile("", a.Name()+"-config*")
+// TargetKConfigFile returns the path to the `.config` file of the named
+// target, or that of the application if no target is named
+func (a *ApplicationConfig) TargetKConfigFile(targetName string) (string, error) {
+	if len(targetName) == 0 {
+		return a.KConfigFile()
+	}
+
+	for _, targ := range a.Targets {
+		if targ.Name() == targetName {
+			return targ.KConfigFile(), nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown target: %s", targetName)
+}
+
+// KConfigTree parses the KConfig menu tree of the application for the named
+// target, or for the application if no target is named.  The tree consists of
+// the `Config.uk` files of the Unikraft core and its internal libraries and
+// platforms, of the libraries and external platforms of the application and of
+// the application itself
+func (a *ApplicationConfig) KConfigTree(targetName string) (*kconfig.Tree, error) {
+	if !a.Unikraft.IsUnpackedInProject(a.WorkingDir) {
+		return nil, fmt.Errorf("cannot read configuration options without Unikraft core component source")
+	}
+
+	coreSrc, err := a.Unikraft.SourceDir()
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := a.kconfigEnv(coreSrc, targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := kconfig.NewTree(
+		kconfig.WithBaseDir(coreSrc),
+		kconfig.WithEnv(env),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The internal libraries and platforms of the Unikraft core are otherwise
+	// sourced from files generated by Unikraft's build system
+	files := []string{filepath.Join(coreSrc, "Config.uk")}
+	for _, pattern := range []string{"plat", "lib"} {
+		matches, err := filepath.Glob(filepath.Join(coreSrc, pattern, "*", "Config.uk"))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	var dirs []string
+	for _, name := range a.LibraryNames() {
+		library := a.Libraries[name]
+		if !library.IsUnpackedInProject(a.WorkingDir) {
+			continue
+		}
+
+		src, err := library.SourceDir()
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, src)
+	}
+
+	for _, targ := range a.Targets {
+		if !targ.Platform.IsUnpackedInProject(a.WorkingDir) {
+			continue
+		}
+
+		src, err := targ.Platform.SourceDir()
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, src)
+	}
+
+	dirs = append(dirs, a.WorkingDir)
+
+	for _, dir := range dirs {
+		files = append(files, filepath.Join(dir, "Config.uk"))
+	}
+
+	for i, file := range files {
+		// Only the Unikraft core is required to provide a `Config.uk` file
+		if _, err := os.Stat(file); i > 0 && os.IsNotExist(err) {
+			continue
+		}
+
+		if err := tree.Include(file); err != nil {
+			return nil, fmt.Errorf("could not parse configuration options: %v", err)
+		}
+	}
+
+	return tree, nil
+}
+
+// kconfigEnv returns the macros which Unikraft's build system defines when it
+// parses the KConfig menu tree of the named target, or of the application if
+// no target is named
+func (a *ApplicationConfig) kconfigEnv(coreSrc, targetName string) (map[string]string, error) {
+	env := map[string]string{
+		"UK_BASE": coreSrc,
+		"UK_APP":  a.WorkingDir,
+		"UK_NAME": a.Name(),
+	}
+
+	// The values of a previous configuration are used unless they can be
+	// determined from the sources and the target
+	dotconfig, err := a.TargetKConfigFile(targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, _, err := readDotConfig(dotconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{unikraft.UK_FULLVERSION, unikraft.UK_CODENAME, unikraft.UK_ARCH} {
+		if value, ok := dc.Get(name); ok {
+			env[strings.TrimPrefix(name, "CONFIG_")] = value
+		}
+	}
+
+	version, codename, err := a.Unikraft.Release()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read Unikraft core release: %v", err)
+	}
+
+	if len(version) > 0 {
+		env["UK_FULLVERSION"] = version
+	}
+
+	if len(codename) > 0 {
+		env["UK_CODENAME"] = codename
+	}
+
+	for _, targ := range a.Targets {
+		if targ.Name() == targetName && len(targ.Architecture.Name()) > 0 {
+			env["UK_ARCH"] = targ.Architecture.Name()
+		}
+	}
+
+	return env, nil
+}
+
+// Set validates the provided `NAME=VALUE` configuration options against the
+// KConfig menu tree of the application and saves them in the Kraftfile, for
+// the named target or otherwise for the Unikraft core, such that they are part
+// of the configuration generated for subsequent builds.  The options are also
+// assigned in order in the corresponding `.config` file, if it exists.
+func (a *ApplicationConfig) Set(targetName string, values ...string) error {
+	section, dotconfig, err := a.targetKConfigSection(targetName)
+	if err != nil {
+		return err
+	}
+
+	tree, err := a.KConfigTree(targetName)
+	if err != nil {
+		return err
+	}
+
+	dc, exists, err := readDotConfig(dotconfig)
 	if err != nil {
 		return err
 	}
-	defer tmpfile.Close()
-	defer os.Remove(tmpfile.Name())
 
-	for k, v := range a.Configuration {
-		var line string
+	saved := map[string]string{}
 
-		if _, err := strconv.ParseFloat(v, 64); err == nil || v == "y" {
-			line = fmt.Sprintf("%s=%s\n", k, v)
-		} else if v == "n" {
-			line = fmt.Sprintf("# %s is not set\n", k)
-		} else {
-			line = fmt.Sprintf("%s=\"%s\"\n", k, v)
+	for _, value := range values {
+		k, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("invalid or malformed argument: %s", value)
 		}
 
-		if _, err := tmpfile.WriteString(line); err != nil {
+		if err := tree.Set(dc, k, v); err != nil {
 			return err
 		}
+
+		sym, _ := tree.Symbol(k)
+		saved[sym.ConfigName()] = v
 	}
 
-	// Sync the file to the storage
-	tmpfile.Sync()
+	if err := a.SaveKConfig(targetName, saved); err != nil {
+		return err
+	}
 
-	// Give the file to the make command to import
-	mopts = append(mopts,
-		make.WithExecOptions(
-			exec.WithEnvKey(unikraft.UK_DEFCONFIG, tmpfile.Name()),
-		),
-	)
+	for k, v := range saved {
+		v := v
+		(*section)[k] = &v
+	}
+
+	if !exists {
+		return nil
+	}
 
-	return a.DefConfig(mopts...)
+	return dc.WriteFile(dotconfig)
 }
 
-func (a *ApplicationConfig) Unset(mopts ...make.MakeOption) error {
-	// Write the configuration to a temporary file
-	tmpfile, err := ioutil.TempFile("", a.Name()+"-config*")
+// Unset removes the named configuration options from the Kraftfile, for the
+// named target or otherwise for the Unikraft core, and from the corresponding
+// `.config` file, if it exists, such that they take their default values
+// again
+func (a *ApplicationConfig) Unset(targetName string, names ...string) error {
+	section, dotconfig, err := a.targetKConfigSection(targetName)
 	if err != nil {
 		return err
 	}
-	defer tmpfile.Close()
-	defer os.Remove(tmpfile.Name())
 
-	for k, v := range a.Configuration {
-		var line string
+	tree, err := a.KConfigTree(targetName)
+	if err != nil {
+		return err
+	}
 
-		if _, err := strconv.ParseFloat(v, 64); err == nil || v == "y" {
-			line = fmt.Sprintf("%s=%s\n", k, v)
-		} else if v == "n" {
-			line = fmt.Sprintf("# %s is not set\n", k)
-		} else {
-			line = fmt.Sprintf("%s=\"%s\"\n", k, v)
-		}
+	dc, exists, err := readDotConfig(dotconfig)
+	if err != nil {
+		return err
+	}
 
-		if _, err := tmpfile.WriteString(line); err != nil {
+	var removed []string
+
+	for _, name := range names {
+		if err := tree.Unset(dc, name); err != nil {
 			return err
 		}
+
+		sym, _ := tree.Symbol(name)
+		removed = append(removed, sym.ConfigName())
 	}
 
-	// Sync the file to the storage
-	tmpfile.Sync()
+	if err := a.RemoveKConfig(targetName, removed...); err != nil {
+		return err
+	}
 
-	// Give the file to the make command to import
-	mopts = append(mopts,
+	for _, k := range removed {
+		delete(*section, k)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	return dc.WriteFile(dotconfig)
+}
+
+// targetKConfigSection returns the configuration options of the named target,
+// or of the Unikraft core if no target is named, and the path to the
+// corresponding `.config` file
+func (a *ApplicationConfig) targetKConfigSection(targetName string) (*component.KConfig, string, error) {
+	section := &a.Unikraft.Configuration
+
+	if len(targetName) > 0 {
+		section = nil
+
+		for i := range a.Targets {
+			if a.Targets[i].Name() == targetName {
+				section = &a.Targets[i].Configuration
+				break
+			}
+		}
+
+		if section == nil {
+			return nil, "", fmt.Errorf("unknown target: %s", targetName)
+		}
+	}
+
+	if *section == nil {
+		*section = component.KConfig{}
+	}
+
+	dotconfig, err := a.TargetKConfigFile(targetName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return section, dotconfig, nil
+}
+
+// readDotConfig reads the `.config` file at the provided path and reports
+// whether it exists, otherwise an empty configuration is returned
+func readDotConfig(dotconfig string) (*kconfig.DotConfig, bool, error) {
+	if _, err := os.Stat(dotconfig); os.IsNotExist(err) {
+		return kconfig.NewDotConfig(), false, nil
+	}
+
+	dc, err := kconfig.NewDotConfigFromFile(dotconfig)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return dc, true, nil
+}
+
+// configureTarget generates the configuration file of the target from the
+// merged configuration options of the application's components
+func (a *ApplicationConfig) configureTarget(targ *target.TargetConfig, args *core.MakeArgs, mopts ...make.MakeOption) error {
+	if err := os.MkdirAll(targ.OutDir, 0o755); err != nil {
+		return fmt.Errorf("could not create output directory: %v", err)
+	}
+
+	defconfig := targ.KConfigFile() + ".defconfig"
+
+	dc := kconfig.NewDotConfig()
+	values := a.TargetKConfig(targ)
+
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		dc.Set(k, *values[k])
+	}
+
+	if err := dc.WriteFile(defconfig); err != nil {
+		return fmt.Errorf("could not write target configuration: %v", err)
+	}
+
+	return a.make(args, append(mopts,
 		make.WithExecOptions(
-			exec.WithEnvKey(unikraft.UK_DEFCONFIG, tmpfile.Name()),
+			exec.WithEnvKey(unikraft.UK_DEFCONFIG, defconfig),
 		),
-	)
-
-	return a.DefConfig(mopts...)
+		make.WithTarget("defconfig"),
+	)...)
 }
 
-// Build offers an invocation of the Unikraft build system with the contextual
-// information of the ApplicationConfigs
-func (a *ApplicationConfig) Build(opts ...BuildOption) error {
+// buildOptions applies the provided build options and populates the make
+// options which are common to every invocation of Unikraft's build system
+func (a *ApplicationConfig) buildOptions(opts ...BuildOption) (*BuildOptions, error) {
 	bopts := &BuildOptions{}
 	for _, o := range opts {
 		err := o(bopts)
 		if err != nil {
-			return fmt.Errorf("could not apply build option: %v", err)
+			return nil, fmt.Errorf("could not apply build option: %v", err)
 		}
 	}
 
 	if !a.Unikraft.IsUnpackedInProject(a.WorkingDir) {
 		// TODO: Produce better error messages (see #34).  In this case, we should
 		// indicate that `kraft pkg pull` needs to occur
-		return fmt.Errorf("cannot build without Unikraft core component source")
+		return nil, fmt.Errorf("cannot build without Unikraft core component source")
 	}
 
 	eopts := []exec.ExecOption{}
@@ -326,16 +693,99 @@ func (a *ApplicationConfig) Build(opts ...BuildOption) error {
 		make.WithExecOptions(eopts...),
 	}...)
 
-	if !bopts.noSyncConfig {
-		if err := a.SyncConfig(append(
+	return bopts, nil
+}
+
+// prepareTarget configures the target and synchronizes its configuration
+func (a *ApplicationConfig) prepareTarget(targ *target.TargetConfig, args *core.MakeArgs, bopts *BuildOptions) error {
+	mopts := append(bopts.mopts, make.WithProgressFunc(nil))
+
+	if err := a.configureTarget(targ, args, mopts...); err != nil {
+		return fmt.Errorf("could not configure target %s: %v", targ.Name(), err)
+	}
+
+	if bopts.noSyncConfig {
+		return nil
+	}
+
+	return a.make(args, append(mopts, make.WithTarget("syncconfig"))...)
+}
+
+// PrepareTargets configures the provided targets and fetches and prepares the
+// sources of their components such that they can subsequently be built
+// without accessing the network, e.g. concurrently with
+// `WithBuildNoPrepare`.  The sources are fetched into the output directory of
+// each target, i.e. once per target.
+func (a *ApplicationConfig) PrepareTargets(opts ...BuildOption) error {
+	bopts, err := a.buildOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	for i := range bopts.target {
+		targ := &bopts.target[i]
+
+		args, err := a.TargetMakeArgs(targ)
+		if err != nil {
+			return err
+		}
+
+		if err := a.prepareTarget(targ, args, bopts); err != nil {
+			return err
+		}
+
+		if err := a.make(args, append(
 			bopts.mopts,
-			make.WithProgressFunc(nil),
+			make.WithTarget("prepare"),
 		)...); err != nil {
+			return fmt.Errorf("could not prepare target %s: %v", targ.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Build offers an invocation of the Unikraft build system with the contextual
+// information of the ApplicationConfigs
+func (a *ApplicationConfig) Build(opts ...BuildOption) error {
+	bopts, err := a.buildOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(bopts.target) == 0 {
+		if !bopts.noSyncConfig {
+			if err := a.SyncConfig(append(
+				bopts.mopts,
+				make.WithProgressFunc(nil),
+			)...); err != nil {
+				return err
+			}
+		}
+
+		return a.Make(bopts.mopts...)
+	}
+
+	for i := range bopts.target {
+		targ := &bopts.target[i]
+
+		args, err := a.TargetMakeArgs(targ)
+		if err != nil {
+			return err
+		}
+
+		if !bopts.noPrepare {
+			if err := a.prepareTarget(targ, args, bopts); err != nil {
+				return err
+			}
+		}
+
+		if err := a.make(args, bopts.mopts...); err != nil {
 			return err
 		}
 	}
 
-	return a.Make(bopts.mopts...)
+	return nil
 }
 
 // LibraryNames return names for all libraries in this Compose config
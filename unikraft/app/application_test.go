@@ -0,0 +1,412 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/arch"
+	"kraftkit.sh/unikraft/component"
+	"kraftkit.sh/unikraft/core"
+	"kraftkit.sh/unikraft/lib"
+	"kraftkit.sh/unikraft/plat"
+	"kraftkit.sh/unikraft/target"
+)
+
+func newKConfig(values ...string) component.KConfig {
+	return component.NewKConfig(values)
+}
+
+func TestTargetKConfig(t *testing.T) {
+	a := ApplicationConfig{
+		ComponentConfig: component.ComponentConfig{
+			Configuration: newKConfig("CONFIG_APP=y", "CONFIG_LEVEL=app"),
+		},
+		Unikraft: core.UnikraftConfig{
+			ComponentConfig: component.ComponentConfig{
+				Configuration: newKConfig("CONFIG_CORE=y", "CONFIG_LEVEL=core", "CONFIG_LIB=core"),
+			},
+		},
+		Libraries: lib.Libraries{
+			"musl": lib.LibraryConfig{
+				ComponentConfig: component.ComponentConfig{
+					Configuration: newKConfig("CONFIG_LIB=musl", "CONFIG_LEVEL=lib"),
+				},
+			},
+		},
+		Configuration: map[string]string{
+			"CONFIG_UK_NAME": "helloworld",
+			"CONFIG_UNUSED":  "y",
+		},
+	}
+
+	targ := target.TargetConfig{
+		ComponentConfig: component.ComponentConfig{
+			Configuration: newKConfig("CONFIG_LEVEL=target", "CONFIG_EMPTY"),
+		},
+		Architecture: arch.ArchitectureConfig{
+			ComponentConfig: component.ComponentConfig{
+				Name:          "arm64",
+				Configuration: newKConfig("CONFIG_LEVEL=arch", "CONFIG_ARCH=y"),
+			},
+		},
+		Platform: plat.PlatformConfig{
+			ComponentConfig: component.ComponentConfig{
+				Name:          "kvm",
+				Configuration: newKConfig("CONFIG_LEVEL=plat", "CONFIG_PLAT=y"),
+			},
+		},
+	}
+
+	expected := map[string]string{
+		"CONFIG_UK_NAME":     "helloworld",
+		"CONFIG_CORE":        "y",
+		"CONFIG_LIB":         "musl",
+		"CONFIG_APP":         "y",
+		"CONFIG_ARCH":        "y",
+		"CONFIG_PLAT":        "y",
+		"CONFIG_LEVEL":       "target",
+		"CONFIG_ARCH_ARM_64": "y",
+		"CONFIG_PLAT_KVM":    "y",
+	}
+
+	values := a.TargetKConfig(&targ)
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d options but got %d: %v", len(expected), len(values), values)
+	}
+
+	for k, v := range expected {
+		if got, ok := values[k]; !ok || *got != v {
+			t.Errorf("expected %s=%s", k, v)
+		}
+	}
+	origins := a.TargetKConfigOrigins(&targ)
+	for k, origin := range map[string]string{
+		"CONFIG_UK_NAME":     "app",
+		"CONFIG_CORE":        "core",
+		"CONFIG_LIB":         "lib/musl",
+		"CONFIG_LEVEL":       "target/",
+		"CONFIG_ARCH_ARM_64": "arch/arm64",
+		"CONFIG_PLAT_KVM":    "plat/kvm",
+	} {
+		if origins[k] != origin {
+			t.Errorf("expected %s to originate from %s, got %s", k, origin, origins[k])
+		}
+	}
+
+	if _, ok := origins["CONFIG_EMPTY"]; ok {
+		t.Errorf("expected CONFIG_EMPTY to be removed")
+	}
+}
+
+func TestSaveKConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "Kraftfile")
+
+	if err := os.WriteFile(filename, []byte(`specification: "0.5"
+# The core is pinned
+unikraft: stable
+targets:
+  - name: kvm
+    architecture: x86_64
+    platform: kvm
+  - name: xen
+    architecture: arm64
+    platform: xen
+    kconfig:
+      - CONFIG_LIBUKDEBUG=n
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := ApplicationConfig{
+		Filename: filename,
+		Targets: target.Targets{
+			{ComponentConfig: component.ComponentConfig{Name: "kvm"}},
+			{ComponentConfig: component.ComponentConfig{Name: "xen"}},
+		},
+	}
+
+	if err := a.SaveKConfig("", map[string]string{
+		"CONFIG_LIBUKDEBUG_BUFSIZE": "256",
+		"CONFIG_LIBUKDEBUG":         "y",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.SaveKConfig("xen", map[string]string{
+		"CONFIG_LIBUKDEBUG":        "y",
+		"CONFIG_LIBUKDEBUG_PRINTK": "n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.SaveKConfig("qemu", nil); err == nil {
+		t.Errorf("expected unknown target")
+	}
+
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `specification: "0.5"
+# The core is pinned
+unikraft:
+  version: stable
+  kconfig:
+    CONFIG_LIBUKDEBUG: "y"
+    CONFIG_LIBUKDEBUG_BUFSIZE: "256"
+targets:
+  - name: kvm
+    architecture: x86_64
+    platform: kvm
+  - name: xen
+    architecture: arm64
+    platform: xen
+    kconfig:
+      - CONFIG_LIBUKDEBUG=y
+      - CONFIG_LIBUKDEBUG_PRINTK=n
+`
+	if string(raw) != expected {
+		t.Errorf("unexpected Kraftfile:\n%s", raw)
+	}
+}
+
+func TestSetPrepare(t *testing.T) {
+	workdir := t.TempDir()
+	coreSrc := filepath.Join(workdir, ".unikraft", "unikraft")
+
+	for path, content := range map[string]string{
+		filepath.Join(workdir, "Kraftfile"): `specification: "0.5"
+unikraft: stable
+targets:
+  - name: kvm
+    architecture: x86_64
+    platform: kvm
+`,
+		filepath.Join(coreSrc, "Config.uk"): `config LIBFOO
+	bool "foo"
+	default n
+
+config LIBBAR
+	bool "bar"
+	default n
+`,
+		// The configuration of the target is generated from the defconfig
+		filepath.Join(coreSrc, "Makefile"): "defconfig:\n\tcp $(UK_DEFCONFIG) $(C)\n\nsyncconfig prepare:\n\t@true\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := ApplicationConfig{
+		WorkingDir: workdir,
+		Filename:   filepath.Join(workdir, "Kraftfile"),
+		Unikraft: core.UnikraftConfig{
+			ComponentConfig: component.ComponentConfig{Name: "unikraft"},
+		},
+		Targets: target.Targets{{
+			ComponentConfig: component.ComponentConfig{Name: "kvm"},
+			Architecture: arch.ArchitectureConfig{
+				ComponentConfig: component.ComponentConfig{Name: "x86_64"},
+			},
+			Platform: plat.PlatformConfig{
+				ComponentConfig: component.ComponentConfig{Name: "kvm"},
+			},
+			OutDir: filepath.Join(workdir, ".unikraft", "build", "kvm"),
+		}},
+	}
+
+	if err := a.Unikraft.ApplyOptions(
+		component.WithWorkdir(workdir),
+		component.WithType(unikraft.ComponentTypeCore),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Targets[0].Platform.ApplyOptions(
+		component.WithWorkdir(workdir),
+		component.WithType(unikraft.ComponentTypePlat),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	prepare := func() string {
+		if err := a.PrepareTargets(WithBuildTarget(a.Targets...)); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := os.ReadFile(a.Targets[0].KConfigFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(raw)
+	}
+
+	prepare()
+
+	if err := a.Set("kvm", "LIBFOO=y"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Set("", "CONFIG_LIBBAR=y"); err != nil {
+		t.Fatal(err)
+	}
+
+	dotconfig := prepare()
+	for _, expected := range []string{"CONFIG_LIBFOO=y", "CONFIG_LIBBAR=y"} {
+		if !strings.Contains(dotconfig, expected) {
+			t.Errorf("expected %s after prepare:\n%s", expected, dotconfig)
+		}
+	}
+
+	kraftfile, err := os.ReadFile(a.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `specification: "0.5"
+unikraft:
+  version: stable
+  kconfig:
+    CONFIG_LIBBAR: "y"
+targets:
+  - name: kvm
+    architecture: x86_64
+    platform: kvm
+    kconfig:
+      CONFIG_LIBFOO: "y"
+`
+	if string(kraftfile) != expected {
+		t.Errorf("unexpected Kraftfile:\n%s", kraftfile)
+	}
+
+	if err := a.Unset("kvm", "LIBFOO"); err != nil {
+		t.Fatal(err)
+	}
+
+	if dotconfig := prepare(); strings.Contains(dotconfig, "CONFIG_LIBFOO=y") {
+		t.Errorf("expected CONFIG_LIBFOO to be unset after prepare:\n%s", dotconfig)
+	}
+
+	kraftfile, err = os.ReadFile(a.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `specification: "0.5"
+unikraft:
+  version: stable
+  kconfig:
+    CONFIG_LIBBAR: "y"
+targets:
+  - name: kvm
+    architecture: x86_64
+    platform: kvm
+`
+	if string(kraftfile) != expected {
+		t.Errorf("unexpected Kraftfile:\n%s", kraftfile)
+	}
+}
+
+func TestKConfigTreeEnv(t *testing.T) {
+	workdir := t.TempDir()
+	coreSrc := filepath.Join(workdir, ".unikraft", "unikraft")
+
+	for path, content := range map[string]string{
+		filepath.Join(coreSrc, "Config.uk"): `mainmenu "Unikraft/$(UK_FULLVERSION) ($(UK_CODENAME)) for $(UK_ARCH)"
+`,
+		filepath.Join(coreSrc, "Makefile"): "UK_VERSION = 0\nUK_SUBVERSION = 11\nUK_EXTRAVERSION = 0\nUK_CODENAME = Cassiopeia\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := ApplicationConfig{
+		WorkingDir: workdir,
+		Unikraft: core.UnikraftConfig{
+			ComponentConfig: component.ComponentConfig{Name: "unikraft"},
+		},
+		Targets: target.Targets{{
+			ComponentConfig: component.ComponentConfig{Name: "arm"},
+			Architecture: arch.ArchitectureConfig{
+				ComponentConfig: component.ComponentConfig{Name: "arm64"},
+			},
+			Platform: plat.PlatformConfig{
+				ComponentConfig: component.ComponentConfig{Name: "xen"},
+			},
+			OutDir: filepath.Join(workdir, ".unikraft", "build", "arm"),
+		}},
+	}
+
+	if err := a.Unikraft.ApplyOptions(
+		component.WithWorkdir(workdir),
+		component.WithType(unikraft.ComponentTypeCore),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Targets[0].Platform.ApplyOptions(
+		component.WithWorkdir(workdir),
+		component.WithType(unikraft.ComponentTypePlat),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := a.KConfigTree("arm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.MainMenu != "Unikraft/0.11.0 (Cassiopeia) for arm64" {
+		t.Errorf("unexpected main menu: %s", tree.MainMenu)
+	}
+
+	if _, err := a.KConfigTree("unknown"); err == nil {
+		t.Error("expected an unknown target to fail")
+	}
+}
@@ -47,6 +47,7 @@ type BuildOptions struct {
 	mopts        []make.MakeOption
 	onProgress   func(progress float64)
 	noSyncConfig bool
+	noPrepare    bool
 }
 
 type BuildOption func(opts *BuildOptions) error
@@ -128,3 +129,13 @@ func WithBuildNoSyncConfig(noSyncConfig bool) BuildOption {
 		return nil
 	}
 }
+
+// WithBuildNoPrepare skips configuring the targets and synchronizing their
+// configuration before building them, as they have already been prepared with
+// `PrepareTargets`.
+func WithBuildNoPrepare(noPrepare bool) BuildOption {
+	return func(bo *BuildOptions) error {
+		bo.noPrepare = noPrepare
+		return nil
+	}
+}
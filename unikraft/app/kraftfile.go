@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/internal/yamlnode"
+)
+
+// SaveKConfig writes the provided configuration options to the `kconfig`
+// section of the Unikraft core in the application's Kraftfile or, if a target
+// is named, to that of the target.  The remainder of the Kraftfile, including
+// its comments, is preserved.
+func (a *ApplicationConfig) SaveKConfig(targetName string, values map[string]string) error {
+	return a.editKConfig(targetName, func(section *yaml.Node) bool {
+		kconfig := yamlnode.Lookup(section, "kconfig")
+		if kconfig == nil {
+			kconfig = &yaml.Node{}
+			section.Content = append(section.Content, scalarNode("kconfig", 0), kconfig)
+		}
+
+		// An empty section, i.e. `kconfig:`, is written as a mapping
+		if kconfig.Kind != yaml.MappingNode && kconfig.Kind != yaml.SequenceNode {
+			*kconfig = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			setKConfigNode(kconfig, name, values[name])
+		}
+
+		return true
+	})
+}
+
+// RemoveKConfig removes the named configuration options from the `kconfig`
+// section of the Unikraft core in the application's Kraftfile or, if a target
+// is named, from that of the target.
+func (a *ApplicationConfig) RemoveKConfig(targetName string, names ...string) error {
+	return a.editKConfig(targetName, func(section *yaml.Node) bool {
+		kconfig := yamlnode.Lookup(section, "kconfig")
+		if kconfig == nil {
+			return false
+		}
+
+		removed := false
+		for _, name := range names {
+			if unsetKConfigNode(kconfig, name) {
+				removed = true
+			}
+		}
+
+		// Drop the section altogether once it no longer holds any option
+		if removed && len(kconfig.Content) == 0 {
+			unsetKConfigNode(section, "kconfig")
+		}
+
+		return removed
+	})
+}
+
+// editKConfig applies the edit to the mapping of the Kraftfile which holds the
+// `kconfig` section of the Unikraft core or of the named target and writes the
+// Kraftfile back if the edit reports a change
+func (a *ApplicationConfig) editKConfig(targetName string, edit func(section *yaml.Node) bool) error {
+	if len(a.Filename) == 0 {
+		return fmt.Errorf("application has no Kraftfile")
+	}
+
+	raw, err := os.ReadFile(a.Filename)
+	if err != nil {
+		return fmt.Errorf("could not read Kraftfile: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("could not parse Kraftfile: %v", err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("could not parse Kraftfile: expected a mapping")
+	}
+
+	section, err := a.kconfigSection(doc.Content[0], targetName)
+	if err != nil {
+		return err
+	}
+
+	if !edit(section) {
+		return nil
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("could not encode Kraftfile: %v", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("could not encode Kraftfile: %v", err)
+	}
+
+	fi, err := os.Stat(a.Filename)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(a.Filename, b.Bytes(), fi.Mode())
+}
+
+// kconfigSection returns the mapping of the Kraftfile which holds the `kconfig`
+// section of the Unikraft core or of the named target
+func (a *ApplicationConfig) kconfigSection(root *yaml.Node, targetName string) (*yaml.Node, error) {
+	if len(targetName) == 0 {
+		uk := yamlnode.Lookup(root, "unikraft")
+		if uk == nil {
+			return nil, fmt.Errorf("Kraftfile does not specify the unikraft component")
+		}
+
+		// Expand the short syntax, e.g. `unikraft: stable`, as it cannot hold
+		// configuration options
+		if uk.Kind == yaml.ScalarNode {
+			version := *uk
+			*uk = yaml.Node{
+				Kind:    yaml.MappingNode,
+				Tag:     "!!map",
+				Content: []*yaml.Node{scalarNode("version", 0), &version},
+			}
+		}
+
+		if uk.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("could not parse Kraftfile: unikraft is not a mapping")
+		}
+
+		return uk, nil
+	}
+
+	// Targets are identified by their position, as unnamed targets are named
+	// only once loaded
+	index := -1
+	for i, targ := range a.Targets {
+		if targ.Name() == targetName {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("unknown target: %s", targetName)
+	}
+
+	targets := yamlnode.Lookup(root, "targets")
+	if targets == nil || targets.Kind != yaml.SequenceNode || len(targets.Content) != len(a.Targets) {
+		return nil, fmt.Errorf("could not find target %s in Kraftfile", targetName)
+	}
+
+	targ := targets.Content[index]
+	if targ.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("could not parse Kraftfile: target %s is not a mapping", targetName)
+	}
+
+	return targ, nil
+}
+
+// setKConfigNode assigns the configuration option in a `kconfig` section,
+// which is either a mapping or a list of `NAME=VALUE` entries
+func setKConfigNode(kconfig *yaml.Node, name, value string) {
+	switch kconfig.Kind {
+	case yaml.MappingNode:
+		// Values are always quoted such that e.g. `y` is not read as a boolean
+		if node := yamlnode.Lookup(kconfig, name); node != nil {
+			*node = *scalarNode(value, yaml.DoubleQuotedStyle)
+			return
+		}
+
+		kconfig.Content = append(kconfig.Content,
+			scalarNode(name, 0),
+			scalarNode(value, yaml.DoubleQuotedStyle),
+		)
+
+	case yaml.SequenceNode:
+		entry := name + "=" + value
+
+		for _, node := range kconfig.Content {
+			if node.Value == name || strings.HasPrefix(node.Value, name+"=") {
+				*node = *scalarNode(entry, 0)
+				return
+			}
+		}
+
+		kconfig.Content = append(kconfig.Content, scalarNode(entry, 0))
+	}
+}
+
+// unsetKConfigNode removes the configuration option from a `kconfig` section
+// and reports whether it was present
+func unsetKConfigNode(kconfig *yaml.Node, name string) bool {
+	switch kconfig.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(kconfig.Content); i += 2 {
+			if kconfig.Content[i].Value == name {
+				kconfig.Content = append(kconfig.Content[:i], kconfig.Content[i+2:]...)
+				return true
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, node := range kconfig.Content {
+			if node.Value == name || strings.HasPrefix(node.Value, name+"=") {
+				kconfig.Content = append(kconfig.Content[:i], kconfig.Content[i+1:]...)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// scalarNode returns a YAML string in the provided style
+func scalarNode(value string, style yaml.Style) *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: value,
+		Style: style,
+	}
+}
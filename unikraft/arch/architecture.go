@@ -33,6 +33,8 @@ package arch
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/unikraft"
@@ -44,7 +46,7 @@ type Architecture interface {
 }
 
 type ArchitectureConfig struct {
-	component.ComponentConfig
+	component.ComponentConfig `mapstructure:",squash"`
 }
 
 // ParseArchitectureConfig parse short syntax for architecture configuration
@@ -60,6 +62,21 @@ func ParseArchitectureConfig(value string) (ArchitectureConfig, error) {
 	return architecture, nil
 }
 
+// HostArchitecture returns the name of the Unikraft architecture matching the
+// host, or an empty string if the host's architecture is not supported
+func HostArchitecture() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "arm64"
+	case "arm":
+		return "arm"
+	}
+
+	return ""
+}
+
 func (ac ArchitectureConfig) Name() string {
 	return ac.ComponentConfig.Name
 }
@@ -68,6 +85,21 @@ func (ac ArchitectureConfig) Version() string {
 	return ac.ComponentConfig.Version
 }
 
+// KConfigSymbol returns the name of the KConfig option which selects the
+// architecture within Unikraft's build system
+func (ac ArchitectureConfig) KConfigSymbol() string {
+	switch ac.Name() {
+	case "x86_64":
+		return "CONFIG_ARCH_X86_64"
+	case "arm64":
+		return "CONFIG_ARCH_ARM_64"
+	case "arm":
+		return "CONFIG_ARCH_ARM_32"
+	}
+
+	return "CONFIG_ARCH_" + strings.ToUpper(strings.ReplaceAll(ac.Name(), "-", "_"))
+}
+
 func (ac ArchitectureConfig) Type() unikraft.ComponentType {
 	return unikraft.ComponentTypeArch
 }
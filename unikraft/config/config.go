@@ -35,10 +35,12 @@ import (
 	"os"
 	"path/filepath"
 
+	"kraftkit.sh/network"
 	"kraftkit.sh/unikraft/component"
 	"kraftkit.sh/unikraft/core"
 	"kraftkit.sh/unikraft/lib"
 	"kraftkit.sh/unikraft/target"
+	"kraftkit.sh/unikraft/volume"
 )
 
 // ConfigDetails are the details about a group of ConfigFiles
@@ -90,6 +92,8 @@ type Config struct {
 	Unikraft  core.UnikraftConfig `yaml:",omitempty" json:"unikraft,omitempty"`
 	Libraries lib.Libraries       `yaml:",omitempty" json:"libraries,omitempty"`
 	Targets   target.Targets      `yaml:",omitempty" json:"targets,omitempty"`
+	Networks  network.Networks    `yaml:",omitempty" json:"networks,omitempty"`
+	Volumes   volume.Volumes      `yaml:",omitempty" json:"volumes,omitempty"`
 
 	Extensions component.Extensions `yaml:",inline" json:"-"`
 }
@@ -32,7 +32,12 @@
 package core
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/unikraft"
@@ -63,3 +68,46 @@ func (uc UnikraftConfig) PrintInfo(io *iostreams.IOStreams) error {
 	fmt.Fprint(io.Out, "not implemented: unikraft.core.UnikraftConfig.PrintInfo")
 	return nil
 }
+
+var releaseRe = regexp.MustCompile(`^(UK_VERSION|UK_SUBVERSION|UK_EXTRAVERSION|UK_CODENAME)\s*[:?]?=\s*(.*)$`)
+
+// Release reads the full version (e.g. `0.11.0`) and the codename of the
+// Unikraft core from the `Makefile` of its source directory.  Values which are
+// not defined are returned empty.
+func (uc UnikraftConfig) Release() (string, string, error) {
+	src, err := uc.SourceDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	f, err := os.Open(filepath.Join(src, "Makefile"))
+	if err != nil {
+		return "", "", err
+	}
+
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if m := releaseRe.FindStringSubmatch(strings.TrimSpace(scanner.Text())); m != nil {
+			values[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	if len(values["UK_VERSION"]) == 0 || len(values["UK_SUBVERSION"]) == 0 {
+		return "", values["UK_CODENAME"], nil
+	}
+
+	version := values["UK_VERSION"] + "." + values["UK_SUBVERSION"]
+	if extra := values["UK_EXTRAVERSION"]; len(extra) > 0 {
+		version += "." + extra
+	}
+
+	return version, values["UK_CODENAME"], nil
+}
@@ -33,6 +33,7 @@ package plat
 
 import (
 	"fmt"
+	"strings"
 
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/unikraft"
@@ -44,7 +45,22 @@ type Platform interface {
 }
 
 type PlatformConfig struct {
-	component.ComponentConfig
+	component.ComponentConfig `mapstructure:",squash"`
+
+	// PreUp is the command which is executed on the host before the unikernel
+	// is started on this platform
+	PreUp []string `yaml:"pre_up,omitempty" json:"pre_up,omitempty"`
+
+	// PostDown is the command which is executed on the host after the unikernel
+	// has exited on this platform
+	PostDown []string `yaml:"post_down,omitempty" json:"post_down,omitempty"`
+
+	// CPUs is the number of virtual CPUs to allocate to the unikernel
+	CPUs int `yaml:",omitempty" json:"cpus,omitempty"`
+
+	// Memory is the amount of memory to allocate to the unikernel, either in
+	// megabytes or with a unit suffix, e.g. "64M"
+	Memory string `yaml:",omitempty" json:"memory,omitempty"`
 }
 
 // ParsePlatformConfig parse short syntax for platform configuration
@@ -68,6 +84,12 @@ func (pc PlatformConfig) Version() string {
 	return pc.ComponentConfig.Version
 }
 
+// KConfigSymbol returns the name of the KConfig option which selects the
+// platform within Unikraft's build system, e.g. `CONFIG_PLAT_KVM`
+func (pc PlatformConfig) KConfigSymbol() string {
+	return "CONFIG_PLAT_" + strings.ToUpper(strings.ReplaceAll(pc.Name(), "-", "_"))
+}
+
 func (pc PlatformConfig) Type() unikraft.ComponentType {
 	return unikraft.ComponentTypePlat
 }
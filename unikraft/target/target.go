@@ -33,6 +33,7 @@ package target
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"kraftkit.sh/initrd"
 	"kraftkit.sh/iostreams"
@@ -48,10 +49,11 @@ type TargetConfig struct {
 	Architecture arch.ArchitectureConfig `yaml:",omitempty" json:"architecture,omitempty"`
 	Platform     plat.PlatformConfig     `yaml:",omitempty" json:"platform,omitempty"`
 	Format       string                  `yaml:",omitempty" json:"format,omitempty"`
+	OutDir       string                  `yaml:",omitempty" json:"outdir,omitempty"`
 	Kernel       string                  `yaml:",omitempty" json:"kernel,omitempty"`
 	KernelDbg    string                  `yaml:",omitempty" json:"kerneldbg,omitempty"`
 	Initrd       *initrd.InitrdConfig    `yaml:",omitempty" json:"initrd,omitempty"`
-	Command      []string                `yaml:",omitempty" json:"commands"`
+	Command      Command                 `yaml:",omitempty" json:"commands"`
 
 	Extensions map[string]interface{} `yaml:",inline" json:"-"`
 }
@@ -76,6 +78,12 @@ func (tc *TargetConfig) ArchPlatString() string {
 	return tc.Platform.Name() + "-" + tc.Architecture.Name()
 }
 
+// KConfigFile returns the path to the target-specific `.config` file, which is
+// generated within the target's output directory
+func (tc *TargetConfig) KConfigFile() string {
+	return filepath.Join(tc.OutDir, ".config")
+}
+
 func (tc TargetConfig) PrintInfo(io *iostreams.IOStreams) error {
 	fmt.Fprint(io.Out, "not implemented: unikraft.plat.TargetConfig.PrintInfo")
 	return nil
@@ -77,7 +77,7 @@ func GuessTypeNameVersion(input string) (ComponentType, string, string, error) {
 		`(?i)^` +
 			`(?:(?P<type>(?:lib|app|plat|arch)s?)[\-/])?` +
 			`(?P<name>[\w\-\_\*]*)` +
-			`(?:\:(?P<version>[\w\.\-\_]*))?` +
+			`(?:\:(?P<version>[\w\.\-\_\^\~<>=!,]*))?` +
 			`$`,
 	)
 
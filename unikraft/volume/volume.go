@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package volume
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type VolumeType string
+
+const (
+	// VolumeType9pfs shares a directory of the host with the unikernel
+	VolumeType9pfs VolumeType = "9pfs"
+
+	// VolumeTypeRaw attaches a raw disk image to the unikernel
+	VolumeTypeRaw VolumeType = "raw"
+
+	// NameDelimeter separates the name of a volume from its source in the short
+	// syntax, e.g. `fs0=./rootfs`
+	NameDelimeter = "="
+)
+
+// VolumeConfig represents storage which is attached to a unikernel
+type VolumeConfig struct {
+	// Name of the volume which, for 9pfs volumes, is also the mount tag
+	Name string `yaml:"-" json:"-"`
+
+	// Type of the volume
+	Type VolumeType `yaml:",omitempty" json:"type,omitempty"`
+
+	// Source is the path on the host to the directory or disk image
+	Source string `yaml:",omitempty" json:"source,omitempty"`
+}
+
+type Volumes map[string]VolumeConfig
+
+// Sorted returns the volumes ordered by their name such that they are always
+// attached to the unikernel in the same order
+func (v Volumes) Sorted() []VolumeConfig {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	volumes := make([]VolumeConfig, 0, len(v))
+	for _, name := range names {
+		vol := v[name]
+		vol.Name = name
+		volumes = append(volumes, vol)
+	}
+
+	return volumes
+}
+
+// ParseVolumeConfig parses the short syntax for a volume, which is either the
+// path to its source or its name and source separated by `=`.  When the name
+// is omitted, the provided default name is used.  Relative sources are
+// resolved against the working directory and the type of the volume is
+// derived from the source.
+func ParseVolumeConfig(workdir, value, name string) (VolumeConfig, error) {
+	volume := VolumeConfig{
+		Name:   name,
+		Source: value,
+	}
+
+	if len(value) == 0 {
+		return volume, fmt.Errorf("cannot ommit volume source")
+	}
+
+	if parts := strings.SplitN(value, NameDelimeter, 2); len(parts) == 2 {
+		volume.Name = parts[0]
+		volume.Source = parts[1]
+	}
+
+	if !filepath.IsAbs(volume.Source) {
+		volume.Source = filepath.Join(workdir, volume.Source)
+	}
+
+	volume.Type = DetectType(volume.Source)
+
+	return volume, nil
+}
+
+// Extend parses the provided volumes in the short syntax, e.g. as supplied via
+// the command-line, and returns them after the volumes of the project.  Volumes
+// without a name are named `fsN` after the next index which is not yet in use
+// and volumes whose name is already in use are rejected.
+func (v Volumes) Extend(workdir string, values ...string) ([]VolumeConfig, error) {
+	volumes := v.Sorted()
+
+	used := map[string]bool{}
+	for name := range v {
+		used[name] = true
+	}
+
+	next := 0
+
+	for _, value := range values {
+		vol, err := ParseVolumeConfig(workdir, value, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not parse volume %s: %v", value, err)
+		}
+
+		if len(vol.Name) == 0 {
+			for used[fmt.Sprintf("fs%d", next)] {
+				next++
+			}
+
+			vol.Name = fmt.Sprintf("fs%d", next)
+		} else if used[vol.Name] {
+			return nil, fmt.Errorf("could not add volume %s: name %s is already in use", value, vol.Name)
+		}
+
+		used[vol.Name] = true
+		volumes = append(volumes, vol)
+	}
+
+	return volumes, nil
+}
+
+// DetectType returns the type of volume suitable for the source: a raw disk
+// for files and otherwise a 9pfs share
+func DetectType(source string) VolumeType {
+	if f, err := os.Stat(source); err == nil && !f.IsDir() {
+		return VolumeTypeRaw
+	}
+
+	return VolumeType9pfs
+}
+
+// Validate checks that the source of the volume exists and is compatible with
+// its type
+func (vc VolumeConfig) Validate() error {
+	if len(vc.Name) == 0 {
+		return fmt.Errorf("volume name cannot be empty")
+	}
+
+	f, err := os.Stat(vc.Source)
+	if err != nil {
+		return fmt.Errorf("volume %s: could not access source: %v", vc.Name, err)
+	}
+
+	switch vc.Type {
+	case VolumeType9pfs:
+		if !f.IsDir() {
+			return fmt.Errorf("volume %s: source of 9pfs volume must be a directory: %s", vc.Name, vc.Source)
+		}
+	case VolumeTypeRaw:
+		if !f.Mode().IsRegular() {
+			return fmt.Errorf("volume %s: source of raw volume must be a disk image: %s", vc.Name, vc.Source)
+		}
+	default:
+		return fmt.Errorf("volume %s: unsupported volume type: %s", vc.Name, vc.Type)
+	}
+
+	return nil
+}
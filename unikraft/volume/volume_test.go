@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// Authors: Alexander Jung <alex@unikraft.io>
+//
+// Copyright (c) 2022, Unikraft GmbH.  All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions
+// are met:
+//
+// 1. Redistributions of source code must retain the above copyright
+//    notice, this list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright
+//    notice, this list of conditions and the following disclaimer in the
+//    documentation and/or other materials provided with the distribution.
+// 3. Neither the name of the copyright holder nor the names of its
+//    contributors may be used to endorse or promote products derived from
+//    this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package volume
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "disk.img"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value  string
+		name   string
+		source string
+		vtype  VolumeType
+	}{
+		{value: ".", name: "fs0", source: dir, vtype: VolumeType9pfs},
+		{value: "rootfs=.", name: "rootfs", source: dir, vtype: VolumeType9pfs},
+		{value: "disk.img", name: "fs0", source: filepath.Join(dir, "disk.img"), vtype: VolumeTypeRaw},
+		{value: "data=/srv/data", name: "data", source: "/srv/data", vtype: VolumeType9pfs},
+	}
+
+	for _, test := range tests {
+		vol, err := ParseVolumeConfig(dir, test.value, "fs0")
+		if err != nil {
+			t.Fatalf("could not parse %s: %v", test.value, err)
+		}
+
+		if vol.Name != test.name || vol.Source != test.source || vol.Type != test.vtype {
+			t.Errorf("unexpected volume for %s: %+v", test.value, vol)
+		}
+	}
+
+	if _, err := ParseVolumeConfig(dir, "", "fs0"); err == nil {
+		t.Errorf("expected error for empty volume")
+	}
+}
+
+func TestVolumeValidate(t *testing.T) {
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "disk.img")
+	if err := ioutil.WriteFile(disk, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := []VolumeConfig{
+		{Name: "fs0", Type: VolumeType9pfs, Source: dir},
+		{Name: "disk0", Type: VolumeTypeRaw, Source: disk},
+	}
+	for _, vol := range valid {
+		if err := vol.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", vol, err)
+		}
+	}
+
+	invalid := []VolumeConfig{
+		{Type: VolumeType9pfs, Source: dir},
+		{Name: "fs0", Type: VolumeType9pfs, Source: disk},
+		{Name: "disk0", Type: VolumeTypeRaw, Source: dir},
+		{Name: "fs0", Type: "nfs", Source: dir},
+		{Name: "fs0", Type: VolumeType9pfs, Source: filepath.Join(dir, "missing")},
+	}
+	for _, vol := range invalid {
+		if err := vol.Validate(); err == nil {
+			t.Errorf("expected error for %+v", vol)
+		}
+	}
+}
+
+func TestVolumesExtend(t *testing.T) {
+	dir := t.TempDir()
+
+	project := Volumes{
+		"fs1":    {Type: VolumeType9pfs, Source: "/srv/fs1"},
+		"rootfs": {Type: VolumeType9pfs, Source: "/srv/rootfs"},
+	}
+
+	volumes, err := project.Extend(dir, "a", "data=b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, vol := range volumes {
+		names = append(names, vol.Name)
+	}
+
+	if strings.Join(names, ",") != "fs1,rootfs,fs0,data,fs2" {
+		t.Errorf("unexpected volume names: %v", names)
+	}
+
+	if _, err := project.Extend(dir, "rootfs=a"); err == nil {
+		t.Error("expected error for a volume name which is already in use")
+	}
+
+	if _, err := project.Extend(dir, "data=a", "data=b"); err == nil {
+		t.Error("expected error for a volume name which is supplied twice")
+	}
+}